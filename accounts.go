@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/internal/fx"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// accountKinds are the valid kinds of an account.
+var accountKinds = map[string]bool{
+	"card":   true,
+	"bank":   true,
+	"upi":    true,
+	"wallet": true,
+	"cash":   true,
+	"other":  true,
+}
+
+// validateAccount normalises the account input and checks that it's valid.
+func (a *App) validateAccount(acc *models.Account) error {
+	acc.Name = strings.TrimSpace(acc.Name)
+	if acc.Name == "" {
+		return errors.New("name is required")
+	}
+
+	acc.Kind = strings.ToLower(strings.TrimSpace(acc.Kind))
+	if acc.Kind == "" {
+		acc.Kind = "other"
+	}
+	if !accountKinds[acc.Kind] {
+		return fmt.Errorf("invalid kind %q, use one of card, bank, upi, wallet, cash, other", acc.Kind)
+	}
+
+	acc.Currency = a.currencyOf(acc.Currency)
+	if !fx.IsCurrency(acc.Currency) {
+		return errors.New("invalid currency, use an ISO 4217 code like USD")
+	}
+	return nil
+}
+
+// resolveAccount returns the ID of the account mentioned in an expense (eg: "HDFC card").
+// An account whose name is the mention, or is contained in it, is picked. Mentions which
+// don't match any account return nil, they're left for the user to assign.
+func (a *App) resolveAccount(accounts []db.Account, mention string) *int64 {
+	mention = strings.ToLower(strings.TrimSpace(mention))
+	if mention == "" {
+		return nil
+	}
+
+	var match *db.Account
+	for i, acc := range accounts {
+		name := strings.ToLower(acc.Name)
+		if name == mention {
+			return &accounts[i].ID
+		}
+		// Prefer the longest name, "HDFC Credit" over "HDFC" for "paid by hdfc credit card".
+		if strings.Contains(mention, name) && (match == nil || len(acc.Name) > len(match.Name)) {
+			match = &accounts[i]
+		}
+	}
+	if match == nil {
+		a.log.Warn("No account found for the mention", "account", mention)
+		return nil
+	}
+	return &match.ID
+}
+
+// accountIDParam parses the optional `account_id` query param used to filter
+// transactions and reports. It's nil when the param isn't set.
+func accountIDParam(s string) (interface{}, error) {
+	if s == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid account_id")
+	}
+	return id, nil
+}
+
+// toAccount converts an account row to its API representation.
+func toAccount(acc db.Account) models.Account {
+	return models.Account{
+		ID:             acc.ID,
+		CreatedAt:      acc.CreatedAt.Format(time.RFC3339),
+		Name:           acc.Name,
+		Kind:           acc.Kind,
+		Currency:       acc.Currency,
+		OpeningBalance: models.FromMinor(acc.OpeningBalance, acc.Currency),
+	}
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"io/fs"
 	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/knadh/koanf/parsers/toml"
@@ -33,33 +35,146 @@ func initConfig(cfgPath string) (*koanf.Koanf, error) {
 	return k, nil
 }
 
+// llmConfig reads the LLM settings from the `[llm]` section. Older configs
+// which have an `[openai]` section and no `llm.provider` continue to work with the
+// OpenAI provider. The provider is checked rather than the section, as the
+// GULLAK_LLM_* environment variables create the section by themselves.
+func llmConfig(ko *koanf.Koanf) llm.Config {
+	cfg := llm.Config{
+		Provider: ko.String("llm.provider"),
+		BaseURL:  ko.String("llm.base_url"),
+		Token:    ko.String("llm.token"),
+		Model:    ko.String("llm.model"),
+		Timeout:  ko.Duration("llm.timeout"),
+
+		OfflineFallback: ko.Bool("llm.offline_fallback"),
+	}
+	if cfg.Provider == "" && ko.Exists("openai") {
+		cfg = llm.Config{
+			Provider: llm.ProviderOpenAI,
+			BaseURL:  ko.String("openai.base_url"),
+			Token:    ko.String("openai.token"),
+			Model:    ko.String("openai.model"),
+			Timeout:  ko.Duration("openai.timeout"),
+
+			OfflineFallback: ko.Bool("openai.offline_fallback"),
+		}
+	}
+
+	// The vision and transcription settings are read from the `[llm]` section either way.
+	cfg.Vision = llm.VisionConfig{
+		BaseURL: ko.String("llm.vision.base_url"),
+		Token:   ko.String("llm.vision.token"),
+		Model:   ko.String("llm.vision.model"),
+		Timeout: ko.Duration("llm.vision.timeout"),
+	}
+	cfg.Transcription = llm.TranscriptionConfig{
+		BaseURL:  ko.String("llm.transcription.base_url"),
+		Token:    ko.String("llm.transcription.token"),
+		Model:    ko.String("llm.transcription.model"),
+		Timeout:  ko.Duration("llm.transcription.timeout"),
+		Language: ko.String("llm.transcription.language"),
+	}
+	return cfg
+}
+
+// defaultCurrency is used when `app.currency` isn't set.
+const defaultCurrency = "INR"
+
 type App struct {
 	srv     *echo.Echo
 	log     *slog.Logger
 	addr    string
 	llm     *llm.Manager
+	db      *sql.DB
 	queries *db.Queries
+
+	// currency is the default currency of transactions and reports.
+	currency string
+
+	// confirm is the policy for confirming parsed transactions without a review.
+	confirm confirmPolicy
+
+	// blobs holds the content of the attachments of transactions.
+	blobs *blobStore
+
+	// recurMu serialises the runs which create the transactions of recurring rules.
+	recurMu sync.Mutex
 }
 
-func initApp(addr string, timeout time.Duration, static fs.FS, queries *db.Queries, llmMgr *llm.Manager, log *slog.Logger) *App {
+func initApp(addr string, timeout time.Duration, static fs.FS, conn *sql.DB, queries *db.Queries, llmMgr *llm.Manager, currency string, confirm confirmPolicy, blobs *blobStore, log *slog.Logger) *App {
 	e := echo.New()
 	e.HideBanner = true
 
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
 	// e.Use(middleware.Logger()) -> Too noisy for now.
 	e.Use(middleware.TimeoutWithConfig(middleware.TimeoutConfig{
 		Timeout: timeout,
 	}))
 
+	// Uploads are limited to the size of their files, with some room for the rest of the form.
+	receiptLimit := middleware.BodyLimit(fmt.Sprintf("%dM", maxReceiptImages*maxReceiptImageSize>>20+1))
+	attachmentLimit := middleware.BodyLimit(fmt.Sprintf("%dM", maxAttachmentSize>>20+1))
+	audioLimit := middleware.BodyLimit(fmt.Sprintf("%dM", maxAudioSize>>20+1))
+
 	// Register handlers.
 
-	e.GET("/api", handleIndex)                                               // Simple welcome message or API status
-	e.POST("/api/transactions", handleCreateTransaction)                     // Creates a new transaction
-	e.GET("/api/transactions", handleListTransactions)                       // Lists all transactions, with optional filters
-	e.GET("/api/transactions/:id", handleGetTransaction)                     // Retrieves a specific transaction by ID
-	e.PUT("/api/transactions/:id", handleUpdateTransaction)                  // Updates a specific transaction by ID
-	e.DELETE("/api/transactions/:id", handleDeleteTransaction)               // Deletes a specific transaction by ID
-	e.GET("/api/reports/top-expense-categories", handleTopExpenseCategories) // Retrieves top expense categories
-	e.GET("/api/reports/daily-spending", handleDailySpending)                // Retrieves spending for a specific day
+	e.GET("/api", handleIndex)                                                           // Simple welcome message or API status
+	e.POST("/api/transactions", handleCreateTransaction)                                 // Creates a new transaction
+	e.GET("/api/transactions", handleListTransactions)                                   // Lists all transactions, with optional filters
+	e.POST("/api/transactions/bulk", handleBulkTransactions)                             // Confirms, recategorizes or deletes many transactions at once
+	e.POST("/api/transactions/receipt", handleCreateReceipt, receiptLimit)               // Creates transactions from the photos of a receipt
+	e.POST("/api/transactions/audio", handleCreateAudio, audioLimit)                     // Creates transactions from a voice note
+	e.GET("/api/transactions/search", handleSearchTransactions)                          // Searches the transactions by text
+	e.GET("/api/transactions/duplicates", handleListDuplicates)                          // Lists the transactions which look like duplicates
+	e.POST("/api/transactions/duplicates/:id/merge", handleMergeDuplicate)               // Merges a duplicate into its original
+	e.POST("/api/transactions/duplicates/:id/dismiss", handleDismissDuplicate)           // Marks a transaction as not a duplicate
+	e.GET("/api/transactions/:id", handleGetTransaction)                                 // Retrieves a specific transaction by ID
+	e.PUT("/api/transactions/:id", handleUpdateTransaction)                              // Updates a specific transaction by ID
+	e.PATCH("/api/transactions/:id", handlePatchTransaction)                             // Updates some of the fields of a specific transaction by ID
+	e.DELETE("/api/transactions/:id", handleDeleteTransaction)                           // Deletes a specific transaction by ID
+	e.POST("/api/transactions/:id/attachments", handleCreateAttachment, attachmentLimit) // Attaches a file to a transaction
+	e.GET("/api/transactions/:id/attachments", handleListAttachments)                    // Lists the attachments of a transaction
+	e.GET("/api/transactions/:id/attachments/:attachment_id", handleGetAttachment)       // Downloads an attachment of a transaction
+	e.DELETE("/api/transactions/:id/attachments/:attachment_id", handleDeleteAttachment) // Deletes an attachment of a transaction
+	e.GET("/api/entries/:id", handleGetEntry)                                            // Retrieves an input line and the transactions parsed from it
+	e.POST("/api/accounts", handleCreateAccount)                                         // Creates a new account
+	e.GET("/api/accounts", handleListAccounts)                                           // Lists all accounts
+	e.GET("/api/accounts/:id", handleGetAccount)                                         // Retrieves a specific account by ID
+	e.PUT("/api/accounts/:id", handleUpdateAccount)                                      // Updates a specific account by ID
+	e.DELETE("/api/accounts/:id", handleDeleteAccount)                                   // Deletes a specific account by ID
+	e.POST("/api/budgets", handleCreateBudget)                                           // Creates a new budget
+	e.GET("/api/budgets", handleListBudgets)                                             // Lists all budgets
+	e.GET("/api/budgets/:id", handleGetBudget)                                           // Retrieves a specific budget by ID
+	e.PUT("/api/budgets/:id", handleUpdateBudget)                                        // Updates a specific budget by ID
+	e.DELETE("/api/budgets/:id", handleDeleteBudget)                                     // Deletes a specific budget by ID
+	e.POST("/api/categories", handleCreateCategory)                                      // Creates a new category
+	e.GET("/api/categories", handleListCategories)                                       // Lists all categories
+	e.GET("/api/categories/:id", handleGetCategory)                                      // Retrieves a specific category by ID
+	e.PUT("/api/categories/:id", handleUpdateCategory)                                   // Updates a specific category by ID
+	e.DELETE("/api/categories/:id", handleDeleteCategory)                                // Deletes a specific category by ID
+	e.POST("/api/recurring-rules", handleCreateRecurringRule)                            // Creates a new recurring rule
+	e.GET("/api/recurring-rules", handleListRecurringRules)                              // Lists all recurring rules
+	e.GET("/api/recurring-rules/:id", handleGetRecurringRule)                            // Retrieves a specific recurring rule by ID
+	e.PUT("/api/recurring-rules/:id", handleUpdateRecurringRule)                         // Updates a specific recurring rule by ID
+	e.DELETE("/api/recurring-rules/:id", handleDeleteRecurringRule)                      // Deletes a specific recurring rule by ID
+	e.POST("/api/rules", handleCreateRule)                                               // Creates a new rule
+	e.GET("/api/rules", handleListRules)                                                 // Lists all rules in the order they're applied
+	e.POST("/api/rules/dry-run", handleDryRunRule)                                       // Shows the transactions a rule would change
+	e.GET("/api/rules/:id", handleGetRule)                                               // Retrieves a specific rule by ID
+	e.PUT("/api/rules/:id", handleUpdateRule)                                            // Updates a specific rule by ID
+	e.DELETE("/api/rules/:id", handleDeleteRule)                                         // Deletes a specific rule by ID
+	e.POST("/api/ask", handleAsk)                                                        // Answers a question about the transactions in natural language
+	e.GET("/api/tags", handleListTags)                                                   // Lists all tags
+	e.GET("/api/reports/top-expense-categories", handleTopExpenseCategories)             // Retrieves top expense categories
+	e.GET("/api/reports/daily-spending", handleDailySpending)                            // Retrieves spending for a specific day
+	e.GET("/api/reports/account-balances", handleAccountBalances)                        // Retrieves the running balance of an account
+	e.GET("/api/reports/cash-flow", handleCashFlow)                                      // Retrieves income, expenses and savings rate
+	e.GET("/api/reports/budget-status", handleBudgetStatus)                              // Retrieves spent and remaining amounts of budgets
+	e.GET("/api/reports/tags", handleTagTotals)                                          // Retrieves the amount spent per tag
 	// e.GET("/api/reports/monthly-spending-summary", handleMonthlySpendingSummary) // Retrieves spending summary by month
 
 	// Middleware to serve the static files.
@@ -71,11 +186,15 @@ func initApp(addr string, timeout time.Duration, static fs.FS, queries *db.Queri
 	}))
 
 	return &App{
-		srv:     e,
-		log:     log,
-		addr:    addr,
-		queries: queries,
-		llm:     llmMgr,
+		srv:      e,
+		log:      log,
+		addr:     addr,
+		db:       conn,
+		queries:  queries,
+		llm:      llmMgr,
+		currency: strings.ToUpper(currency),
+		confirm:  confirm,
+		blobs:    blobs,
 	}
 }
 
@@ -88,6 +207,9 @@ func (m *App) Start(ctx context.Context) error {
 		}
 	})
 
+	// Create the transactions of recurring rules as they fall due.
+	go m.runScheduler(ctx)
+
 	// Start server in a goroutine to allow for graceful shutdown.
 	go func() {
 		if err := m.srv.Start(m.addr); err != http.ErrServerClosed {
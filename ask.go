@@ -0,0 +1,496 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/internal/fx"
+	"github.com/mr-karan/gullak/internal/llm"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+const (
+	// defaultAnswerGroups is the number of groups an answer breaks down into, when the
+	// question doesn't ask for a number. Groupings by date aren't limited by default.
+	defaultAnswerGroups = 10
+
+	// maxAnswerGroups is the most groups an answer breaks down into.
+	maxAnswerGroups = 50
+
+	// maxPlanDescription is the longest description a plan can filter by.
+	maxPlanDescription = 100
+)
+
+// errInvalidPlan is returned when the plan of a question can't be run.
+var errInvalidPlan = errors.New("couldn't answer the question")
+
+// planGroupColumns are the columns the transactions are grouped by for every grouping a
+// plan can have. The groupings by date are done on the dates of the rows.
+var planGroupColumns = map[string]string{
+	"":                     "''",
+	models.GroupByCategory: "t.category",
+	models.GroupByTag:      "tg.name",
+	models.GroupByAccount:  "COALESCE(a.name, '')",
+	models.GroupByType:     "t.type",
+	models.GroupByDay:      "''",
+	models.GroupByWeek:     "''",
+	models.GroupByMonth:    "''",
+}
+
+// askQuery is a validated query plan, with the names in it resolved.
+type askQuery struct {
+	plan       models.QueryPlan
+	categories []string
+	accountIDs []int64
+	// description is escaped for LIKE.
+	description string
+	// start and end are zero when the period is open on that side.
+	start, end time.Time
+}
+
+// planTotal is the total of a group of transactions on a day, in the report currency.
+type planTotal struct {
+	key   string
+	date  time.Time
+	total int64
+	count int64
+}
+
+// ask answers a question about the transactions. The LLM only turns the question into
+// a query plan, which is validated and run here, so that the numbers come from the
+// database. The amounts are converted to base.
+func (a *App) ask(ctx context.Context, question, base string) (models.Answer, error) {
+	t, err := a.loadTaxonomy(ctx)
+	if err != nil {
+		return models.Answer{}, err
+	}
+	accounts, err := a.queries.ListAccounts(ctx)
+	if err != nil {
+		return models.Answer{}, fmt.Errorf("error listing accounts: %w", err)
+	}
+	tags, err := a.queries.ListTags(ctx)
+	if err != nil {
+		return models.Answer{}, fmt.Errorf("error listing tags: %w", err)
+	}
+
+	hints := llm.PlanHints{Categories: t.list()}
+	for _, tg := range tags {
+		hints.Tags = append(hints.Tags, tg.Name)
+	}
+	for _, acc := range accounts {
+		hints.Accounts = append(hints.Accounts, acc.Name)
+	}
+
+	plan, err := a.llm.Plan(ctx, question, hints)
+	if err != nil {
+		return models.Answer{}, err
+	}
+	q, err := a.validatePlan(t, accounts, plan)
+	if err != nil {
+		return models.Answer{}, err
+	}
+
+	conv, err := a.converter(ctx)
+	if err != nil {
+		return models.Answer{}, err
+	}
+
+	answer := models.Answer{
+		Question: question,
+		Plan:     q.plan,
+		Currency: base,
+		Groups:   []models.AnswerGroup{},
+	}
+
+	// The overall numbers are of a query without groups, so that a transaction with two
+	// tags isn't counted twice.
+	overall, err := a.runPlan(ctx, conv, q, "", base)
+	if err != nil {
+		return models.Answer{}, err
+	}
+	var total int64
+	for _, r := range overall {
+		total += r.total
+		answer.Count += r.count
+	}
+	answer.Total = models.FromMinor(total, base)
+	answer.Average = average(total, answer.Count, base)
+
+	if q.plan.GroupBy != "" {
+		rows := overall
+		if planGroupColumns[q.plan.GroupBy] != "''" {
+			if rows, err = a.runPlan(ctx, conv, q, q.plan.GroupBy, base); err != nil {
+				return models.Answer{}, err
+			}
+		}
+		if answer.Groups, err = groupTotals(rows, q.plan, base); err != nil {
+			return models.Answer{}, err
+		}
+	}
+
+	if answer.Answer, err = answerText(answer, q); err != nil {
+		return models.Answer{}, err
+	}
+	return answer, nil
+}
+
+// validatePlan checks the plan against the metrics, groupings and filters which can
+// be run, and resolves the names in it.
+func (a *App) validatePlan(t *taxonomy, accounts []db.Account, plan models.QueryPlan) (askQuery, error) {
+	plan.Metric = strings.ToLower(strings.TrimSpace(plan.Metric))
+	switch plan.Metric {
+	case "":
+		plan.Metric = models.MetricTotal
+	case models.MetricTotal, models.MetricCount, models.MetricAverage:
+	default:
+		return askQuery{}, fmt.Errorf("%w: unknown metric %q", errInvalidPlan, plan.Metric)
+	}
+
+	plan.GroupBy = strings.ToLower(strings.TrimSpace(plan.GroupBy))
+	if _, ok := planGroupColumns[plan.GroupBy]; !ok {
+		return askQuery{}, fmt.Errorf("%w: unknown grouping %q", errInvalidPlan, plan.GroupBy)
+	}
+
+	typ, err := transactionType(plan.Type)
+	if err != nil {
+		return askQuery{}, fmt.Errorf("%w: %w", errInvalidPlan, err)
+	}
+	plan.Type = typ
+
+	q := askQuery{}
+	categories := []string{}
+	for _, c := range plan.Categories {
+		if c = strings.TrimSpace(c); c == "" {
+			continue
+		}
+		categories = append(categories, t.resolve(c))
+		q.categories = append(q.categories, t.family(c)...)
+	}
+	plan.Categories = categories
+
+	if plan.Tags = llm.NormalizeTags(plan.Tags); plan.Tags == nil {
+		plan.Tags = []string{}
+	}
+
+	names := []string{}
+	for _, name := range plan.Accounts {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		id := a.resolveAccount(accounts, name)
+		if id == nil {
+			return askQuery{}, fmt.Errorf("%w: unknown account %q", errInvalidPlan, name)
+		}
+		names = append(names, name)
+		q.accountIDs = append(q.accountIDs, *id)
+	}
+	plan.Accounts = names
+
+	plan.Description = strings.TrimSpace(plan.Description)
+	if len(plan.Description) > maxPlanDescription {
+		return askQuery{}, fmt.Errorf("%w: description is too long", errInvalidPlan)
+	}
+	q.description = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(plan.Description)
+
+	if plan.StartDate = strings.TrimSpace(plan.StartDate); plan.StartDate != "" {
+		if q.start, err = time.Parse("2006-01-02", plan.StartDate); err != nil {
+			return askQuery{}, fmt.Errorf("%w: invalid start_date %q", errInvalidPlan, plan.StartDate)
+		}
+	}
+	if plan.EndDate = strings.TrimSpace(plan.EndDate); plan.EndDate != "" {
+		if q.end, err = time.Parse("2006-01-02", plan.EndDate); err != nil {
+			return askQuery{}, fmt.Errorf("%w: invalid end_date %q", errInvalidPlan, plan.EndDate)
+		}
+	}
+	if !q.start.IsZero() && !q.end.IsZero() {
+		if err := validateDateRange(q.start, q.end); err != nil {
+			return askQuery{}, fmt.Errorf("%w: %w", errInvalidPlan, err)
+		}
+	}
+
+	if plan.Limit < 0 {
+		plan.Limit = 0
+	}
+	plan.Limit = min(plan.Limit, maxAnswerGroups)
+
+	q.plan = plan
+	return q, nil
+}
+
+// runPlan runs the query of a plan, with the transactions grouped by groupBy. Like the
+// reports, only confirmed transactions are counted. The totals are per day, and
+// converted to base using the exchange rate of that day.
+func (a *App) runPlan(ctx context.Context, conv *fx.Converter, q askQuery, groupBy, base string) ([]planTotal, error) {
+	var (
+		b     strings.Builder
+		where = []string{"t.confirm = 1", "t.type = ?"}
+		args  = []any{q.plan.Type}
+	)
+	// in returns the placeholders of an IN clause of the values, and adds them to the args.
+	in := func(values ...any) string {
+		args = append(args, values...)
+		return strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+	}
+
+	fmt.Fprintf(&b, "SELECT %s, t.currency, t.transaction_date, CAST(SUM(t.amount) AS INTEGER), COUNT(*) FROM transactions t", planGroupColumns[groupBy])
+	switch groupBy {
+	case models.GroupByTag:
+		b.WriteString(" JOIN transaction_tags tt ON tt.transaction_id = t.id JOIN tags tg ON tg.id = tt.tag_id")
+	case models.GroupByAccount:
+		b.WriteString(" LEFT JOIN accounts a ON a.id = t.account_id")
+	}
+
+	if len(q.categories) > 0 {
+		values := make([]any, len(q.categories))
+		for i, c := range q.categories {
+			values[i] = strings.ToLower(c)
+		}
+		where = append(where, "lower(t.category) IN ("+in(values...)+")")
+	}
+	if len(q.plan.Tags) > 0 {
+		values := make([]any, len(q.plan.Tags))
+		for i, tg := range q.plan.Tags {
+			values[i] = tg
+		}
+		where = append(where, "t.id IN (SELECT ft.transaction_id FROM transaction_tags ft JOIN tags fg ON fg.id = ft.tag_id WHERE fg.name IN ("+in(values...)+"))")
+	}
+	if len(q.accountIDs) > 0 {
+		values := make([]any, len(q.accountIDs))
+		for i, id := range q.accountIDs {
+			values[i] = id
+		}
+		accounts := in(values...)
+		args = append(args, values...)
+		where = append(where, "(t.account_id IN ("+accounts+") OR t.transfer_account_id IN ("+accounts+"))")
+	}
+	if q.description != "" {
+		where = append(where, `t.description LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+q.description+"%")
+	}
+	if !q.start.IsZero() {
+		where = append(where, "t.transaction_date >= ?")
+		args = append(args, q.start)
+	}
+	if !q.end.IsZero() {
+		where = append(where, "t.transaction_date <= ?")
+		args = append(args, q.end)
+	}
+	b.WriteString(" WHERE " + strings.Join(where, " AND "))
+	b.WriteString(" GROUP BY 1, t.currency, t.transaction_date")
+
+	rows, err := a.db.QueryContext(ctx, b.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error running query plan: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []planTotal
+	for rows.Next() {
+		var (
+			r        planTotal
+			currency string
+		)
+		if err := rows.Scan(&r.key, &currency, &r.date, &r.total, &r.count); err != nil {
+			return nil, fmt.Errorf("error running query plan: %w", err)
+		}
+		if r.total, err = conv.Convert(ctx, r.total, a.currencyOf(currency), base, r.date); err != nil {
+			return nil, err
+		}
+		totals = append(totals, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error running query plan: %w", err)
+	}
+	return totals, nil
+}
+
+// groupTotals adds up the totals of the groups of the plan. The largest groups by the
+// metric come first, except for the groupings by date which are in order of the dates.
+func groupTotals(rows []planTotal, plan models.QueryPlan, base string) ([]models.AnswerGroup, error) {
+	type group struct {
+		key          string
+		total, count int64
+	}
+	var groups []*group
+	idx := map[string]*group{}
+	for _, r := range rows {
+		key := r.key
+		switch plan.GroupBy {
+		case models.GroupByDay:
+			key = r.date.Format("2006-01-02")
+		case models.GroupByWeek:
+			// Weeks start on a Monday.
+			key = r.date.AddDate(0, 0, -(int(r.date.Weekday())+6)%7).Format("2006-01-02")
+		case models.GroupByMonth:
+			key = r.date.Format("2006-01")
+		}
+		g, ok := idx[key]
+		if !ok {
+			g = &group{key: key}
+			idx[key] = g
+			groups = append(groups, g)
+		}
+		g.total += r.total
+		g.count += r.count
+	}
+
+	out := make([]models.AnswerGroup, len(groups))
+	for i, g := range groups {
+		out[i] = models.AnswerGroup{
+			Key:     g.key,
+			Total:   models.FromMinor(g.total, base),
+			Count:   g.count,
+			Average: average(g.total, g.count, base),
+		}
+	}
+	if err := sortDesc(out, func(a, b models.AnswerGroup) (int, error) {
+		return compareMetric(a, b, plan.Metric)
+	}); err != nil {
+		return nil, err
+	}
+
+	byDate := plan.GroupBy == models.GroupByDay || plan.GroupBy == models.GroupByWeek || plan.GroupBy == models.GroupByMonth
+	limit := plan.Limit
+	if limit == 0 && !byDate {
+		limit = defaultAnswerGroups
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	if byDate {
+		sort.SliceStable(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	}
+	return out, nil
+}
+
+// compareMetric compares the metric of two groups.
+func compareMetric(a, b models.AnswerGroup, metric string) (int, error) {
+	switch metric {
+	case models.MetricCount:
+		return int(a.Count - b.Count), nil
+	case models.MetricAverage:
+		return a.Average.Cmp(b.Average)
+	}
+	return a.Total.Cmp(b.Total)
+}
+
+// average returns the average of count amounts in minor units of the currency which
+// add up to total, rounded half away from zero.
+func average(total, count int64, currency string) models.Decimal {
+	if count == 0 {
+		return models.FromMinor(0, currency)
+	}
+	avg, rem := total/count, total%count
+	if rem < 0 {
+		rem = -rem
+	}
+	if 2*rem >= count {
+		if total < 0 {
+			avg--
+		} else {
+			avg++
+		}
+	}
+	return models.FromMinor(avg, currency)
+}
+
+// answerText writes the answer to a question from its numbers, eg: "You spent 4500 INR
+// on food from 2024-05-01 to 2024-05-31."
+func answerText(ans models.Answer, q askQuery) (string, error) {
+	p := q.plan
+	nouns := map[string][2]string{
+		models.TypeExpense:  {"expense", "expenses"},
+		models.TypeIncome:   {"income transaction", "income transactions"},
+		models.TypeTransfer: {"transfer", "transfers"},
+	}[p.Type]
+	noun := nouns[1]
+	if ans.Count == 1 {
+		noun = nouns[0]
+	}
+
+	var scope strings.Builder
+	if len(p.Categories) > 0 {
+		scope.WriteString(" on " + joinAnd(p.Categories))
+	}
+	if len(p.Tags) > 0 {
+		scope.WriteString(" tagged " + joinAnd(p.Tags))
+	}
+	if len(p.Accounts) > 0 {
+		scope.WriteString(" with " + joinAnd(p.Accounts))
+	}
+	if p.Description != "" {
+		scope.WriteString(fmt.Sprintf(" for %q", p.Description))
+	}
+	switch {
+	case p.StartDate != "" && p.StartDate == p.EndDate:
+		scope.WriteString(" on " + p.StartDate)
+	case p.StartDate != "" && p.EndDate != "":
+		scope.WriteString(" from " + p.StartDate + " to " + p.EndDate)
+	case p.StartDate != "":
+		scope.WriteString(" since " + p.StartDate)
+	case p.EndDate != "":
+		scope.WriteString(" until " + p.EndDate)
+	}
+
+	amount := func(d models.Decimal) string {
+		return d.String() + " " + ans.Currency
+	}
+
+	var text string
+	switch {
+	case ans.Count == 0:
+		text = fmt.Sprintf("There are no %s%s.", nouns[1], scope.String())
+	case p.Metric == models.MetricCount:
+		text = fmt.Sprintf("You have %d %s%s.", ans.Count, noun, scope.String())
+	case p.Metric == models.MetricAverage:
+		text = fmt.Sprintf("Your average %s%s was %s, over %d %s.", nouns[0], scope.String(), amount(ans.Average), ans.Count, noun)
+	default:
+		verb := map[string]string{
+			models.TypeExpense:  "spent",
+			models.TypeIncome:   "received",
+			models.TypeTransfer: "transferred",
+		}[p.Type]
+		text = fmt.Sprintf("You %s %s%s.", verb, amount(ans.Total), scope.String())
+	}
+
+	if len(ans.Groups) > 0 {
+		// The largest groups are listed, which for the groupings by date means
+		// sorting them again.
+		groups := append([]models.AnswerGroup{}, ans.Groups...)
+		if err := sortDesc(groups, func(a, b models.AnswerGroup) (int, error) {
+			return compareMetric(a, b, p.Metric)
+		}); err != nil {
+			return "", err
+		}
+		var parts []string
+		for _, g := range groups[:min(len(groups), 3)] {
+			key := g.Key
+			if key == "" {
+				key = "none"
+			}
+			switch p.Metric {
+			case models.MetricCount:
+				parts = append(parts, fmt.Sprintf("%s (%d)", key, g.Count))
+			case models.MetricAverage:
+				parts = append(parts, fmt.Sprintf("%s (%s)", key, amount(g.Average)))
+			default:
+				parts = append(parts, fmt.Sprintf("%s (%s)", key, amount(g.Total)))
+			}
+		}
+		text += fmt.Sprintf(" The most by %s: %s.", p.GroupBy, joinAnd(parts))
+	}
+	return text, nil
+}
+
+// joinAnd joins the items like "food, travel and rent".
+func joinAnd(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
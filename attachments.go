@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// defaultAttachmentsDir is used when `app.attachments_dir` isn't set.
+const defaultAttachmentsDir = "./attachments"
+
+// maxAttachmentSize is the size limit of an attachment, in bytes.
+const maxAttachmentSize = 20 << 20
+
+// attachmentTypes are the formats of files which can be attached, photos of bills and
+// PDF invoices. They're served back as is, so types which a browser runs, like HTML,
+// aren't allowed.
+var attachmentTypes = []string{"image/jpeg", "image/png", "image/webp", "image/gif", "application/pdf"}
+
+// errAttachmentNotFound is returned when a transaction doesn't have the attachment.
+var errAttachmentNotFound = errors.New("attachment not found")
+
+// blobStore keeps the content of attachments on disk by its SHA-256 hash, so that a file
+// is stored once however many transactions it's attached to.
+type blobStore struct {
+	dir string
+
+	// mu serialises storing and attaching blobs with removing the blobs which aren't
+	// attached anymore, so that a blob isn't removed just as it's attached again.
+	mu sync.Mutex
+}
+
+func newBlobStore(dir string) (*blobStore, error) {
+	if dir == "" {
+		dir = defaultAttachmentsDir
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("error creating attachments directory: %w", err)
+	}
+	return &blobStore{dir: dir}, nil
+}
+
+// path returns the path of a blob. Blobs are spread over directories named after the
+// first two characters of their hash, so that no directory grows too large.
+func (s *blobStore) path(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// put stores the data and returns its hash. Data which is already stored isn't written again.
+func (s *blobStore) put(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	path := s.path(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", fmt.Errorf("error creating blob directory: %w", err)
+	}
+
+	// The data is written to a temporary file which is renamed once it's complete, so
+	// that a blob is never read half written.
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("error creating blob: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", fmt.Errorf("error writing blob: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("error writing blob: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return "", fmt.Errorf("error saving blob: %w", err)
+	}
+	return hash, nil
+}
+
+// open opens a blob for reading.
+func (s *blobStore) open(hash string) (*os.File, error) {
+	return os.Open(s.path(hash))
+}
+
+// remove deletes a blob. A blob which doesn't exist is already removed.
+func (s *blobStore) remove(hash string) error {
+	if err := os.Remove(s.path(hash)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error removing blob: %w", err)
+	}
+	return nil
+}
+
+// uploadError is returned when an uploaded file isn't accepted, eg: as it's too large.
+// Its message is meant for the user, unlike the other errors of reading an upload.
+type uploadError struct {
+	msg string
+}
+
+func (e *uploadError) Error() string {
+	return e.msg
+}
+
+// readUpload reads an uploaded file, checking its size and format. The format is
+// detected from the content rather than trusting the upload.
+func readUpload(fh *multipart.FileHeader, maxSize int64, types []string) ([]byte, string, error) {
+	if fh.Size > maxSize {
+		return nil, "", &uploadError{fmt.Sprintf("%s is larger than %d MB", fh.Filename, maxSize>>20)}
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading %s: %w", fh.Filename, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading %s: %w", fh.Filename, err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, "", &uploadError{fmt.Sprintf("%s is larger than %d MB", fh.Filename, maxSize>>20)}
+	}
+
+	typ, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	if !slices.Contains(types, typ) {
+		return nil, "", &uploadError{fmt.Sprintf("%s isn't a supported file, use one of %s", fh.Filename, strings.Join(types, ", "))}
+	}
+	return data, typ, nil
+}
+
+// attachment is a file in the blob store, to be attached to transactions as they're saved.
+type attachment struct {
+	Filename string
+	MIMEType string
+	Size     int64
+	Hash     string
+}
+
+// toAttachment converts an attachment row to its API representation.
+func toAttachment(a db.Attachment) models.Attachment {
+	return models.Attachment{
+		ID:            a.ID,
+		CreatedAt:     a.CreatedAt.Format(time.RFC3339),
+		TransactionID: a.TransactionID,
+		Filename:      a.Filename,
+		MIMEType:      a.MimeType,
+		Size:          a.Size,
+		SHA256:        a.Sha256,
+	}
+}
+
+// listAttachments returns the attachments of a transaction.
+func (a *App) listAttachments(ctx context.Context, transactionID int64) ([]models.Attachment, error) {
+	rows, err := a.queries.ListAttachmentsByTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing attachments: %w", err)
+	}
+	out := make([]models.Attachment, len(rows))
+	for i, r := range rows {
+		out[i] = toAttachment(r)
+	}
+	return out, nil
+}
+
+// attach stores the uploaded file and attaches it to the transaction.
+func (a *App) attach(ctx context.Context, transactionID int64, fh *multipart.FileHeader) (models.Attachment, error) {
+	data, typ, err := readUpload(fh, maxAttachmentSize, attachmentTypes)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	a.blobs.mu.Lock()
+	defer a.blobs.mu.Unlock()
+
+	hash, err := a.blobs.put(data)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	row, err := a.queries.CreateAttachment(ctx, db.CreateAttachmentParams{
+		CreatedAt:     time.Now(),
+		TransactionID: transactionID,
+		Filename:      filepath.Base(fh.Filename),
+		MimeType:      typ,
+		Size:          int64(len(data)),
+		Sha256:        hash,
+	})
+	if err != nil {
+		a.removeOrphanBlobs(ctx, []string{hash})
+		return models.Attachment{}, fmt.Errorf("error saving attachment: %w", err)
+	}
+	return toAttachment(row), nil
+}
+
+// detach deletes an attachment of a transaction, along with its content unless it's
+// attached elsewhere too.
+func (a *App) detach(ctx context.Context, transactionID, id int64) error {
+	a.blobs.mu.Lock()
+	defer a.blobs.mu.Unlock()
+
+	hash, err := a.queries.DeleteAttachment(ctx, db.DeleteAttachmentParams{
+		ID:            id,
+		TransactionID: transactionID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return errAttachmentNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting attachment: %w", err)
+	}
+	a.removeOrphanBlobs(ctx, []string{hash})
+	return nil
+}
+
+// deleteTransaction deletes a transaction along with its attachments, and returns the
+// hashes of their content. The content is left for removeOrphanBlobs, once the deletion
+// is committed.
+func deleteTransaction(ctx context.Context, q *db.Queries, id int64) ([]string, error) {
+	attachments, err := q.ListAttachmentsByTransaction(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error listing attachments: %w", err)
+	}
+	if err := q.DeleteTransaction(ctx, id); err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, len(attachments))
+	for i, att := range attachments {
+		hashes[i] = att.Sha256
+	}
+	return hashes, nil
+}
+
+// cleanupBlobs removes the content of the hashes which isn't attached to any transaction
+// anymore, once the transactions it was attached to are deleted.
+func (a *App) cleanupBlobs(ctx context.Context, hashes []string) {
+	if len(hashes) == 0 {
+		return
+	}
+	a.blobs.mu.Lock()
+	defer a.blobs.mu.Unlock()
+	a.removeOrphanBlobs(ctx, hashes)
+}
+
+// removeOrphanBlobs removes the content of the hashes which isn't attached to any
+// transaction anymore. It must be called with blobs.mu held. Failures are only logged,
+// as the attachments are already gone.
+func (a *App) removeOrphanBlobs(ctx context.Context, hashes []string) {
+	for _, hash := range hashes {
+		n, err := a.queries.CountAttachmentsByHash(ctx, hash)
+		if err != nil {
+			a.log.Error("Error counting attachments", "sha256", hash, "error", err)
+			continue
+		}
+		if n > 0 {
+			continue
+		}
+		if err := a.blobs.remove(hash); err != nil {
+			a.log.Error("Error removing orphaned attachment", "sha256", hash, "error", err)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/internal/fx"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// Budget periods.
+const (
+	periodWeekly  = "weekly"
+	periodMonthly = "monthly"
+	periodYearly  = "yearly"
+)
+
+type BudgetStatus struct {
+	models.Budget
+	PeriodStart string `json:"period_start"`
+	PeriodEnd   string `json:"period_end"`
+	// CarriedOver is the unspent amount of the previous period, negative if it
+	// was overspent. It's only set for budgets with rollover.
+	CarriedOver models.Decimal `json:"carried_over"`
+	Limit       models.Decimal `json:"limit"`
+	Spent       models.Decimal `json:"spent"`
+	Remaining   models.Decimal `json:"remaining"`
+	// Percentage is the share of the limit which is spent. It's null when the
+	// limit isn't positive.
+	Percentage *float64 `json:"percentage"`
+}
+
+// periodBounds returns the first and last day of the budget period containing date.
+// Weeks start on Monday.
+func periodBounds(period string, date time.Time) (time.Time, time.Time) {
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case periodWeekly:
+		start := date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 6)
+	case periodYearly:
+		start := time.Date(date.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, -1)
+	default:
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1)
+	}
+}
+
+// validateBudget normalises the budget input and checks that it's valid.
+func (a *App) validateBudget(b *models.Budget) error {
+	t, err := a.loadTaxonomy(context.Background())
+	if err != nil {
+		return err
+	}
+	b.Category = t.resolve(b.Category)
+	if b.Category == "" {
+		return errors.New("category is required")
+	}
+
+	b.Period = strings.ToLower(strings.TrimSpace(b.Period))
+	if b.Period == "" {
+		b.Period = periodMonthly
+	}
+	if b.Period != periodWeekly && b.Period != periodMonthly && b.Period != periodYearly {
+		return fmt.Errorf("invalid period %q, use one of weekly, monthly, yearly", b.Period)
+	}
+
+	if b.Amount.Sign() <= 0 {
+		return errors.New("amount must be positive")
+	}
+
+	b.Currency = a.currencyOf(b.Currency)
+	if !fx.IsCurrency(b.Currency) {
+		return errors.New("invalid currency, use an ISO 4217 code like USD")
+	}
+	return nil
+}
+
+// budgetTracker computes the status of budgets. The category totals of a
+// period are shared by the budgets in the same period and currency.
+type budgetTracker struct {
+	app    *App
+	conv   *fx.Converter
+	tax    *taxonomy
+	totals map[string]map[string]int64
+}
+
+func (a *App) newBudgetTracker(ctx context.Context) (*budgetTracker, error) {
+	conv, err := a.converter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading exchange rates: %w", err)
+	}
+	tax, err := a.loadTaxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &budgetTracker{app: a, conv: conv, tax: tax, totals: map[string]map[string]int64{}}, nil
+}
+
+// spent returns the confirmed expenses of the category and its subcategories in the
+// period, in minor units of currency.
+func (t *budgetTracker) spent(ctx context.Context, category string, start, end time.Time, currency string) (int64, error) {
+	key := start.Format("2006-01-02") + end.Format("2006-01-02") + currency
+	totals, ok := t.totals[key]
+	if !ok {
+		categories, err := t.app.categoryTotals(ctx, t.conv, db.TopExpenseCategoriesParams{
+			StartDate: start,
+			EndDate:   end,
+		}, currency)
+		if err != nil {
+			return 0, err
+		}
+
+		totals = map[string]int64{}
+		for _, cat := range categories {
+			minor, err := cat.TotalSpent.Minor(currency)
+			if err != nil {
+				return 0, err
+			}
+			totals[strings.ToLower(cat.Category)] += minor
+		}
+		t.totals[key] = totals
+	}
+
+	var spent int64
+	for cat, total := range totals {
+		if t.tax.within(cat, category) {
+			spent += total
+		}
+	}
+	return spent, nil
+}
+
+// status returns the status of the budget in the period containing date. With rollover,
+// the unspent amount of the previous period (or the overspend) is added to the limit.
+func (t *budgetTracker) status(ctx context.Context, b db.Budget, date time.Time) (BudgetStatus, error) {
+	start, end := periodBounds(b.Period, date)
+	spent, err := t.spent(ctx, b.Category, start, end, b.Currency)
+	if err != nil {
+		return BudgetStatus{}, err
+	}
+
+	// Nothing carries over to the first period of the budget.
+	var carried int64
+	if b.Rollover && b.CreatedAt.Before(start) {
+		prevStart, prevEnd := periodBounds(b.Period, start.AddDate(0, 0, -1))
+		prevSpent, err := t.spent(ctx, b.Category, prevStart, prevEnd, b.Currency)
+		if err != nil {
+			return BudgetStatus{}, err
+		}
+		carried = b.Amount - prevSpent
+	}
+
+	limit := b.Amount + carried
+	status := BudgetStatus{
+		Budget:      toBudget(b),
+		PeriodStart: start.Format("2006-01-02"),
+		PeriodEnd:   end.Format("2006-01-02"),
+		CarriedOver: models.FromMinor(carried, b.Currency),
+		Limit:       models.FromMinor(limit, b.Currency),
+		Spent:       models.FromMinor(spent, b.Currency),
+		Remaining:   models.FromMinor(limit-spent, b.Currency),
+	}
+	if limit > 0 {
+		pct := math.Round(float64(spent)/float64(limit)*10000) / 100
+		status.Percentage = &pct
+	}
+	return status, nil
+}
+
+// budgetWarnings returns a warning for every budget which the new expenses push over
+// its limit, but not for one which was already over it. The spending only counts
+// confirmed expenses, so the new expenses which aren't confirmed are added to it, while
+// those confirmed as they're saved, by a rule or the auto-confirm policy, are taken out
+// of it for the spending before them.
+func (a *App) budgetWarnings(ctx context.Context, items []models.Item) ([]string, error) {
+	budgets, err := a.queries.ListBudgets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing budgets: %w", err)
+	}
+	if len(budgets) == 0 {
+		return nil, nil
+	}
+
+	t, err := a.newBudgetTracker(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The new spending of each budget in each period, which is either pending or
+	// already confirmed.
+	type budgetPeriod struct {
+		idx   int
+		start string
+	}
+	var (
+		order     []budgetPeriod
+		pending   = map[budgetPeriod]int64{}
+		confirmed = map[budgetPeriod]int64{}
+		dates     = map[budgetPeriod]time.Time{}
+	)
+	for _, item := range items {
+		if item.Type != models.TypeExpense {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", item.TransactionDate)
+		if err != nil {
+			return nil, err
+		}
+		for i, b := range budgets {
+			if !t.tax.within(item.Category, b.Category) {
+				continue
+			}
+			start, _ := periodBounds(b.Period, date)
+			key := budgetPeriod{idx: i, start: start.Format("2006-01-02")}
+			if _, ok := dates[key]; !ok {
+				order = append(order, key)
+				dates[key] = date
+			}
+
+			minor, err := item.Amount.Minor(item.Currency)
+			if err != nil {
+				return nil, err
+			}
+			if minor, err = t.conv.Convert(ctx, minor, item.Currency, b.Currency, date); err != nil {
+				return nil, err
+			}
+			if item.Confirm {
+				confirmed[key] += minor
+			} else {
+				pending[key] += minor
+			}
+		}
+	}
+
+	var warnings []string
+	for _, key := range order {
+		b := budgets[key.idx]
+		status, err := t.status(ctx, b, dates[key])
+		if err != nil {
+			return nil, err
+		}
+
+		limit, _ := status.Limit.Minor(b.Currency)
+		spent, _ := status.Spent.Minor(b.Currency)
+		before, after := spent-confirmed[key], spent+pending[key]
+		if before <= limit && after > limit {
+			over := models.FromMinor(after-limit, b.Currency)
+			warnings = append(warnings, fmt.Sprintf("%s is over its %s budget by %s %s", b.Category, b.Period, over, b.Currency))
+		}
+	}
+	return warnings, nil
+}
+
+// toBudget converts a budget row to its API representation.
+func toBudget(b db.Budget) models.Budget {
+	return models.Budget{
+		ID:        b.ID,
+		CreatedAt: b.CreatedAt.Format(time.RFC3339),
+		Category:  b.Category,
+		Period:    b.Period,
+		Amount:    models.FromMinor(b.Amount, b.Currency),
+		Currency:  b.Currency,
+		Rollover:  b.Rollover,
+	}
+}
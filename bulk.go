@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/internal/llm"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// validateBulk normalises the bulk request and checks that it's valid.
+func (a *App) validateBulk(ctx context.Context, req *models.BulkRequest) error {
+	if (len(req.IDs) == 0) == (req.Filter == nil) {
+		return errors.New("pass either ids or filter")
+	}
+	if req.Filter != nil {
+		// An empty filter would select every transaction, which is more likely a mistake.
+		if *req.Filter == (models.TransactionFilter{}) {
+			return errors.New("filter needs at least one condition")
+		}
+		if _, err := a.filterParams(ctx, *req.Filter); err != nil {
+			return err
+		}
+	}
+
+	req.Action = strings.ToLower(strings.TrimSpace(req.Action))
+	switch req.Action {
+	case models.BulkConfirm, models.BulkDelete:
+	case models.BulkSetCategory:
+		t, err := a.loadTaxonomy(ctx)
+		if err != nil {
+			return err
+		}
+		if req.Category = t.resolve(req.Category); req.Category == "" {
+			return errors.New("category is required")
+		}
+	case models.BulkAddTag, models.BulkRemoveTag:
+		tags := llm.NormalizeTags([]string{req.Tag})
+		if tags == nil {
+			return errors.New("tag is required")
+		}
+		req.Tag = tags[0]
+	case models.BulkSetAccount:
+		if req.AccountID == nil {
+			return errors.New("account_id is required")
+		}
+		if _, err := a.queries.GetAccount(ctx, *req.AccountID); err != nil {
+			return errors.New("invalid account_id")
+		}
+	case models.BulkSetDate:
+		if _, err := time.Parse("2006-01-02", req.TransactionDate); err != nil {
+			return errors.New("invalid transaction_date format, use YYYY-MM-DD")
+		}
+	default:
+		return fmt.Errorf("invalid action %q, use one of confirm, delete, set_category, add_tag, remove_tag, set_account, set_date", req.Action)
+	}
+	return nil
+}
+
+// applyBulk applies the action of a validated bulk request to its transactions, all in
+// one database transaction. Transactions which the action can't be applied to, like
+// missing ones, are reported in the results and don't stop the others. An error from
+// the database rolls back the whole request.
+func (a *App) applyBulk(ctx context.Context, req models.BulkRequest) ([]models.BulkResult, error) {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	q := a.queries.WithTx(tx)
+
+	// The transactions are looked up by ID, or listed with the filter. A missing
+	// transaction is left with only its ID, to report it in the order of the IDs.
+	var rows []*db.Transaction
+	if req.Filter != nil {
+		params, err := a.filterParams(ctx, *req.Filter)
+		if err != nil {
+			return nil, err
+		}
+		list, err := q.ListTransactions(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("error listing transactions: %w", err)
+		}
+		for i := range list {
+			rows = append(rows, &list[i])
+		}
+	} else {
+		seen := map[int64]bool{}
+		for _, id := range req.IDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			row, err := q.GetTransaction(ctx, id)
+			if errors.Is(err, sql.ErrNoRows) {
+				rows = append(rows, &db.Transaction{ID: id})
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error getting transaction %d: %w", id, err)
+			}
+			rows = append(rows, &row)
+		}
+	}
+
+	var tag db.Tag
+	if req.Action == models.BulkAddTag {
+		if tag, err = q.UpsertTag(ctx, req.Tag); err != nil {
+			return nil, fmt.Errorf("error saving tag %s: %w", req.Tag, err)
+		}
+	}
+
+	// The corrections of the categories are recorded once the changes are committed, as
+	// is the content of the attachments of deleted transactions removed.
+	var (
+		corrected []db.Transaction
+		hashes    []string
+	)
+
+	results := make([]models.BulkResult, 0, len(rows))
+	for _, row := range rows {
+		if row.CreatedAt.IsZero() {
+			results = append(results, models.BulkResult{ID: row.ID, Error: "transaction not found"})
+			continue
+		}
+
+		params := db.UpdateTransactionParams{
+			Amount:            row.Amount,
+			Currency:          row.Currency,
+			Category:          row.Category,
+			Description:       row.Description,
+			Confirm:           row.Confirm,
+			TransactionDate:   row.TransactionDate,
+			AccountID:         row.AccountID,
+			Type:              row.Type,
+			TransferAccountID: row.TransferAccountID,
+			ID:                row.ID,
+			Version:           row.Version,
+		}
+
+		// n is the number of transactions updated, which is zero when the transaction has
+		// changed since it was retrieved.
+		n := int64(1)
+		switch req.Action {
+		case models.BulkConfirm:
+			params.Confirm = true
+			n, err = q.UpdateTransaction(ctx, params)
+		case models.BulkDelete:
+			var deleted []string
+			if deleted, err = deleteTransaction(ctx, q, row.ID); err == nil {
+				hashes = append(hashes, deleted...)
+			}
+		case models.BulkSetCategory:
+			if !strings.EqualFold(row.Category, req.Category) {
+				corrected = append(corrected, *row)
+			}
+			params.Category = req.Category
+			n, err = q.UpdateTransaction(ctx, params)
+		case models.BulkAddTag:
+			if err = q.AddTransactionTag(ctx, db.AddTransactionTagParams{
+				TransactionID: row.ID,
+				TagID:         tag.ID,
+			}); err == nil {
+				err = q.TouchTransaction(ctx, row.ID)
+			}
+		case models.BulkRemoveTag:
+			if err = q.RemoveTransactionTag(ctx, db.RemoveTransactionTagParams{
+				TransactionID: row.ID,
+				Tag:           req.Tag,
+			}); err == nil {
+				err = q.TouchTransaction(ctx, row.ID)
+			}
+		case models.BulkSetAccount:
+			if row.TransferAccountID != nil && *row.TransferAccountID == *req.AccountID {
+				results = append(results, models.BulkResult{ID: row.ID, Error: "account_id must be different from transfer_account_id"})
+				continue
+			}
+			params.AccountID = req.AccountID
+			n, err = q.UpdateTransaction(ctx, params)
+		case models.BulkSetDate:
+			params.TransactionDate, _ = time.Parse("2006-01-02", req.TransactionDate)
+			n, err = q.UpdateTransaction(ctx, params)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error applying %s to transaction %d: %w", req.Action, row.ID, err)
+		}
+		if n == 0 {
+			results = append(results, models.BulkResult{ID: row.ID, Error: errStale.Error()})
+			continue
+		}
+		results = append(results, models.BulkResult{ID: row.ID, OK: true})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	a.cleanupBlobs(ctx, hashes)
+
+	// Recording the corrections is best effort, the transactions are already updated.
+	for _, row := range corrected {
+		if err := a.recordCorrection(ctx, row.Description, req.Category); err != nil {
+			a.log.Error("Error recording category correction", "error", err)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// reColor matches a hex color like #e67e22.
+var reColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// taxonomy resolves category names and aliases to the managed categories.
+type taxonomy struct {
+	categories []db.Category
+
+	// names maps the lowercased name or alias of a category to its name.
+	names map[string]string
+	// parents maps the lowercased name of a category to the name of its parent.
+	parents map[string]string
+}
+
+func (a *App) loadTaxonomy(ctx context.Context) (*taxonomy, error) {
+	categories, err := a.queries.ListCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing categories: %w", err)
+	}
+	aliases, err := a.queries.ListCategoryAliases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing category aliases: %w", err)
+	}
+
+	t := &taxonomy{
+		categories: categories,
+		names:      map[string]string{},
+		parents:    map[string]string{},
+	}
+	byID := map[int64]string{}
+	for _, c := range categories {
+		byID[c.ID] = c.Name
+		t.names[strings.ToLower(c.Name)] = c.Name
+	}
+	for _, c := range categories {
+		if c.ParentID != nil {
+			t.parents[strings.ToLower(c.Name)] = byID[*c.ParentID]
+		}
+	}
+	for _, al := range aliases {
+		t.names[strings.ToLower(al.Alias)] = byID[al.CategoryID]
+	}
+	return t, nil
+}
+
+// resolve returns the name of the category whose name or alias is category. Categories
+// which aren't managed are lowercased, so that they don't differ in case at least.
+func (t *taxonomy) resolve(category string) string {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if name, ok := t.names[category]; ok {
+		return name
+	}
+	return category
+}
+
+// root returns the top level category of category, which is itself if it has no parent.
+func (t *taxonomy) root(category string) string {
+	category = t.resolve(category)
+	// The hierarchy can't have cycles, the bound only guards against a corrupt one.
+	for range t.categories {
+		parent, ok := t.parents[strings.ToLower(category)]
+		if !ok {
+			break
+		}
+		category = parent
+	}
+	return category
+}
+
+// within reports whether category is ancestor or one of its subcategories, at any depth.
+func (t *taxonomy) within(category, ancestor string) bool {
+	category, ancestor = t.resolve(category), strings.ToLower(t.resolve(ancestor))
+	for range len(t.categories) + 1 {
+		if strings.ToLower(category) == ancestor {
+			return true
+		}
+		parent, ok := t.parents[strings.ToLower(category)]
+		if !ok {
+			return false
+		}
+		category = parent
+	}
+	return false
+}
+
+// family returns category along with all of its subcategories, at any depth.
+func (t *taxonomy) family(category string) []string {
+	category = t.resolve(category)
+	names := []string{category}
+	for _, c := range t.categories {
+		if !strings.EqualFold(c.Name, category) && t.within(c.Name, category) {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// list returns the names of all the categories, to be offered to the LLM.
+func (t *taxonomy) list() []string {
+	names := make([]string, len(t.categories))
+	for i, c := range t.categories {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// validateCategory normalises the category input and checks that it's valid. id is
+// the ID of the category being updated, zero when it's created.
+func (a *App) validateCategory(ctx context.Context, id int64, c *models.Category) error {
+	c.Name = strings.ToLower(strings.TrimSpace(c.Name))
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+
+	c.Color = strings.ToLower(strings.TrimSpace(c.Color))
+	if c.Color != "" && !reColor.MatchString(c.Color) {
+		return errors.New("invalid color, use a hex color like #e67e22")
+	}
+
+	t, err := a.loadTaxonomy(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The name can't be an alias of another category.
+	if name, ok := t.names[c.Name]; ok && !strings.EqualFold(name, c.Name) {
+		for _, cat := range t.categories {
+			if cat.Name == name && cat.ID != id {
+				return fmt.Errorf("%q is an alias of %s", c.Name, name)
+			}
+		}
+	}
+
+	// The parent can't be the category itself or one of its subcategories.
+	if c.ParentID != nil {
+		var parent *db.Category
+		for i, cat := range t.categories {
+			if cat.ID == *c.ParentID {
+				parent = &t.categories[i]
+			}
+		}
+		if parent == nil {
+			return errors.New("invalid parent_id")
+		}
+		if id != 0 {
+			for _, cat := range t.categories {
+				if cat.ID == id && t.within(parent.Name, cat.Name) {
+					return errors.New("parent_id can't be the category or one of its subcategories")
+				}
+			}
+		}
+	}
+
+	// An alias can't be the name of another category.
+	var aliases []string
+	seen := map[string]bool{}
+	for _, al := range c.Aliases {
+		al = strings.ToLower(strings.TrimSpace(al))
+		if al == "" || al == c.Name || seen[al] {
+			continue
+		}
+		seen[al] = true
+		for _, cat := range t.categories {
+			if cat.ID != id && strings.EqualFold(cat.Name, al) {
+				return fmt.Errorf("alias %q is the name of a category", al)
+			}
+		}
+		aliases = append(aliases, al)
+	}
+	c.Aliases = aliases
+	return nil
+}
+
+// saveCategory creates the category, or updates it when id isn't zero, along with its
+// aliases. Renaming a category moves its transactions, budgets, recurring rules,
+// category corrections and rules to the new name.
+func (a *App) saveCategory(ctx context.Context, id int64, input models.Category) (db.Category, error) {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return db.Category{}, err
+	}
+	defer tx.Rollback()
+	q := a.queries.WithTx(tx)
+
+	var cat db.Category
+	if id == 0 {
+		cat, err = q.CreateCategory(ctx, db.CreateCategoryParams{
+			CreatedAt: time.Now(),
+			Name:      input.Name,
+			ParentID:  input.ParentID,
+			Color:     input.Color,
+		})
+		if err != nil {
+			return db.Category{}, err
+		}
+	} else {
+		old, err := q.GetCategory(ctx, id)
+		if err != nil {
+			return db.Category{}, err
+		}
+		cat, err = q.UpdateCategory(ctx, db.UpdateCategoryParams{
+			Name:     input.Name,
+			ParentID: input.ParentID,
+			Color:    input.Color,
+			ID:       id,
+		})
+		if err != nil {
+			return db.Category{}, err
+		}
+
+		if old.Name != cat.Name {
+			if err := q.RenameTransactionsCategory(ctx, db.RenameTransactionsCategoryParams{NewName: cat.Name, OldName: old.Name}); err != nil {
+				return db.Category{}, err
+			}
+			if err := q.RenameBudgetsCategory(ctx, db.RenameBudgetsCategoryParams{NewName: cat.Name, OldName: old.Name}); err != nil {
+				return db.Category{}, err
+			}
+			if err := q.RenameRecurringRulesCategory(ctx, db.RenameRecurringRulesCategoryParams{NewName: cat.Name, OldName: old.Name}); err != nil {
+				return db.Category{}, err
+			}
+			if err := q.RenameCorrectionsCategory(ctx, db.RenameCorrectionsCategoryParams{NewName: cat.Name, OldName: old.Name}); err != nil {
+				return db.Category{}, err
+			}
+			if err := q.RenameRulesCategory(ctx, db.RenameRulesCategoryParams{NewName: cat.Name, OldName: old.Name}); err != nil {
+				return db.Category{}, err
+			}
+		}
+
+		if err := q.DeleteCategoryAliases(ctx, id); err != nil {
+			return db.Category{}, err
+		}
+	}
+
+	for _, al := range input.Aliases {
+		if err := q.AddCategoryAlias(ctx, db.AddCategoryAliasParams{
+			Alias:      al,
+			CategoryID: cat.ID,
+		}); err != nil {
+			return db.Category{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return db.Category{}, err
+	}
+	return cat, nil
+}
+
+// toCategory converts a category row to its API representation.
+func toCategory(c db.Category, aliases []db.CategoryAlias) models.Category {
+	out := models.Category{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt.Format(time.RFC3339),
+		Name:      c.Name,
+		ParentID:  c.ParentID,
+		Color:     c.Color,
+		Aliases:   []string{},
+	}
+	for _, al := range aliases {
+		if al.CategoryID == c.ID {
+			out.Aliases = append(out.Aliases, al.Alias)
+		}
+	}
+	return out
+}
+
+// rollup merges the totals of subcategories into their top level category.
+func (t *taxonomy) rollup(categories []CategorySummary) ([]CategorySummary, error) {
+	out := []CategorySummary{}
+	idx := map[string]int{}
+	for _, cat := range categories {
+		root := t.root(cat.Category)
+		if i, ok := idx[strings.ToLower(root)]; ok {
+			var err error
+			if out[i].TotalSpent, err = out[i].TotalSpent.Add(cat.TotalSpent); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		idx[strings.ToLower(root)] = len(out)
+		cat.Category = root
+		out = append(out, cat)
+	}
+	return out, nil
+}
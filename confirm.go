@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+	"github.com/mr-karan/gullak/internal/fx"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// confirmPolicy decides which parsed transactions are trusted enough to be saved as
+// confirmed, so that only the doubtful ones are left for review. The zero value
+// doesn't confirm any transaction.
+type confirmPolicy struct {
+	// MinConfidence is the confidence the model needs to have in a transaction, from
+	// 0 to 1. Zero turns auto-confirmation off.
+	MinConfidence float64
+	// MaxAmount is the largest amount which is confirmed, in the default currency.
+	MaxAmount *models.Decimal
+	// Categories are the categories, along with their subcategories, whose transactions
+	// are confirmed. All the categories are when it's empty.
+	Categories []string
+}
+
+// confirmPolicyConfig reads the auto-confirmation policy from the `[auto_confirm]` section.
+func confirmPolicyConfig(ko *koanf.Koanf) (confirmPolicy, error) {
+	p := confirmPolicy{
+		MinConfidence: ko.Float64("auto_confirm.min_confidence"),
+	}
+	if p.MinConfidence < 0 || p.MinConfidence > 1 {
+		return confirmPolicy{}, errors.New("auto_confirm.min_confidence should be between 0 and 1")
+	}
+
+	if s := ko.String("auto_confirm.max_amount"); s != "" {
+		amt, err := models.ParseDecimal(s)
+		if err != nil {
+			return confirmPolicy{}, fmt.Errorf("invalid auto_confirm.max_amount: %w", err)
+		}
+		p.MaxAmount = &amt
+	}
+
+	for _, c := range ko.Strings("auto_confirm.categories") {
+		if c = strings.TrimSpace(c); c != "" {
+			p.Categories = append(p.Categories, c)
+		}
+	}
+	return p, nil
+}
+
+// enabled reports whether the policy confirms any transaction at all.
+func (p confirmPolicy) enabled() bool {
+	return p.MinConfidence > 0
+}
+
+// trusts reports whether a parsed transaction can be confirmed without a review. The
+// category of the item has to be resolved. An amount in another currency is converted
+// to the default one to check it against the maximum, and isn't trusted without a rate.
+func (a *App) trusts(ctx context.Context, conv *fx.Converter, t *taxonomy, item models.Item, date time.Time) bool {
+	p := a.confirm
+	if !p.enabled() || item.Confidence < p.MinConfidence {
+		return false
+	}
+
+	if len(p.Categories) > 0 && !slices.ContainsFunc(p.Categories, func(c string) bool {
+		return t.within(item.Category, c)
+	}) {
+		return false
+	}
+
+	if p.MaxAmount != nil {
+		minor, err := item.Amount.Minor(item.Currency)
+		if err != nil {
+			return false
+		}
+		converted, err := conv.Convert(ctx, minor, item.Currency, a.currency, date)
+		if err != nil {
+			a.log.Debug("Not confirming transaction, error converting amount", "error", err, "description", item.Description)
+			return false
+		}
+		// An amount which can't be compared with the maximum isn't trusted either.
+		if c, err := models.FromMinor(converted, a.currency).Cmp(*p.MaxAmount); err != nil || c > 0 {
+			return false
+		}
+	}
+	return true
+}
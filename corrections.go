@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/internal/llm"
+)
+
+// fewShotExamples is the number of past corrections offered to the LLM as examples.
+const fewShotExamples = 5
+
+// recordCorrection remembers the category which the user corrected a description to.
+func (a *App) recordCorrection(ctx context.Context, description, category string) error {
+	description = strings.TrimSpace(description)
+	if description == "" || category == "" {
+		return nil
+	}
+
+	if err := a.queries.UpsertCategoryCorrection(ctx, db.UpsertCategoryCorrectionParams{
+		CreatedAt:   time.Now(),
+		Description: description,
+		Category:    category,
+	}); err != nil {
+		return fmt.Errorf("error saving category correction: %w", err)
+	}
+	return nil
+}
+
+// parseHints returns the hints for parsing the line: the categories, and the past
+// corrections of similar lines. The corrections are only a hint, the line is parsed
+// without them if they can't be retrieved.
+func (a *App) parseHints(ctx context.Context, line string) (llm.Hints, error) {
+	t, err := a.loadTaxonomy(ctx)
+	if err != nil {
+		return llm.Hints{}, err
+	}
+
+	examples, err := a.similarCorrections(ctx, line)
+	if err != nil {
+		a.log.Error("Error retrieving category corrections", "error", err)
+	}
+
+	return llm.Hints{
+		Categories: t.list(),
+		Examples:   examples,
+	}, nil
+}
+
+// similarCorrections returns the past corrections whose descriptions are the most
+// similar to the line, to be offered to the LLM as examples.
+func (a *App) similarCorrections(ctx context.Context, line string) ([]llm.Example, error) {
+	query := correctionsQuery(line)
+	if query == "" {
+		return nil, nil
+	}
+
+	rows, err := a.queries.SimilarCategoryCorrections(ctx, db.SimilarCategoryCorrectionsParams{
+		Query: query,
+		Limit: fewShotExamples,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving category corrections: %w", err)
+	}
+
+	examples := make([]llm.Example, len(rows))
+	for i, r := range rows {
+		examples[i] = llm.Example{Description: r.Description, Category: r.Category}
+	}
+	return examples, nil
+}
+
+// correctionsQuery builds a full text query which matches descriptions containing any
+// of the words of the line. Numbers, like amounts, and words shorter than a trigram are
+// left out.
+func correctionsQuery(line string) string {
+	words := strings.FieldsFunc(strings.ToLower(line), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	var terms []string
+	seen := map[string]bool{}
+	for _, w := range words {
+		if utf8.RuneCountInString(w) < 3 || seen[w] || strings.IndexFunc(w, unicode.IsLetter) == -1 {
+			continue
+		}
+		seen[w] = true
+		terms = append(terms, `"`+w+`"`)
+	}
+	return strings.Join(terms, " OR ")
+}
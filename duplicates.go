@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+const (
+	// duplicateWindow is how far back transactions are looked at for duplicates of a new
+	// one. Logging the same expense twice, say from the Shortcut and the web UI, happens
+	// within a short while.
+	duplicateWindow = 48 * time.Hour
+
+	// duplicateSimilarity is the similarity of the trigrams of two descriptions above
+	// which they're considered to be of the same expense.
+	duplicateSimilarity = 0.5
+)
+
+// findDuplicate returns the ID of the transaction which the new transaction looks like
+// a duplicate of: one from another entry on the same day, with the same amount and type
+// and a similar description. It's nil when there's none.
+func findDuplicate(ctx context.Context, q *db.Queries, t db.Transaction) (*int64, error) {
+	day := dateOf(t.TransactionDate)
+	candidates, err := q.ListDuplicateCandidates(ctx, db.ListDuplicateCandidatesParams{
+		Amount:       t.Amount,
+		Currency:     t.Currency,
+		Type:         t.Type,
+		DayStart:     day,
+		DayEnd:       day.AddDate(0, 0, 1),
+		EntryID:      t.EntryID,
+		CreatedAfter: t.CreatedAt.Add(-duplicateWindow),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing duplicate candidates: %w", err)
+	}
+
+	for _, c := range candidates {
+		if c.ID != t.ID && similarDescriptions(c.Description, t.Description) {
+			return &c.ID, nil
+		}
+	}
+	return nil, nil
+}
+
+// similarDescriptions reports whether two descriptions are likely of the same expense,
+// eg: "Coffee, Blue Tokai" and "blue tokai coffee" or "Uber to office" and "uber office".
+// A description which only shares a word with a longer one, eg: "coffee" and "coffee
+// beans 1kg", is of another expense.
+func similarDescriptions(a, b string) bool {
+	wa, wb := descriptionWords(a), descriptionWords(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return len(wa) == len(wb)
+	}
+
+	// The same words, in any order.
+	if sameWords(wa, wb) {
+		return true
+	}
+
+	return trigramSimilarity(strings.Join(wa, " "), strings.Join(wb, " ")) >= duplicateSimilarity
+}
+
+// sameWords reports whether the words of a are all in b, and those of b all in a.
+func sameWords(a, b []string) bool {
+	return containsWords(a, b) && containsWords(b, a)
+}
+
+// containsWords reports whether all the words of sub are in words.
+func containsWords(words, sub []string) bool {
+	set := map[string]bool{}
+	for _, w := range words {
+		set[w] = true
+	}
+	for _, w := range sub {
+		if !set[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// descriptionWords returns the lowercased words of a description, without punctuation.
+func descriptionWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// trigramSimilarity returns the Jaccard similarity of the trigrams of two strings,
+// from 0 for nothing in common to 1 for the same trigrams.
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	var common int
+	for t := range ta {
+		if tb[t] {
+			common++
+		}
+	}
+	total := len(ta) + len(tb) - common
+	if total == 0 {
+		return 0
+	}
+	return float64(common) / float64(total)
+}
+
+// trigrams returns the set of the trigrams of s, padded so that short words have them too.
+func trigrams(s string) map[string]bool {
+	r := []rune("  " + s + " ")
+	out := map[string]bool{}
+	for i := 0; i+3 <= len(r); i++ {
+		out[string(r[i:i+3])] = true
+	}
+	return out
+}
+
+// duplicateWarnings returns a warning for every saved transaction which looks like a
+// duplicate, so that it can be reviewed.
+func duplicateWarnings(items []models.Item) []string {
+	var warnings []string
+	for _, item := range items {
+		if item.DuplicateOf != nil {
+			warnings = append(warnings, fmt.Sprintf("%s looks like a duplicate of transaction %d", item.Description, *item.DuplicateOf))
+		}
+	}
+	return warnings
+}
+
+// errNotDuplicate is returned when a transaction isn't flagged as a duplicate.
+var errNotDuplicate = errors.New("duplicate not found")
+
+// mergeDuplicate merges a duplicate into its original: the tags and attachments of the
+// duplicate are added to the original, and the duplicate is deleted. It returns the original.
+func (a *App) mergeDuplicate(ctx context.Context, id int64) (db.Transaction, error) {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return db.Transaction{}, err
+	}
+	defer tx.Rollback()
+	q := a.queries.WithTx(tx)
+
+	dup, err := q.GetTransaction(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && dup.DuplicateOf == nil) {
+		return db.Transaction{}, errNotDuplicate
+	}
+	if err != nil {
+		return db.Transaction{}, err
+	}
+
+	tags, err := q.ListTagsByTransaction(ctx, dup.ID)
+	if err != nil {
+		return db.Transaction{}, err
+	}
+	for _, name := range tags {
+		tag, err := q.UpsertTag(ctx, name)
+		if err != nil {
+			return db.Transaction{}, err
+		}
+		if err := q.AddTransactionTag(ctx, db.AddTransactionTagParams{
+			TransactionID: *dup.DuplicateOf,
+			TagID:         tag.ID,
+		}); err != nil {
+			return db.Transaction{}, err
+		}
+	}
+	if len(tags) > 0 {
+		if err := q.TouchTransaction(ctx, *dup.DuplicateOf); err != nil {
+			return db.Transaction{}, err
+		}
+	}
+
+	if err := q.MoveAttachments(ctx, db.MoveAttachmentsParams{
+		OriginalID:  *dup.DuplicateOf,
+		DuplicateID: dup.ID,
+	}); err != nil {
+		return db.Transaction{}, err
+	}
+
+	hashes, err := deleteTransaction(ctx, q, dup.ID)
+	if err != nil {
+		return db.Transaction{}, err
+	}
+
+	original, err := q.GetTransaction(ctx, *dup.DuplicateOf)
+	if err != nil {
+		return db.Transaction{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return db.Transaction{}, err
+	}
+	a.cleanupBlobs(ctx, hashes)
+	return original, nil
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/internal/fx"
+	"github.com/mr-karan/gullak/internal/llm"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// maxPageLimit is the most transactions which are listed in a page.
+const maxPageLimit = 500
+
+// transactionSorts are the orders the transactions can be listed in. A leading - sorts
+// in descending order.
+var transactionSorts = map[string]bool{
+	"date": true, "-date": true,
+	"amount": true, "-amount": true,
+	"category": true, "-category": true,
+}
+
+// defaultSort lists the newest transactions first.
+const defaultSort = "-date"
+
+// filterParams converts a filter to the parameters of ListTransactions.
+func (a *App) filterParams(ctx context.Context, f models.TransactionFilter) (db.ListTransactionsParams, error) {
+	var params db.ListTransactionsParams
+	if f.Confirm != nil {
+		params.Confirm = *f.Confirm
+	}
+	if f.NeedsReparse != nil {
+		params.NeedsReparse = *f.NeedsReparse
+	}
+	if f.AccountID != nil {
+		params.AccountID = *f.AccountID
+	}
+
+	var startDate, endDate time.Time
+	var err error
+	if f.StartDate != "" {
+		if startDate, err = time.Parse("2006-01-02", f.StartDate); err != nil {
+			return db.ListTransactionsParams{}, errors.New("invalid start_date format, use YYYY-MM-DD")
+		}
+		params.StartDate = startDate
+	}
+	if f.EndDate != "" {
+		if endDate, err = time.Parse("2006-01-02", f.EndDate); err != nil {
+			return db.ListTransactionsParams{}, errors.New("invalid end_date format, use YYYY-MM-DD")
+		}
+		params.EndDate = endDate
+	}
+	if f.StartDate != "" && f.EndDate != "" {
+		if err := validateDateRange(startDate, endDate); err != nil {
+			return db.ListTransactionsParams{}, err
+		}
+	}
+
+	if f.Type != "" {
+		typ, err := transactionType(f.Type)
+		if err != nil {
+			return db.ListTransactionsParams{}, err
+		}
+		params.Type = typ
+	}
+	if tag := llm.NormalizeTags([]string{f.Tag}); tag != nil {
+		params.Tag = tag[0]
+	}
+
+	if c := strings.TrimSpace(f.Category); c != "" {
+		t, err := a.loadTaxonomy(ctx)
+		if err != nil {
+			return db.ListTransactionsParams{}, err
+		}
+		b, err := json.Marshal(t.family(c))
+		if err != nil {
+			return db.ListTransactionsParams{}, err
+		}
+		params.Categories = string(b)
+	}
+
+	if c := strings.ToUpper(strings.TrimSpace(f.Currency)); c != "" {
+		if !fx.IsCurrency(c) {
+			return db.ListTransactionsParams{}, errors.New("invalid currency, use an ISO 4217 code like USD")
+		}
+		params.Currency = c
+	}
+
+	if f.MinAmount != nil && f.MaxAmount != nil {
+		c, err := f.MinAmount.Cmp(*f.MaxAmount)
+		if err != nil {
+			return db.ListTransactionsParams{}, fmt.Errorf("invalid min_amount or max_amount: %w", err)
+		}
+		if c > 0 {
+			return db.ListTransactionsParams{}, errors.New("min_amount can't be more than max_amount")
+		}
+	}
+	if f.MinAmount != nil {
+		if params.MinAmount, err = amountBound(*f.MinAmount, true); err != nil {
+			return db.ListTransactionsParams{}, fmt.Errorf("invalid min_amount: %w", err)
+		}
+	}
+	if f.MaxAmount != nil {
+		if params.MaxAmount, err = amountBound(*f.MaxAmount, false); err != nil {
+			return db.ListTransactionsParams{}, fmt.Errorf("invalid max_amount: %w", err)
+		}
+	}
+
+	if d := strings.TrimSpace(f.Description); d != "" {
+		// The wildcards of LIKE match themselves.
+		params.Description = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(d)
+	}
+	return params, nil
+}
+
+// amountBound converts a bound on the amount of transactions to a JSON object of the
+// bound in minor units of each currency, as ListTransactions compares them. The
+// currencies with two decimal places are under "*". A lower bound is rounded up and an
+// upper one down, so that they don't match amounts past the bound.
+func amountBound(d models.Decimal, lower bool) (string, error) {
+	minor := func(currency string) (int64, error) {
+		v, err := d.Minor(currency)
+		if err != nil {
+			return 0, err
+		}
+		c, err := models.FromMinor(v, currency).Cmp(d)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case lower && c < 0:
+			v++
+		case !lower && c > 0:
+			v--
+		}
+		return v, nil
+	}
+
+	bounds := map[string]int64{}
+	v, err := minor("")
+	if err != nil {
+		return "", err
+	}
+	bounds["*"] = v
+	for currency := range models.CurrencyExponents() {
+		if bounds[currency], err = minor(currency); err != nil {
+			return "", err
+		}
+	}
+
+	b, err := json.Marshal(bounds)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// countParams returns the parameters of CountTransactions for the filters of ListTransactions.
+func countParams(p db.ListTransactionsParams) db.CountTransactionsParams {
+	return db.CountTransactionsParams{
+		Confirm:      p.Confirm,
+		StartDate:    p.StartDate,
+		EndDate:      p.EndDate,
+		NeedsReparse: p.NeedsReparse,
+		AccountID:    p.AccountID,
+		Type:         p.Type,
+		Tag:          p.Tag,
+		Categories:   p.Categories,
+		Currency:     p.Currency,
+		MinAmount:    p.MinAmount,
+		MaxAmount:    p.MaxAmount,
+		Description:  p.Description,
+	}
+}
+
+// pageCursor is the position in the list of transactions after which the next page
+// starts: the sort value and ID of the last transaction of a page. It's passed around
+// as an opaque string.
+type pageCursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    int64           `json:"id"`
+}
+
+// nextCursor returns the cursor of the page which follows the transaction t.
+func nextCursor(sort string, t db.Transaction) string {
+	var v any
+	switch strings.TrimPrefix(sort, "-") {
+	case "amount":
+		v = t.Amount
+	case "category":
+		v = strings.ToLower(t.Category)
+	default:
+		v = t.TransactionDate.Format(time.RFC3339Nano)
+	}
+	value, _ := json.Marshal(v)
+	b, _ := json.Marshal(pageCursor{Sort: sort, Value: value, ID: t.ID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// setCursor sets the cursor parameters of ListTransactions from a cursor returned for
+// the same sort.
+func setCursor(params *db.ListTransactionsParams, sort, cursor string) error {
+	errInvalid := errors.New("invalid cursor, use the next_cursor of the previous page")
+
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return errInvalid
+	}
+	var c pageCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return errInvalid
+	}
+	if c.Sort != sort {
+		return errors.New("cursor is of another sort, pass the same sort as the previous page")
+	}
+
+	switch strings.TrimPrefix(sort, "-") {
+	case "amount":
+		var v int64
+		if err := json.Unmarshal(c.Value, &v); err != nil {
+			return errInvalid
+		}
+		params.CursorValue = v
+	case "category":
+		var v string
+		if err := json.Unmarshal(c.Value, &v); err != nil {
+			return errInvalid
+		}
+		params.CursorValue = v
+	default:
+		var v string
+		if err := json.Unmarshal(c.Value, &v); err != nil {
+			return errInvalid
+		}
+		date, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return errInvalid
+		}
+		params.CursorValue = date
+	}
+	params.CursorID = c.ID
+	return nil
+}
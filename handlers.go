@@ -2,13 +2,23 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/internal/fx"
 	"github.com/mr-karan/gullak/internal/llm"
 	"github.com/mr-karan/gullak/pkg/models"
 )
@@ -17,22 +27,66 @@ type ExpenseInput struct {
 	Line string `json:"line"`
 }
 
+type AskInput struct {
+	Question string `json:"question"`
+}
+
 type Resp struct {
-	Message string      `json:"message,omitempty"`
-	Error   string      `json:"error,omitempty"`
-	Data    interface{} `json:"data"`
+	Message  string      `json:"message,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	Warnings []string    `json:"warnings,omitempty"`
+	Data     interface{} `json:"data"`
+	// Total is the number of items across all the pages of a paginated list, and
+	// NextCursor is passed to get its next page. It's empty on the last page.
+	Total      *int64 `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type EntryDetail struct {
+	db.Entry
+	Transactions []models.Item `json:"transactions"`
 }
 
 type CategorySummary struct {
-	Category   string  `json:"category"`
-	TotalSpent float64 `json:"total_spent"`
+	Category   string         `json:"category"`
+	TotalSpent models.Decimal `json:"total_spent"`
+	Currency   string         `json:"currency"`
+}
+
+type TagSummary struct {
+	Tag          string         `json:"tag"`
+	Transactions int64          `json:"transactions"`
+	TotalSpent   models.Decimal `json:"total_spent"`
+	Currency     string         `json:"currency"`
 }
 
 type DailySpendingSummary struct {
-	TransactionDate string  `json:"transaction_date"`
-	TotalSpent      float64 `json:"total_spent"`
+	TransactionDate string         `json:"transaction_date"`
+	TotalSpent      models.Decimal `json:"total_spent"`
+	Currency        string         `json:"currency"`
+}
+
+type AccountBalance struct {
+	TransactionDate string         `json:"transaction_date"`
+	Received        models.Decimal `json:"received"`
+	Spent           models.Decimal `json:"spent"`
+	Balance         models.Decimal `json:"balance"`
+	Currency        string         `json:"currency"`
 }
 
+type CashFlowSummary struct {
+	Income   models.Decimal `json:"income"`
+	Expenses models.Decimal `json:"expenses"`
+	Net      models.Decimal `json:"net"`
+	// SavingsRate is the percentage of the income which wasn't spent. It's
+	// null when there's no income in the period.
+	SavingsRate *float64 `json:"savings_rate"`
+	Currency    string   `json:"currency"`
+}
+
+// topCategoriesLimit is the number of categories returned by the top expense categories report.
+const topCategoriesLimit = 5
+
 func handleIndex(c echo.Context) error {
 	return c.JSON(http.StatusOK, Resp{
 		Message: "Welcome to Gullak. POST to /api/transactions to save expenses.",
@@ -56,39 +110,265 @@ func handleCreateTransaction(c echo.Context) error {
 		})
 	}
 
-	transactions, err := m.llm.Parse(input.Line)
+	// A retried request with the same Idempotency-Key gets the response of the original
+	// one, instead of saving the transactions again.
+	key := strings.TrimSpace(c.Request().Header.Get("Idempotency-Key"))
+	if len(key) > maxIdempotencyKeyLen {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: fmt.Sprintf("Idempotency-Key can't be longer than %d characters", maxIdempotencyKeyLen),
+		})
+	}
+	if key != "" {
+		replay, err := m.claimIdempotencyKey(c.Request().Context(), key, input.Line)
+		switch {
+		case errors.Is(err, errIdempotencyKeyReused):
+			return c.JSON(http.StatusUnprocessableEntity, Resp{Error: err.Error()})
+		case errors.Is(err, errIdempotencyKeyInProgress):
+			return c.JSON(http.StatusConflict, Resp{Error: err.Error()})
+		case err != nil:
+			m.log.Error("Error claiming idempotency key", "error", err)
+			return c.JSON(http.StatusInternalServerError, Resp{
+				Error: "Error saving expenses",
+			})
+		case replay != nil:
+			c.Response().Header().Set("Idempotent-Replayed", "true")
+			return c.JSONBlob(http.StatusOK, replay)
+		}
+
+		// The key is released when the request fails, so that it can be retried. It's
+		// kept once the transactions are saved.
+		defer func() {
+			if err := m.releaseIdempotencyKey(context.Background(), key); err != nil {
+				m.log.Error("Error releasing idempotency key", "error", err)
+			}
+		}()
+	}
+
+	hints, err := m.parseHints(c.Request().Context(), input.Line)
+	if err != nil {
+		m.log.Error("Error loading categories", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error saving expenses",
+		})
+	}
+
+	res, err := m.llm.Parse(c.Request().Context(), input.Line, hints)
+	if err != nil {
+		var noTxErr *llm.NoValidTransactionError
+		if errors.As(err, &noTxErr) {
+			m.log.Error("No valid transactions found", "error", noTxErr)
+			return c.JSON(http.StatusBadRequest, Resp{
+				Error: noTxErr.Error(),
+			})
+		}
+		m.log.Error("Error parsing expenses", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Error parsing expenses",
+		})
+	}
+
+	savedTransactions, err := m.Save(c.Request().Context(), input.Line, res, key)
+	if err != nil {
+		m.log.Error("Error saving transactions", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error saving transactions",
+		})
+	}
+
+	// Budget warnings are best effort, the transactions are already saved.
+	warnings, err := m.budgetWarnings(c.Request().Context(), savedTransactions)
+	if err != nil {
+		m.log.Error("Error checking budgets", "error", err)
+	}
+	warnings = append(warnings, duplicateWarnings(savedTransactions)...)
+
+	resp := Resp{
+		Message:  "Expenses saved",
+		Warnings: warnings,
+		Data:     savedTransactions,
+	}
+	// Saving the response is best effort too, retries rebuild it from the transactions.
+	if key != "" {
+		if err := m.completeIdempotencyKey(context.Background(), key, resp); err != nil {
+			m.log.Error("Error saving idempotency key response", "error", err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// handleCreateReceipt reads the transactions from the photos of a receipt, uploaded as
+// image fields of a multipart form, and attaches the photos to them.
+func handleCreateReceipt(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid form, upload the photos of the receipt as image",
+		})
+	}
+	images, err := readReceipt(form.File["image"])
+	if err != nil {
+		var upErr *uploadError
+		if errors.As(err, &upErr) {
+			return c.JSON(http.StatusBadRequest, Resp{Error: err.Error()})
+		}
+		m.log.Error("Error reading receipt", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error saving receipt",
+		})
+	}
+
+	t, err := m.loadTaxonomy(c.Request().Context())
+	if err != nil {
+		m.log.Error("Error loading categories", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error saving receipt",
+		})
+	}
+
+	photos := make([]llm.Image, len(images))
+	for i, img := range images {
+		photos[i] = img.Image
+	}
+	res, err := m.llm.ParseReceipt(c.Request().Context(), photos, llm.Hints{
+		Categories: t.list(),
+	})
+	if err != nil {
+		var noTxErr *llm.NoValidTransactionError
+		switch {
+		case errors.As(err, &noTxErr):
+			m.log.Error("No valid transactions found", "error", noTxErr)
+			return c.JSON(http.StatusBadRequest, Resp{Error: noTxErr.Error()})
+		case errors.Is(err, llm.ErrNoVision):
+			return c.JSON(http.StatusBadRequest, Resp{Error: err.Error()})
+		}
+		m.log.Error("Error parsing receipt", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Error parsing receipt",
+		})
+	}
+
+	savedTransactions, err := m.saveReceipt(c.Request().Context(), images, res)
+	if err != nil {
+		m.log.Error("Error saving transactions", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error saving transactions",
+		})
+	}
+
+	// Budget warnings are best effort, the transactions are already saved.
+	warnings, err := m.budgetWarnings(c.Request().Context(), savedTransactions)
+	if err != nil {
+		m.log.Error("Error checking budgets", "error", err)
+	}
+	warnings = append(warnings, duplicateWarnings(savedTransactions)...)
+
+	return c.JSON(http.StatusOK, Resp{
+		Message:  "Receipt saved",
+		Warnings: warnings,
+		Data:     savedTransactions,
+	})
+}
+
+// handleCreateAudio transcribes a voice note, uploaded as the audio field of a multipart
+// form, and saves the transactions parsed from the transcript.
+func handleCreateAudio(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	fh, err := c.FormFile("audio")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid form, upload the voice note as audio",
+		})
+	}
+	audio, err := readAudio(fh)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{Error: err.Error()})
+	}
+
+	transcript, err := m.llm.Transcribe(c.Request().Context(), audio)
+	if err != nil {
+		if errors.Is(err, llm.ErrNoTranscription) {
+			return c.JSON(http.StatusBadRequest, Resp{Error: err.Error()})
+		}
+		m.log.Error("Error transcribing audio", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Error transcribing audio",
+		})
+	}
+	if transcript == "" {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "No speech found in the audio",
+		})
+	}
+
+	// The transcript is returned even when nothing is saved, to show what was heard.
+	note := models.VoiceNote{Transcript: transcript, Transactions: []models.Item{}}
+
+	hints, err := m.parseHints(c.Request().Context(), transcript)
+	if err != nil {
+		m.log.Error("Error loading categories", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error saving expenses",
+			Data:  note,
+		})
+	}
+
+	res, err := m.llm.Parse(c.Request().Context(), transcript, hints)
 	if err != nil {
 		var noTxErr *llm.NoValidTransactionError
 		if errors.As(err, &noTxErr) {
 			m.log.Error("No valid transactions found", "error", noTxErr)
 			return c.JSON(http.StatusBadRequest, Resp{
 				Error: noTxErr.Error(),
+				Data:  note,
 			})
 		}
 		m.log.Error("Error parsing expenses", "error", err)
 		return c.JSON(http.StatusBadRequest, Resp{
 			Error: "Error parsing expenses",
+			Data:  note,
 		})
 	}
 
-	savedTransactions, err := m.Save(transactions)
+	savedTransactions, err := m.Save(c.Request().Context(), transcript, res, "")
 	if err != nil {
 		m.log.Error("Error saving transactions", "error", err)
 		return c.JSON(http.StatusInternalServerError, Resp{
 			Error: "Error saving transactions",
+			Data:  note,
 		})
 	}
+	note.Transactions = savedTransactions
+
+	// Budget warnings are best effort, the transactions are already saved.
+	warnings, err := m.budgetWarnings(c.Request().Context(), note.Transactions)
+	if err != nil {
+		m.log.Error("Error checking budgets", "error", err)
+	}
+	warnings = append(warnings, duplicateWarnings(note.Transactions)...)
 
 	return c.JSON(http.StatusOK, Resp{
-		Message: "Expenses saved",
-		Data:    savedTransactions,
+		Message:  "Voice note saved",
+		Warnings: warnings,
+		Data:     note,
 	})
 }
 
 func handleListTransactions(c echo.Context) error {
 	m := c.Get("app").(*App)
 
-	var params db.ListTransactionsParams
+	f := models.TransactionFilter{
+		StartDate:   c.QueryParam("start_date"),
+		EndDate:     c.QueryParam("end_date"),
+		Type:        c.QueryParam("type"),
+		Tag:         c.QueryParam("tag"),
+		Category:    c.QueryParam("category"),
+		Currency:    c.QueryParam("currency"),
+		Description: c.QueryParam("description"),
+	}
 
 	if confirmStr := c.QueryParam("confirm"); confirmStr != "" {
 		// Convert and check the confirm parameter
@@ -96,42 +376,68 @@ func handleListTransactions(c echo.Context) error {
 		if err != nil {
 			return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid confirm value"})
 		}
-		params.Confirm = confirm
-	} else {
-		params.Confirm = nil // Explicitly setting as nil if not provided
+		f.Confirm = &confirm
 	}
 
-	startDateStr := c.QueryParam("start_date")
-	endDateStr := c.QueryParam("end_date")
-	var startDate, endDate time.Time
-	var err error
-
-	if startDateStr != "" {
-		startDate, err = time.Parse("2006-01-02", startDateStr)
+	if reparseStr := c.QueryParam("needs_reparse"); reparseStr != "" {
+		reparse, err := strconv.ParseBool(reparseStr)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid start date format"})
+			return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid needs_reparse value"})
 		}
-		params.StartDate = startDate
-	} else {
-		params.StartDate = nil // Explicitly setting as nil if not provided
+		f.NeedsReparse = &reparse
 	}
 
-	if endDateStr != "" {
-		endDate, err = time.Parse("2006-01-02", endDateStr)
+	if s := c.QueryParam("account_id"); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid end date format"})
+			return c.JSON(http.StatusBadRequest, Resp{Error: "invalid account_id"})
 		}
-		params.EndDate = endDate
-	} else {
-		params.EndDate = nil // Explicitly setting as nil if not provided
+		f.AccountID = &id
 	}
 
-	// Validate the date range if both dates are provided
-	if startDateStr != "" && endDateStr != "" {
-		if err := validateDateRange(startDate, endDate); err != nil {
-			return c.JSON(http.StatusBadRequest, Resp{
-				Error: err.Error(),
-			})
+	for _, p := range []struct {
+		name string
+		amt  **models.Decimal
+	}{{"min_amount", &f.MinAmount}, {"max_amount", &f.MaxAmount}} {
+		if s := c.QueryParam(p.name); s != "" {
+			amt, err := models.ParseDecimal(s)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, Resp{Error: fmt.Sprintf("Invalid %s value", p.name)})
+			}
+			*p.amt = &amt
+		}
+	}
+
+	params, err := m.filterParams(context.Background(), f)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{Error: err.Error()})
+	}
+
+	sort := strings.ToLower(strings.TrimSpace(c.QueryParam("sort")))
+	if sort == "" {
+		sort = defaultSort
+	}
+	if !transactionSorts[sort] {
+		return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid sort, use one of date, amount, category, with a leading - for descending order"})
+	}
+	// Amounts are in minor units of their currency, so they're only sorted within one.
+	if strings.TrimPrefix(sort, "-") == "amount" && params.Currency == nil {
+		return c.JSON(http.StatusBadRequest, Resp{Error: "Sorting by amount needs a currency, as amounts in different currencies can't be compared"})
+	}
+	params.Sort = sort
+
+	// Without a limit, all the transactions are listed.
+	var limit int
+	if s := c.QueryParam("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 || limit > maxPageLimit {
+			return c.JSON(http.StatusBadRequest, Resp{Error: fmt.Sprintf("Invalid limit, use a number from 1 to %d", maxPageLimit)})
+		}
+		// One more transaction is fetched to know whether there's a next page.
+		params.Limit = limit + 1
+	}
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		if err := setCursor(&params, sort, cursor); err != nil {
+			return c.JSON(http.StatusBadRequest, Resp{Error: err.Error()})
 		}
 	}
 
@@ -141,9 +447,40 @@ func handleListTransactions(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving transactions"})
 	}
 
+	var next string
+	if limit > 0 && len(transactions) > limit {
+		transactions = transactions[:limit]
+		next = nextCursor(sort, transactions[limit-1])
+	}
+
+	total, err := m.queries.CountTransactions(context.Background(), countParams(params))
+	if err != nil {
+		m.log.Error("Error counting transactions", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving transactions"})
+	}
+
+	// The tags are retrieved for the transactions in the page.
+	items := toItems(transactions)
+	if len(transactions) > 0 {
+		ids := make([]int64, len(transactions))
+		for i, t := range transactions {
+			ids[i] = t.ID
+		}
+		// The IDs are passed as a JSON array, as a query can't take a list.
+		b, _ := json.Marshal(ids)
+		tags, err := m.queries.ListTransactionTags(context.Background(), string(b))
+		if err != nil {
+			m.log.Error("Error retrieving transaction tags", "error", err)
+			return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving transactions"})
+		}
+		attachTags(items, tags)
+	}
+
 	return c.JSON(http.StatusOK, Resp{
-		Data:    transactions,
-		Message: "Transactions retrieved",
+		Data:       items,
+		Message:    "Transactions retrieved",
+		Total:      &total,
+		NextCursor: next,
 	})
 }
 
@@ -167,12 +504,29 @@ func handleGetTransaction(c echo.Context) error {
 		})
 	}
 
+	items := []models.Item{toItem(transaction)}
+	if err := m.withTags(context.Background(), items); err != nil {
+		m.log.Error("Error retrieving transaction tags", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving transaction",
+		})
+	}
+	if items[0].Attachments, err = m.listAttachments(context.Background(), id); err != nil {
+		m.log.Error("Error retrieving transaction attachments", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving transaction",
+		})
+	}
+
+	c.Response().Header().Set("ETag", etag(transaction.Version))
 	return c.JSON(http.StatusOK, Resp{
-		Data:    transaction,
+		Data:    items[0],
 		Message: "Transaction retrieved",
 	})
 }
 
+// handleUpdateTransaction replaces a transaction with the one in the request. With an
+// If-Match header, the update is rejected if the transaction has changed since.
 func handleUpdateTransaction(c echo.Context) error {
 	m := c.Get("app").(*App)
 	idStr := c.Param("id")
@@ -193,39 +547,20 @@ func handleUpdateTransaction(c echo.Context) error {
 		})
 	}
 
-	// Ensure transaction_date is in the correct format
-	transactionDate, err := time.Parse("2006-01-02", input.TransactionDate)
+	version, err := ifMatch(c)
 	if err != nil {
-		m.log.Error("Error parsing transaction date", "error", err)
 		return c.JSON(http.StatusBadRequest, Resp{
-			Error: "Invalid transaction date",
-		})
-	}
-
-	params := db.UpdateTransactionParams{
-		Amount:          input.Amount,
-		Currency:        input.Currency,
-		Category:        input.Category,
-		Description:     input.Description,
-		Confirm:         input.Confirm,
-		TransactionDate: transactionDate,
-		ID:              id,
-	}
-
-	if err := m.queries.UpdateTransaction(context.Background(), params); err != nil {
-		m.log.Error("Error updating transaction", "error", err)
-		return c.JSON(http.StatusInternalServerError, Resp{
-			Error: "Error updating transaction",
+			Error: err.Error(),
 		})
 	}
 
-	return c.JSON(http.StatusOK, Resp{
-		Message: "Transaction updated",
-		Data:    params,
-	})
+	return m.updateTransaction(c, id, input, version)
 }
 
-func handleDeleteTransaction(c echo.Context) error {
+// handlePatchTransaction updates the fields of a transaction which are in the request,
+// as a JSON merge patch (RFC 7386). With an If-Match header, the update is rejected if
+// the transaction has changed since.
+func handlePatchTransaction(c echo.Context) error {
 	m := c.Get("app").(*App)
 	idStr := c.Param("id")
 
@@ -237,97 +572,1877 @@ func handleDeleteTransaction(c echo.Context) error {
 		})
 	}
 
-	if err := m.queries.DeleteTransaction(context.Background(), id); err != nil {
-		m.log.Error("Error deleting transaction", "error", err)
-		return c.JSON(http.StatusInternalServerError, Resp{
-			Error: "Error deleting transaction",
+	version, err := ifMatch(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
 		})
 	}
 
-	return c.JSON(http.StatusOK, Resp{
-		Message: "Transaction deleted",
-	})
-}
+	patch, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		m.log.Error("Error reading input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
 
-func handleTopExpenseCategories(c echo.Context) error {
-	m := c.Get("app").(*App)
-	startDateStr := c.QueryParam("start_date")
-	endDateStr := c.QueryParam("end_date")
+	transaction, err := m.queries.GetTransaction(context.Background(), id)
+	if err != nil {
+		m.log.Error("Error retrieving transaction", "error", err)
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Transaction not found",
+		})
+	}
+	if version != nil && *version != transaction.Version {
+		return c.JSON(http.StatusPreconditionFailed, Resp{
+			Error: errStale.Error(),
+		})
+	}
 
-	if startDateStr == "" || endDateStr == "" {
+	// The patch is applied to the version of the transaction that was just retrieved.
+	input, err := patchItem(toItem(transaction), patch)
+	if err != nil {
 		return c.JSON(http.StatusBadRequest, Resp{
-			Error: "Missing required parameters: start_date, end_date",
+			Error: err.Error(),
 		})
 	}
 
-	// Parse start date and end date strings into time.Time
-	startDate, err := time.Parse("2006-01-02", startDateStr)
+	return m.updateTransaction(c, id, input, &transaction.Version)
+}
+
+// updateTransaction validates and saves the update of a transaction, and responds with
+// the updated transaction. version is the version which the update is based on, the
+// current one when it's nil.
+func (m *App) updateTransaction(c echo.Context, id int64, input models.Item, version *int64) error {
+	// Ensure transaction_date is in the correct format
+	transactionDate, err := time.Parse("2006-01-02", input.TransactionDate)
 	if err != nil {
-		m.log.Error("Invalid start date", "error", err)
+		m.log.Error("Error parsing transaction date", "error", err)
 		return c.JSON(http.StatusBadRequest, Resp{
-			Error: "Invalid start date format, use YYYY-MM-DD",
+			Error: "Invalid transaction date",
 		})
 	}
 
-	endDate, err := time.Parse("2006-01-02", endDateStr)
+	input.Currency = m.currencyOf(input.Currency)
+	amount, err := input.Amount.Minor(input.Currency)
 	if err != nil {
-		m.log.Error("Invalid end date", "error", err)
+		m.log.Error("Error converting amount", "error", err)
 		return c.JSON(http.StatusBadRequest, Resp{
-			Error: "Invalid end date format, use YYYY-MM-DD",
+			Error: "Invalid amount",
 		})
 	}
 
-	// Validate the date range
-	if err := validateDateRange(startDate, endDate); err != nil {
+	if input.Type, err = transactionType(input.Type); err != nil {
 		return c.JSON(http.StatusBadRequest, Resp{
 			Error: err.Error(),
 		})
 	}
 
-	params := db.TopExpenseCategoriesParams{
-		StartDate: startDate,
-		EndDate:   endDate,
-	}
-
-	rawCategories, err := m.queries.TopExpenseCategories(context.Background(), params)
+	t, err := m.loadTaxonomy(context.Background())
 	if err != nil {
-		m.log.Error("Error retrieving top expense categories", "error", err)
+		m.log.Error("Error loading categories", "error", err)
 		return c.JSON(http.StatusInternalServerError, Resp{
-			Error: "Error retrieving top expense categories",
+			Error: "Error updating transaction",
 		})
 	}
+	input.Category = t.resolve(input.Category)
 
-	// Transform into client-friendly structure
-	categories := make([]CategorySummary, len(rawCategories))
-	for i, cat := range rawCategories {
-		totalSpent := 0.0
-		if cat.TotalSpent.Valid {
-			totalSpent = cat.TotalSpent.Float64
-		}
-		categories[i] = CategorySummary{
-			Category:   cat.Category,
-			TotalSpent: totalSpent,
-		}
+	// Only transfers have a destination account, and it can't be the source account.
+	if input.Type != models.TypeTransfer {
+		input.TransferAccountID = nil
 	}
-
-	return c.JSON(http.StatusOK, Resp{
-		Data:    categories,
-		Message: "Top expense categories retrieved",
-	})
-}
-
-func handleDailySpending(c echo.Context) error {
-	m := c.Get("app").(*App)
-	startDateStr := c.QueryParam("start_date")
-	endDateStr := c.QueryParam("end_date")
-
-	if startDateStr == "" || endDateStr == "" {
+	if input.TransferAccountID != nil && input.AccountID != nil && *input.TransferAccountID == *input.AccountID {
 		return c.JSON(http.StatusBadRequest, Resp{
-			Error: "Missing required parameters: start_date, end_date",
+			Error: "transfer_account_id must be different from account_id",
 		})
 	}
 
-	startDate, err := time.Parse("2006-01-02", startDateStr)
+	for _, acc := range []struct {
+		field string
+		id    *int64
+	}{{"account_id", input.AccountID}, {"transfer_account_id", input.TransferAccountID}} {
+		if acc.id == nil {
+			continue
+		}
+		if _, err := m.queries.GetAccount(context.Background(), *acc.id); err != nil {
+			m.log.Error("Error retrieving account", "error", err)
+			return c.JSON(http.StatusBadRequest, Resp{
+				Error: "Invalid " + acc.field,
+			})
+		}
+	}
+
+	// The category before the update, to learn from the user's correction of it.
+	old, err := m.queries.GetTransaction(context.Background(), id)
+	if err != nil {
+		m.log.Error("Error retrieving transaction", "error", err)
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Transaction not found",
+		})
+	}
+	oldCategory := old.Category
+	if version == nil {
+		version = &old.Version
+	}
+
+	params := db.UpdateTransactionParams{
+		Amount:            amount,
+		Currency:          input.Currency,
+		Category:          input.Category,
+		Description:       input.Description,
+		Confirm:           input.Confirm,
+		TransactionDate:   transactionDate,
+		AccountID:         input.AccountID,
+		Type:              input.Type,
+		TransferAccountID: input.TransferAccountID,
+		ID:                id,
+		Version:           *version,
+	}
+
+	n, err := m.queries.UpdateTransaction(context.Background(), params)
+	if err != nil {
+		m.log.Error("Error updating transaction", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error updating transaction",
+		})
+	}
+	if n == 0 {
+		return c.JSON(http.StatusPreconditionFailed, Resp{
+			Error: errStale.Error(),
+		})
+	}
+
+	// Recording the correction is best effort, the transaction is already updated.
+	if oldCategory != "" && !strings.EqualFold(oldCategory, input.Category) {
+		if err := m.recordCorrection(context.Background(), input.Description, input.Category); err != nil {
+			m.log.Error("Error recording category correction", "error", err)
+		}
+	}
+
+	// The tags are only replaced when they're given.
+	if input.Tags != nil {
+		input.Tags, err = setTags(context.Background(), m.queries, id, input.Tags)
+	} else {
+		input.Tags, err = m.queries.ListTagsByTransaction(context.Background(), id)
+	}
+	if err != nil {
+		m.log.Error("Error updating transaction tags", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error updating transaction",
+		})
+	}
+
+	input.ID = id
+	input.TransactionDate = transactionDate.Format("2006-01-02")
+	input.Version = *version + 1
+	c.Response().Header().Set("ETag", etag(input.Version))
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Transaction updated",
+		Data:    input,
+	})
+}
+
+func handleDeleteTransaction(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid transaction ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid transaction ID",
+		})
+	}
+
+	hashes, err := deleteTransaction(context.Background(), m.queries, id)
+	if err != nil {
+		m.log.Error("Error deleting transaction", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error deleting transaction",
+		})
+	}
+	m.cleanupBlobs(context.Background(), hashes)
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Transaction deleted",
+	})
+}
+
+// handleCreateAttachment attaches a file, uploaded as the file field of a multipart
+// form, to a transaction.
+func handleCreateAttachment(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid transaction ID"})
+	}
+	if _, err := m.queries.GetTransaction(context.Background(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, Resp{Error: "Transaction not found"})
+		}
+		m.log.Error("Error retrieving transaction", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error saving attachment"})
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid form, upload the file as file"})
+	}
+	att, err := m.attach(context.Background(), id, fh)
+	if err != nil {
+		var upErr *uploadError
+		if errors.As(err, &upErr) {
+			return c.JSON(http.StatusBadRequest, Resp{Error: err.Error()})
+		}
+		m.log.Error("Error saving attachment", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error saving attachment"})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    att,
+		Message: "Attachment saved",
+	})
+}
+
+func handleListAttachments(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid transaction ID"})
+	}
+
+	attachments, err := m.listAttachments(context.Background(), id)
+	if err != nil {
+		m.log.Error("Error listing attachments", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error listing attachments"})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    attachments,
+		Message: "Attachments retrieved",
+	})
+}
+
+// handleGetAttachment serves the content of an attachment, to be shown inline in the browser.
+func handleGetAttachment(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid transaction ID"})
+	}
+	attID, err := strconv.ParseInt(c.Param("attachment_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid attachment ID"})
+	}
+
+	att, err := m.queries.GetAttachment(context.Background(), db.GetAttachmentParams{
+		ID:            attID,
+		TransactionID: id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, Resp{Error: "Attachment not found"})
+	}
+	if err != nil {
+		m.log.Error("Error retrieving attachment", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving attachment"})
+	}
+
+	f, err := m.blobs.open(att.Sha256)
+	if err != nil {
+		m.log.Error("Error opening attachment", "sha256", att.Sha256, "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving attachment"})
+	}
+	defer f.Close()
+
+	h := c.Response().Header()
+	h.Set(echo.HeaderContentType, att.MimeType)
+	h.Set(echo.HeaderContentDisposition, mime.FormatMediaType("inline", map[string]string{"filename": att.Filename}))
+	h.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	// The content of an attachment never changes, as it's stored by its hash.
+	h.Set("ETag", `"`+att.Sha256+`"`)
+	http.ServeContent(c.Response(), c.Request(), att.Filename, att.CreatedAt, f)
+	return nil
+}
+
+func handleDeleteAttachment(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid transaction ID"})
+	}
+	attID, err := strconv.ParseInt(c.Param("attachment_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid attachment ID"})
+	}
+
+	if err := m.detach(context.Background(), id, attID); err != nil {
+		if errors.Is(err, errAttachmentNotFound) {
+			return c.JSON(http.StatusNotFound, Resp{Error: "Attachment not found"})
+		}
+		m.log.Error("Error deleting attachment", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error deleting attachment"})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Attachment deleted",
+	})
+}
+
+// handleBulkTransactions applies an action to many transactions at once, picked by
+// their IDs or with a filter, and reports the outcome for every transaction.
+func handleBulkTransactions(c echo.Context) error {
+	m := c.Get("app").(*App)
+	var input models.BulkRequest
+	if err := c.Bind(&input); err != nil {
+		m.log.Error("Error binding input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
+
+	if err := m.validateBulk(context.Background(), &input); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	results, err := m.applyBulk(context.Background(), input)
+	if err != nil {
+		m.log.Error("Error applying bulk action", "action", input.Action, "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error applying bulk action",
+		})
+	}
+
+	var ok int
+	for _, r := range results {
+		if r.OK {
+			ok++
+		}
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: fmt.Sprintf("Applied %s to %d of %d transactions", input.Action, ok, len(results)),
+		Data:    results,
+	})
+}
+
+// handleSearchTransactions returns the transactions which match the full text search `q`,
+// the most relevant first.
+func handleSearchTransactions(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	q := strings.TrimSpace(c.QueryParam("q"))
+	if q == "" {
+		return c.JSON(http.StatusBadRequest, Resp{Error: "q is required"})
+	}
+
+	f := models.TransactionFilter{
+		StartDate: c.QueryParam("start_date"),
+		EndDate:   c.QueryParam("end_date"),
+	}
+	if confirmStr := c.QueryParam("confirm"); confirmStr != "" {
+		confirm, err := strconv.ParseBool(confirmStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid confirm value"})
+		}
+		f.Confirm = &confirm
+	}
+	filter, err := m.filterParams(context.Background(), f)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{Error: err.Error()})
+	}
+
+	limit := defaultSearchLimit
+	if s := c.QueryParam("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 || limit > maxPageLimit {
+			return c.JSON(http.StatusBadRequest, Resp{Error: fmt.Sprintf("Invalid limit, use a number from 1 to %d", maxPageLimit)})
+		}
+	}
+
+	// The transactions match all the words, unless match=any.
+	var anyWord bool
+	switch c.QueryParam("match") {
+	case "", "all":
+	case "any":
+		anyWord = true
+	default:
+		return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid match, use one of all, any"})
+	}
+
+	results, err := m.searchTransactions(context.Background(), q, anyWord, db.SearchTransactionsParams{
+		Confirm:   filter.Confirm,
+		StartDate: filter.StartDate,
+		EndDate:   filter.EndDate,
+		Limit:     int64(limit),
+	})
+	if err != nil {
+		m.log.Error("Error searching transactions", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error searching transactions"})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    results,
+		Message: "Transactions retrieved",
+	})
+}
+
+// handleListDuplicates lists the transactions which look like duplicates, along with
+// their originals, for review.
+func handleListDuplicates(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	transactions, err := m.queries.ListDuplicateTransactions(context.Background())
+	if err != nil {
+		m.log.Error("Error retrieving duplicates", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving duplicates"})
+	}
+
+	out := []models.Duplicate{}
+	for _, t := range transactions {
+		original, err := m.queries.GetTransaction(context.Background(), *t.DuplicateOf)
+		if err != nil {
+			m.log.Error("Error retrieving transaction", "error", err)
+			return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving duplicates"})
+		}
+
+		items := []models.Item{toItem(t), toItem(original)}
+		if err := m.withTags(context.Background(), items); err != nil {
+			m.log.Error("Error retrieving transaction tags", "error", err)
+			return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving duplicates"})
+		}
+		out = append(out, models.Duplicate{Transaction: items[0], Original: items[1]})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    out,
+		Message: "Duplicates retrieved",
+	})
+}
+
+// handleMergeDuplicate merges a duplicate into its original, deleting the duplicate.
+func handleMergeDuplicate(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid transaction ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid transaction ID",
+		})
+	}
+
+	original, err := m.mergeDuplicate(context.Background(), id)
+	if errors.Is(err, errNotDuplicate) {
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Duplicate not found",
+		})
+	}
+	if err != nil {
+		m.log.Error("Error merging duplicate", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error merging duplicate",
+		})
+	}
+
+	items := []models.Item{toItem(original)}
+	if err := m.withTags(context.Background(), items); err != nil {
+		m.log.Error("Error retrieving transaction tags", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error merging duplicate",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Duplicate merged",
+		Data:    items[0],
+	})
+}
+
+// handleDismissDuplicate clears the duplicate flag of a transaction which isn't one.
+func handleDismissDuplicate(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid transaction ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid transaction ID",
+		})
+	}
+
+	transaction, err := m.queries.GetTransaction(context.Background(), id)
+	if err != nil || transaction.DuplicateOf == nil {
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Duplicate not found",
+		})
+	}
+
+	if err := m.queries.SetTransactionDuplicate(context.Background(), db.SetTransactionDuplicateParams{
+		DuplicateOf: nil,
+		ID:          id,
+	}); err != nil {
+		m.log.Error("Error dismissing duplicate", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error dismissing duplicate",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Duplicate dismissed",
+	})
+}
+
+func handleGetEntry(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid entry ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid entry ID",
+		})
+	}
+
+	entry, err := m.queries.GetEntry(context.Background(), id)
+	if err != nil {
+		m.log.Error("Error retrieving entry", "error", err)
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Entry not found",
+		})
+	}
+
+	transactions, err := m.queries.ListTransactionsByEntry(context.Background(), &id)
+	if err != nil {
+		m.log.Error("Error retrieving entry transactions", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving entry",
+		})
+	}
+
+	items := toItems(transactions)
+	if err := m.withTags(context.Background(), items); err != nil {
+		m.log.Error("Error retrieving entry transaction tags", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving entry",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    EntryDetail{Entry: entry, Transactions: items},
+		Message: "Entry retrieved",
+	})
+}
+
+func handleCreateAccount(c echo.Context) error {
+	m := c.Get("app").(*App)
+	var input models.Account
+	if err := c.Bind(&input); err != nil {
+		m.log.Error("Error binding input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
+
+	if err := m.validateAccount(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	balance, err := input.OpeningBalance.Minor(input.Currency)
+	if err != nil {
+		m.log.Error("Error converting opening balance", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid opening_balance",
+		})
+	}
+
+	account, err := m.queries.CreateAccount(context.Background(), db.CreateAccountParams{
+		CreatedAt:      time.Now(),
+		Name:           input.Name,
+		Kind:           input.Kind,
+		Currency:       input.Currency,
+		OpeningBalance: balance,
+	})
+	if isUniqueErr(err) {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "An account with this name already exists",
+		})
+	}
+	if err != nil {
+		m.log.Error("Error creating account", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error creating account",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Account created",
+		Data:    toAccount(account),
+	})
+}
+
+func handleListAccounts(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	accounts, err := m.queries.ListAccounts(context.Background())
+	if err != nil {
+		m.log.Error("Error retrieving accounts", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving accounts"})
+	}
+
+	out := make([]models.Account, len(accounts))
+	for i, acc := range accounts {
+		out[i] = toAccount(acc)
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    out,
+		Message: "Accounts retrieved",
+	})
+}
+
+func handleGetAccount(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid account ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid account ID",
+		})
+	}
+
+	account, err := m.queries.GetAccount(context.Background(), id)
+	if err != nil {
+		m.log.Error("Error retrieving account", "error", err)
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Account not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    toAccount(account),
+		Message: "Account retrieved",
+	})
+}
+
+func handleUpdateAccount(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid account ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid account ID",
+		})
+	}
+
+	var input models.Account
+	if err := c.Bind(&input); err != nil {
+		m.log.Error("Error binding input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
+
+	if err := m.validateAccount(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	balance, err := input.OpeningBalance.Minor(input.Currency)
+	if err != nil {
+		m.log.Error("Error converting opening balance", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid opening_balance",
+		})
+	}
+
+	account, err := m.queries.UpdateAccount(context.Background(), db.UpdateAccountParams{
+		Name:           input.Name,
+		Kind:           input.Kind,
+		Currency:       input.Currency,
+		OpeningBalance: balance,
+		ID:             id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Account not found",
+		})
+	}
+	if isUniqueErr(err) {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "An account with this name already exists",
+		})
+	}
+	if err != nil {
+		m.log.Error("Error updating account", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error updating account",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Account updated",
+		Data:    toAccount(account),
+	})
+}
+
+func handleDeleteAccount(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid account ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid account ID",
+		})
+	}
+
+	// Rules which use the account would match or file transactions differently without it.
+	n, err := m.queries.CountRulesByAccount(context.Background(), &id)
+	if err != nil {
+		m.log.Error("Error counting rules", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error deleting account",
+		})
+	}
+	if n > 0 {
+		return c.JSON(http.StatusConflict, Resp{
+			Error: "Account is used by rules, update or delete them first",
+		})
+	}
+
+	if err := m.queries.DeleteAccount(context.Background(), id); err != nil {
+		m.log.Error("Error deleting account", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error deleting account",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Account deleted",
+	})
+}
+
+func handleCreateBudget(c echo.Context) error {
+	m := c.Get("app").(*App)
+	var input models.Budget
+	if err := c.Bind(&input); err != nil {
+		m.log.Error("Error binding input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
+
+	if err := m.validateBudget(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	amount, err := input.Amount.Minor(input.Currency)
+	if err != nil {
+		m.log.Error("Error converting amount", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid amount",
+		})
+	}
+
+	budget, err := m.queries.CreateBudget(context.Background(), db.CreateBudgetParams{
+		CreatedAt: time.Now(),
+		Category:  input.Category,
+		Period:    input.Period,
+		Amount:    amount,
+		Currency:  input.Currency,
+		Rollover:  input.Rollover,
+	})
+	if isUniqueErr(err) {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "A budget for this category and period already exists",
+		})
+	}
+	if err != nil {
+		m.log.Error("Error creating budget", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error creating budget",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Budget created",
+		Data:    toBudget(budget),
+	})
+}
+
+func handleListBudgets(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	budgets, err := m.queries.ListBudgets(context.Background())
+	if err != nil {
+		m.log.Error("Error retrieving budgets", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving budgets"})
+	}
+
+	out := make([]models.Budget, len(budgets))
+	for i, b := range budgets {
+		out[i] = toBudget(b)
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    out,
+		Message: "Budgets retrieved",
+	})
+}
+
+func handleGetBudget(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid budget ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid budget ID",
+		})
+	}
+
+	budget, err := m.queries.GetBudget(context.Background(), id)
+	if err != nil {
+		m.log.Error("Error retrieving budget", "error", err)
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Budget not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    toBudget(budget),
+		Message: "Budget retrieved",
+	})
+}
+
+func handleUpdateBudget(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid budget ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid budget ID",
+		})
+	}
+
+	var input models.Budget
+	if err := c.Bind(&input); err != nil {
+		m.log.Error("Error binding input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
+
+	if err := m.validateBudget(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	amount, err := input.Amount.Minor(input.Currency)
+	if err != nil {
+		m.log.Error("Error converting amount", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid amount",
+		})
+	}
+
+	budget, err := m.queries.UpdateBudget(context.Background(), db.UpdateBudgetParams{
+		Category: input.Category,
+		Period:   input.Period,
+		Amount:   amount,
+		Currency: input.Currency,
+		Rollover: input.Rollover,
+		ID:       id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Budget not found",
+		})
+	}
+	if isUniqueErr(err) {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "A budget for this category and period already exists",
+		})
+	}
+	if err != nil {
+		m.log.Error("Error updating budget", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error updating budget",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Budget updated",
+		Data:    toBudget(budget),
+	})
+}
+
+func handleDeleteBudget(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid budget ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid budget ID",
+		})
+	}
+
+	if err := m.queries.DeleteBudget(context.Background(), id); err != nil {
+		m.log.Error("Error deleting budget", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error deleting budget",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Budget deleted",
+	})
+}
+
+func handleCreateCategory(c echo.Context) error {
+	m := c.Get("app").(*App)
+	var input models.Category
+	if err := c.Bind(&input); err != nil {
+		m.log.Error("Error binding input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
+
+	if err := m.validateCategory(context.Background(), 0, &input); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	category, err := m.saveCategory(context.Background(), 0, input)
+	if isUniqueErr(err) {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "A category or alias with this name already exists",
+		})
+	}
+	if err != nil {
+		m.log.Error("Error creating category", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error creating category",
+		})
+	}
+
+	out := toCategory(category, nil)
+	out.Aliases = append(out.Aliases, input.Aliases...)
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Category created",
+		Data:    out,
+	})
+}
+
+func handleListCategories(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	categories, err := m.queries.ListCategories(context.Background())
+	if err != nil {
+		m.log.Error("Error retrieving categories", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving categories"})
+	}
+
+	aliases, err := m.queries.ListCategoryAliases(context.Background())
+	if err != nil {
+		m.log.Error("Error retrieving category aliases", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving categories"})
+	}
+
+	out := make([]models.Category, len(categories))
+	for i, cat := range categories {
+		out[i] = toCategory(cat, aliases)
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    out,
+		Message: "Categories retrieved",
+	})
+}
+
+func handleGetCategory(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid category ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid category ID",
+		})
+	}
+
+	category, err := m.queries.GetCategory(context.Background(), id)
+	if err != nil {
+		m.log.Error("Error retrieving category", "error", err)
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Category not found",
+		})
+	}
+
+	aliases, err := m.queries.ListCategoryAliases(context.Background())
+	if err != nil {
+		m.log.Error("Error retrieving category aliases", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving category",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    toCategory(category, aliases),
+		Message: "Category retrieved",
+	})
+}
+
+func handleUpdateCategory(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid category ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid category ID",
+		})
+	}
+
+	var input models.Category
+	if err := c.Bind(&input); err != nil {
+		m.log.Error("Error binding input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
+
+	if err := m.validateCategory(context.Background(), id, &input); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	category, err := m.saveCategory(context.Background(), id, input)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Category not found",
+		})
+	}
+	if isUniqueErr(err) {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "A category or alias with this name already exists",
+		})
+	}
+	if err != nil {
+		m.log.Error("Error updating category", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error updating category",
+		})
+	}
+
+	out := toCategory(category, nil)
+	out.Aliases = append(out.Aliases, input.Aliases...)
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Category updated",
+		Data:    out,
+	})
+}
+
+func handleDeleteCategory(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid category ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid category ID",
+		})
+	}
+
+	if err := m.queries.DeleteCategory(context.Background(), id); err != nil {
+		m.log.Error("Error deleting category", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error deleting category",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Category deleted",
+	})
+}
+
+func handleCreateRecurringRule(c echo.Context) error {
+	m := c.Get("app").(*App)
+	var input models.RecurringRule
+	if err := c.Bind(&input); err != nil {
+		m.log.Error("Error binding input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
+
+	rule, start, err := m.validateRecurringRule(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	amount, err := input.Amount.Minor(input.Currency)
+	if err != nil {
+		m.log.Error("Error converting amount", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid amount",
+		})
+	}
+
+	// The first transaction is due on the start date, unless the rule ends before it.
+	var nextDue *time.Time
+	if d, ok := rule.Occurrence(start, 0); ok {
+		nextDue = &d
+	}
+
+	created, err := m.queries.CreateRecurringRule(context.Background(), db.CreateRecurringRuleParams{
+		CreatedAt:   time.Now(),
+		Description: input.Description,
+		Category:    input.Category,
+		Type:        input.Type,
+		Amount:      amount,
+		Currency:    input.Currency,
+		AccountID:   input.AccountID,
+		Rrule:       input.RRule,
+		StartDate:   start,
+		NextDue:     nextDue,
+		Occurrences: 0,
+	})
+	if err != nil {
+		m.log.Error("Error creating recurring rule", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error creating recurring rule",
+		})
+	}
+
+	// Create the transactions which are already due, instead of waiting for the scheduler.
+	// It's best effort, the scheduler picks up the rule if it fails.
+	if _, err := m.createRecurringTransactions(context.Background(), time.Now()); err != nil {
+		m.log.Error("Error creating recurring transactions", "error", err)
+	} else if created, err = m.queries.GetRecurringRule(context.Background(), created.ID); err != nil {
+		m.log.Error("Error retrieving recurring rule", "error", err)
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Recurring rule created",
+		Data:    toRecurringRule(created),
+	})
+}
+
+func handleListRecurringRules(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	rules, err := m.queries.ListRecurringRules(context.Background())
+	if err != nil {
+		m.log.Error("Error retrieving recurring rules", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving recurring rules"})
+	}
+
+	out := make([]models.RecurringRule, len(rules))
+	for i, r := range rules {
+		out[i] = toRecurringRule(r)
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    out,
+		Message: "Recurring rules retrieved",
+	})
+}
+
+func handleGetRecurringRule(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid recurring rule ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid recurring rule ID",
+		})
+	}
+
+	rule, err := m.queries.GetRecurringRule(context.Background(), id)
+	if err != nil {
+		m.log.Error("Error retrieving recurring rule", "error", err)
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Recurring rule not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    toRecurringRule(rule),
+		Message: "Recurring rule retrieved",
+	})
+}
+
+func handleUpdateRecurringRule(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid recurring rule ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid recurring rule ID",
+		})
+	}
+
+	var input models.RecurringRule
+	if err := c.Bind(&input); err != nil {
+		m.log.Error("Error binding input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
+
+	rule, start, err := m.validateRecurringRule(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	amount, err := input.Amount.Minor(input.Currency)
+	if err != nil {
+		m.log.Error("Error converting amount", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid amount",
+		})
+	}
+
+	// Hold off the scheduler so that it doesn't advance the rule while it's updated.
+	m.recurMu.Lock()
+	defer m.recurMu.Unlock()
+
+	existing, err := m.queries.GetRecurringRule(context.Background(), id)
+	if err != nil {
+		m.log.Error("Error retrieving recurring rule", "error", err)
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Recurring rule not found",
+		})
+	}
+
+	// The updated rule applies from today, it doesn't create transactions for the past
+	// again. Occurrences which were due and not created yet are still created.
+	from := dateOf(time.Now())
+	if existing.NextDue != nil && existing.NextDue.Before(from) {
+		from = *existing.NextDue
+	}
+	var (
+		nextDue     *time.Time
+		occurrences int
+	)
+	if d, n, ok := rule.Next(start, from); ok {
+		nextDue, occurrences = &d, n
+	}
+
+	updated, err := m.queries.UpdateRecurringRule(context.Background(), db.UpdateRecurringRuleParams{
+		Description: input.Description,
+		Category:    input.Category,
+		Type:        input.Type,
+		Amount:      amount,
+		Currency:    input.Currency,
+		AccountID:   input.AccountID,
+		Rrule:       input.RRule,
+		StartDate:   start,
+		NextDue:     nextDue,
+		Occurrences: int64(occurrences),
+		ID:          id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Recurring rule not found",
+		})
+	}
+	if err != nil {
+		m.log.Error("Error updating recurring rule", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error updating recurring rule",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Recurring rule updated",
+		Data:    toRecurringRule(updated),
+	})
+}
+
+func handleDeleteRecurringRule(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid recurring rule ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid recurring rule ID",
+		})
+	}
+
+	if err := m.queries.DeleteRecurringRule(context.Background(), id); err != nil {
+		m.log.Error("Error deleting recurring rule", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error deleting recurring rule",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Recurring rule deleted",
+	})
+}
+
+func handleCreateRule(c echo.Context) error {
+	m := c.Get("app").(*App)
+	var input models.Rule
+	if err := c.Bind(&input); err != nil {
+		m.log.Error("Error binding input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
+
+	if err := m.validateRule(context.Background(), &input); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	minAmount, maxAmount, tags, err := m.ruleParams(input)
+	if err != nil {
+		m.log.Error("Error converting amount", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid amount",
+		})
+	}
+
+	created, err := m.queries.CreateRule(context.Background(), db.CreateRuleParams{
+		CreatedAt:          time.Now(),
+		Name:               input.Name,
+		Priority:           input.Priority,
+		DescriptionPattern: input.DescriptionPattern,
+		MinAmount:          minAmount,
+		MaxAmount:          maxAmount,
+		AccountID:          input.AccountID,
+		Currency:           input.Currency,
+		SetCategory:        input.SetCategory,
+		AddTags:            tags,
+		SetAccountID:       input.SetAccountID,
+		Confirm:            input.Confirm,
+	})
+	if err != nil {
+		m.log.Error("Error creating rule", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error creating rule",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Rule created",
+		Data:    m.toRule(created),
+	})
+}
+
+func handleListRules(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	rules, err := m.queries.ListRules(context.Background())
+	if err != nil {
+		m.log.Error("Error retrieving rules", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving rules"})
+	}
+
+	out := make([]models.Rule, len(rules))
+	for i, r := range rules {
+		out[i] = m.toRule(r)
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    out,
+		Message: "Rules retrieved",
+	})
+}
+
+func handleGetRule(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid rule ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid rule ID",
+		})
+	}
+
+	rule, err := m.queries.GetRule(context.Background(), id)
+	if err != nil {
+		m.log.Error("Error retrieving rule", "error", err)
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Rule not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    m.toRule(rule),
+		Message: "Rule retrieved",
+	})
+}
+
+func handleUpdateRule(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid rule ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid rule ID",
+		})
+	}
+
+	var input models.Rule
+	if err := c.Bind(&input); err != nil {
+		m.log.Error("Error binding input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
+
+	if err := m.validateRule(context.Background(), &input); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	minAmount, maxAmount, tags, err := m.ruleParams(input)
+	if err != nil {
+		m.log.Error("Error converting amount", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid amount",
+		})
+	}
+
+	updated, err := m.queries.UpdateRule(context.Background(), db.UpdateRuleParams{
+		Name:               input.Name,
+		Priority:           input.Priority,
+		DescriptionPattern: input.DescriptionPattern,
+		MinAmount:          minAmount,
+		MaxAmount:          maxAmount,
+		AccountID:          input.AccountID,
+		Currency:           input.Currency,
+		SetCategory:        input.SetCategory,
+		AddTags:            tags,
+		SetAccountID:       input.SetAccountID,
+		Confirm:            input.Confirm,
+		ID:                 id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Rule not found",
+		})
+	}
+	if err != nil {
+		m.log.Error("Error updating rule", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error updating rule",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Rule updated",
+		Data:    m.toRule(updated),
+	})
+}
+
+func handleDeleteRule(c echo.Context) error {
+	m := c.Get("app").(*App)
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		m.log.Error("Invalid rule ID", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid rule ID",
+		})
+	}
+
+	if err := m.queries.DeleteRule(context.Background(), id); err != nil {
+		m.log.Error("Error deleting rule", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error deleting rule",
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Message: "Rule deleted",
+	})
+}
+
+// handleDryRunRule shows the existing transactions which the rule in the request
+// would change, as they are and as they would be. Nothing is saved.
+func handleDryRunRule(c echo.Context) error {
+	m := c.Get("app").(*App)
+	var input models.Rule
+	if err := c.Bind(&input); err != nil {
+		m.log.Error("Error binding input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
+
+	if err := m.validateRule(context.Background(), &input); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	rule, err := compileRule(input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	transactions, err := m.queries.ListTransactions(context.Background(), db.ListTransactionsParams{})
+	if err != nil {
+		m.log.Error("Error retrieving transactions", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving transactions"})
+	}
+
+	tags, err := m.queries.ListTransactionTags(context.Background(), nil)
+	if err != nil {
+		m.log.Error("Error retrieving transaction tags", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving transactions"})
+	}
+
+	items := toItems(transactions)
+	attachTags(items, tags)
+
+	changes := []models.RuleChange{}
+	for _, before := range items {
+		after := before
+		after.Tags = slices.Clone(before.Tags)
+		if rule.apply(&after) {
+			changes = append(changes, models.RuleChange{Before: before, After: after})
+		}
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    changes,
+		Message: fmt.Sprintf("Rule would change %d transactions", len(changes)),
+	})
+}
+
+func handleAsk(c echo.Context) error {
+	m := c.Get("app").(*App)
+	var input AskInput
+	if err := c.Bind(&input); err != nil {
+		m.log.Error("Error binding input", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid input",
+		})
+	}
+
+	input.Question = strings.TrimSpace(input.Question)
+	if input.Question == "" {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "question is required",
+		})
+	}
+
+	base, err := m.reportCurrency(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	answer, err := m.ask(c.Request().Context(), input.Question, base)
+	if err != nil {
+		var noPlanErr *llm.NoPlanError
+		if errors.As(err, &noPlanErr) || errors.Is(err, errInvalidPlan) {
+			m.log.Error("Error planning question", "question", input.Question, "error", err)
+			return c.JSON(http.StatusBadRequest, Resp{
+				Error: err.Error(),
+			})
+		}
+		return reportError(c, m, err, "Error answering the question")
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    answer,
+		Message: "Question answered",
+	})
+}
+
+func handleListTags(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	tags, err := m.queries.ListTags(context.Background())
+	if err != nil {
+		m.log.Error("Error retrieving tags", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{Error: "Error retrieving tags"})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    tags,
+		Message: "Tags retrieved",
+	})
+}
+
+func handleTopExpenseCategories(c echo.Context) error {
+	m := c.Get("app").(*App)
+	startDateStr := c.QueryParam("start_date")
+	endDateStr := c.QueryParam("end_date")
+
+	if startDateStr == "" || endDateStr == "" {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Missing required parameters: start_date, end_date",
+		})
+	}
+
+	// Parse start date and end date strings into time.Time
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		m.log.Error("Invalid start date", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid start date format, use YYYY-MM-DD",
+		})
+	}
+
+	endDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		m.log.Error("Invalid end date", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid end date format, use YYYY-MM-DD",
+		})
+	}
+
+	// Validate the date range
+	if err := validateDateRange(startDate, endDate); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	base, err := m.reportCurrency(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	accountID, err := accountIDParam(c.QueryParam("account_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	var rollup bool
+	if rollupStr := c.QueryParam("rollup"); rollupStr != "" {
+		if rollup, err = strconv.ParseBool(rollupStr); err != nil {
+			return c.JSON(http.StatusBadRequest, Resp{Error: "Invalid rollup value"})
+		}
+	}
+
+	params := db.TopExpenseCategoriesParams{
+		StartDate: startDate,
+		EndDate:   endDate,
+		AccountID: accountID,
+	}
+
+	conv, err := m.converter(c.Request().Context())
+	if err != nil {
+		m.log.Error("Error loading exchange rates", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving top expense categories",
+		})
+	}
+
+	categories, err := m.categoryTotals(c.Request().Context(), conv, params, base)
+	if err != nil {
+		return reportError(c, m, err, "Error retrieving top expense categories")
+	}
+
+	// Subcategories are counted under their top level category.
+	if rollup {
+		t, err := m.loadTaxonomy(c.Request().Context())
+		if err != nil {
+			return reportError(c, m, err, "Error retrieving top expense categories")
+		}
+		if categories, err = t.rollup(categories); err != nil {
+			return reportError(c, m, err, "Error retrieving top expense categories")
+		}
+	}
+
+	if err := sortDesc(categories, func(a, b CategorySummary) (int, error) {
+		return a.TotalSpent.Cmp(b.TotalSpent)
+	}); err != nil {
+		return reportError(c, m, err, "Error retrieving top expense categories")
+	}
+	if len(categories) > topCategoriesLimit {
+		categories = categories[:topCategoriesLimit]
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    categories,
+		Message: "Top expense categories retrieved",
+	})
+}
+
+func handleDailySpending(c echo.Context) error {
+	m := c.Get("app").(*App)
+	startDateStr := c.QueryParam("start_date")
+	endDateStr := c.QueryParam("end_date")
+
+	if startDateStr == "" || endDateStr == "" {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Missing required parameters: start_date, end_date",
+		})
+	}
+
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		m.log.Error("Invalid start date", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid start date format, use YYYY-MM-DD",
+		})
+	}
+
+	endDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		m.log.Error("Invalid end date", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid end date format, use YYYY-MM-DD",
+		})
+	}
+
+	// Validate the date range
+	if err := validateDateRange(startDate, endDate); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	base, err := m.reportCurrency(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	accountID, err := accountIDParam(c.QueryParam("account_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	params := db.DailySpendingParams{
+		StartDate: startDate,
+		EndDate:   endDate,
+		AccountID: accountID,
+	}
+
+	rawSpending, err := m.queries.DailySpending(context.Background(), params)
+	if err != nil {
+		m.log.Error("Error retrieving daily spending", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving daily spending",
+		})
+	}
+
+	conv, err := m.converter(c.Request().Context())
+	if err != nil {
+		m.log.Error("Error loading exchange rates", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving daily spending",
+		})
+	}
+
+	// Transform into client-friendly structure. Rows are ordered by date, with one row
+	// for each currency spent in that day which is converted to the base currency.
+	spendingSummaries := []DailySpendingSummary{}
+	for _, daily := range rawSpending {
+		minor, err := conv.Convert(c.Request().Context(), daily.TotalSpent, m.currencyOf(daily.Currency), base, daily.TransactionDate)
+		if err != nil {
+			return conversionError(c, m, err)
+		}
+
+		date := daily.TransactionDate.Format("2006-01-02")
+		total := models.FromMinor(minor, base)
+		if n := len(spendingSummaries); n > 0 && spendingSummaries[n-1].TransactionDate == date {
+			if spendingSummaries[n-1].TotalSpent, err = spendingSummaries[n-1].TotalSpent.Add(total); err != nil {
+				return reportError(c, m, err, "Error retrieving daily spending")
+			}
+			continue
+		}
+		spendingSummaries = append(spendingSummaries, DailySpendingSummary{
+			TransactionDate: date,
+			TotalSpent:      total,
+			Currency:        base,
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    spendingSummaries,
+		Message: "Daily spending totals retrieved successfully",
+	})
+}
+
+func handleAccountBalances(c echo.Context) error {
+	m := c.Get("app").(*App)
+	startDateStr := c.QueryParam("start_date")
+	endDateStr := c.QueryParam("end_date")
+
+	if startDateStr == "" || endDateStr == "" || c.QueryParam("account_id") == "" {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Missing required parameters: account_id, start_date, end_date",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.QueryParam("account_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid account_id",
+		})
+	}
+
+	startDate, err := time.Parse("2006-01-02", startDateStr)
 	if err != nil {
 		m.log.Error("Invalid start date", "error", err)
 		return c.JSON(http.StatusBadRequest, Resp{
@@ -350,35 +2465,418 @@ func handleDailySpending(c echo.Context) error {
 		})
 	}
 
-	params := db.DailySpendingParams{
+	account, err := m.queries.GetAccount(context.Background(), id)
+	if err != nil {
+		m.log.Error("Error retrieving account", "error", err)
+		return c.JSON(http.StatusNotFound, Resp{
+			Error: "Account not found",
+		})
+	}
+
+	rawTotals, err := m.queries.AccountDailyTotals(context.Background(), db.AccountDailyTotalsParams{
+		AccountID: &id,
+		EndDate:   endDate,
+	})
+	if err != nil {
+		m.log.Error("Error retrieving account totals", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving account balances",
+		})
+	}
+
+	conv, err := m.converter(c.Request().Context())
+	if err != nil {
+		m.log.Error("Error loading exchange rates", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving account balances",
+		})
+	}
+
+	// Balances are in the account's currency and run from the opening balance, so the
+	// days before the start date are added up but only the days in the range are returned.
+	balance := account.OpeningBalance
+	balances := []AccountBalance{}
+	for _, daily := range rawTotals {
+		inflow, err := conv.Convert(c.Request().Context(), daily.Inflow, m.currencyOf(daily.Currency), account.Currency, daily.TransactionDate)
+		if err != nil {
+			return conversionError(c, m, err)
+		}
+		outflow, err := conv.Convert(c.Request().Context(), daily.Outflow, m.currencyOf(daily.Currency), account.Currency, daily.TransactionDate)
+		if err != nil {
+			return conversionError(c, m, err)
+		}
+		balance += inflow - outflow
+
+		if daily.TransactionDate.Before(startDate) {
+			continue
+		}
+
+		date := daily.TransactionDate.Format("2006-01-02")
+		if n := len(balances); n > 0 && balances[n-1].TransactionDate == date {
+			if balances[n-1].Received, err = balances[n-1].Received.Add(models.FromMinor(inflow, account.Currency)); err != nil {
+				return reportError(c, m, err, "Error retrieving account balances")
+			}
+			if balances[n-1].Spent, err = balances[n-1].Spent.Add(models.FromMinor(outflow, account.Currency)); err != nil {
+				return reportError(c, m, err, "Error retrieving account balances")
+			}
+			balances[n-1].Balance = models.FromMinor(balance, account.Currency)
+			continue
+		}
+		balances = append(balances, AccountBalance{
+			TransactionDate: date,
+			Received:        models.FromMinor(inflow, account.Currency),
+			Spent:           models.FromMinor(outflow, account.Currency),
+			Balance:         models.FromMinor(balance, account.Currency),
+			Currency:        account.Currency,
+		})
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    balances,
+		Message: "Account balances retrieved",
+	})
+}
+
+func handleCashFlow(c echo.Context) error {
+	m := c.Get("app").(*App)
+	startDateStr := c.QueryParam("start_date")
+	endDateStr := c.QueryParam("end_date")
+
+	if startDateStr == "" || endDateStr == "" {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Missing required parameters: start_date, end_date",
+		})
+	}
+
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		m.log.Error("Invalid start date", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid start date format, use YYYY-MM-DD",
+		})
+	}
+
+	endDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		m.log.Error("Invalid end date", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid end date format, use YYYY-MM-DD",
+		})
+	}
+
+	// Validate the date range
+	if err := validateDateRange(startDate, endDate); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	base, err := m.reportCurrency(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	accountID, err := accountIDParam(c.QueryParam("account_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	rawFlows, err := m.queries.CashFlow(context.Background(), db.CashFlowParams{
 		StartDate: startDate,
 		EndDate:   endDate,
+		AccountID: accountID,
+	})
+	if err != nil {
+		m.log.Error("Error retrieving cash flow", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving cash flow",
+		})
 	}
 
-	rawSpending, err := m.queries.DailySpending(context.Background(), params)
+	conv, err := m.converter(c.Request().Context())
 	if err != nil {
-		m.log.Error("Error retrieving daily spending", "error", err)
+		m.log.Error("Error loading exchange rates", "error", err)
 		return c.JSON(http.StatusInternalServerError, Resp{
-			Error: "Error retrieving daily spending",
+			Error: "Error retrieving cash flow",
 		})
 	}
 
-	// Transform into client-friendly structure
-	spendingSummaries := make([]DailySpendingSummary, len(rawSpending))
-	for i, daily := range rawSpending {
-		totalSpent := 0.0
-		if daily.TotalSpent.Valid {
-			totalSpent = daily.TotalSpent.Float64
+	var income, expenses int64
+	for _, flow := range rawFlows {
+		minor, err := conv.Convert(c.Request().Context(), flow.Total, m.currencyOf(flow.Currency), base, flow.TransactionDate)
+		if err != nil {
+			return conversionError(c, m, err)
 		}
-		spendingSummaries[i] = DailySpendingSummary{
-			TransactionDate: daily.TransactionDate.Format("2006-01-02"),
-			TotalSpent:      totalSpent,
+		if flow.Type == models.TypeIncome {
+			income += minor
+		} else {
+			expenses += minor
 		}
 	}
 
+	summary := CashFlowSummary{
+		Income:   models.FromMinor(income, base),
+		Expenses: models.FromMinor(expenses, base),
+		Net:      models.FromMinor(income-expenses, base),
+		Currency: base,
+	}
+	if income > 0 {
+		rate := math.Round(float64(income-expenses)/float64(income)*10000) / 100
+		summary.SavingsRate = &rate
+	}
+
 	return c.JSON(http.StatusOK, Resp{
-		Data:    spendingSummaries,
-		Message: "Daily spending totals retrieved successfully",
+		Data:    summary,
+		Message: "Cash flow retrieved",
+	})
+}
+
+// categoryTotals adds up the expenses of every category in the period, converted to the
+// base currency. Totals are per currency and day, and are converted using the rate of
+// that day before being added up per category. Categories are in the order of the rows.
+func (m *App) categoryTotals(ctx context.Context, conv *fx.Converter, params db.TopExpenseCategoriesParams, base string) ([]CategorySummary, error) {
+	rawCategories, err := m.queries.TopExpenseCategories(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving category totals: %w", err)
+	}
+
+	categories := []CategorySummary{}
+	idx := map[string]int{}
+	for _, cat := range rawCategories {
+		minor, err := conv.Convert(ctx, cat.TotalSpent, m.currencyOf(cat.Currency), base, cat.TransactionDate)
+		if err != nil {
+			return nil, err
+		}
+
+		total := models.FromMinor(minor, base)
+		if i, ok := idx[cat.Category]; ok {
+			if categories[i].TotalSpent, err = categories[i].TotalSpent.Add(total); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		idx[cat.Category] = len(categories)
+		categories = append(categories, CategorySummary{
+			Category:   cat.Category,
+			TotalSpent: total,
+			Currency:   base,
+		})
+	}
+
+	return categories, nil
+}
+
+func handleBudgetStatus(c echo.Context) error {
+	m := c.Get("app").(*App)
+
+	// The status is of the periods containing the date, today by default.
+	date := time.Now()
+	if dateStr := c.QueryParam("date"); dateStr != "" {
+		var err error
+		if date, err = time.Parse("2006-01-02", dateStr); err != nil {
+			return c.JSON(http.StatusBadRequest, Resp{
+				Error: "Invalid date format, use YYYY-MM-DD",
+			})
+		}
+	}
+
+	budgets, err := m.queries.ListBudgets(context.Background())
+	if err != nil {
+		m.log.Error("Error retrieving budgets", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving budget status",
+		})
+	}
+
+	t, err := m.newBudgetTracker(c.Request().Context())
+	if err != nil {
+		m.log.Error("Error loading exchange rates", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving budget status",
+		})
+	}
+
+	statuses := make([]BudgetStatus, 0, len(budgets))
+	for _, b := range budgets {
+		status, err := t.status(c.Request().Context(), b, date)
+		if err != nil {
+			return reportError(c, m, err, "Error retrieving budget status")
+		}
+		statuses = append(statuses, status)
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    statuses,
+		Message: "Budget status retrieved",
+	})
+}
+
+func handleTagTotals(c echo.Context) error {
+	m := c.Get("app").(*App)
+	startDateStr := c.QueryParam("start_date")
+	endDateStr := c.QueryParam("end_date")
+
+	if startDateStr == "" || endDateStr == "" {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Missing required parameters: start_date, end_date",
+		})
+	}
+
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		m.log.Error("Invalid start date", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid start date format, use YYYY-MM-DD",
+		})
+	}
+
+	endDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		m.log.Error("Invalid end date", "error", err)
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: "Invalid end date format, use YYYY-MM-DD",
+		})
+	}
+
+	if err := validateDateRange(startDate, endDate); err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	base, err := m.reportCurrency(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	accountID, err := accountIDParam(c.QueryParam("account_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: err.Error(),
+		})
+	}
+
+	rawTags, err := m.queries.TagTotals(context.Background(), db.TagTotalsParams{
+		StartDate: startDate,
+		EndDate:   endDate,
+		AccountID: accountID,
+	})
+	if err != nil {
+		m.log.Error("Error retrieving tag totals", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving tag totals",
+		})
+	}
+
+	conv, err := m.converter(c.Request().Context())
+	if err != nil {
+		m.log.Error("Error loading exchange rates", "error", err)
+		return c.JSON(http.StatusInternalServerError, Resp{
+			Error: "Error retrieving tag totals",
+		})
+	}
+
+	// An expense is counted under each of its tags, so the totals can add up to
+	// more than the amount spent.
+	tags := []TagSummary{}
+	idx := map[string]int{}
+	for _, t := range rawTags {
+		minor, err := conv.Convert(c.Request().Context(), t.TotalSpent, m.currencyOf(t.Currency), base, t.TransactionDate)
+		if err != nil {
+			return conversionError(c, m, err)
+		}
+
+		total := models.FromMinor(minor, base)
+		if i, ok := idx[t.Tag]; ok {
+			if tags[i].TotalSpent, err = tags[i].TotalSpent.Add(total); err != nil {
+				return reportError(c, m, err, "Error retrieving tag totals")
+			}
+			tags[i].Transactions += t.Transactions
+			continue
+		}
+		idx[t.Tag] = len(tags)
+		tags = append(tags, TagSummary{
+			Tag:          t.Tag,
+			Transactions: t.Transactions,
+			TotalSpent:   total,
+			Currency:     base,
+		})
+	}
+
+	if err := sortDesc(tags, func(a, b TagSummary) (int, error) {
+		return a.TotalSpent.Cmp(b.TotalSpent)
+	}); err != nil {
+		return reportError(c, m, err, "Error retrieving tag totals")
+	}
+
+	return c.JSON(http.StatusOK, Resp{
+		Data:    tags,
+		Message: "Tag totals retrieved",
+	})
+}
+
+// reportCurrency returns the currency a report is converted to. It's set with
+// the `base_currency` query param and defaults to the configured currency.
+func (m *App) reportCurrency(c echo.Context) (string, error) {
+	base := c.QueryParam("base_currency")
+	if base == "" {
+		return m.currency, nil
+	}
+
+	base = strings.ToUpper(base)
+	if !fx.IsCurrency(base) {
+		return "", errors.New("invalid base_currency, use an ISO 4217 code like USD")
+	}
+	return base, nil
+}
+
+// conversionError responds to an error converting report totals. A missing
+// rate is the client's to fix by importing rates or picking another base currency.
+func conversionError(c echo.Context, m *App, err error) error {
+	var noRateErr *fx.NoRateError
+	if errors.As(err, &noRateErr) {
+		return c.JSON(http.StatusBadRequest, Resp{
+			Error: noRateErr.Error(),
+		})
+	}
+
+	m.log.Error("Error converting currency", "error", err)
+	return c.JSON(http.StatusInternalServerError, Resp{
+		Error: "Error converting currency",
+	})
+}
+
+// sortDesc sorts the items stably by cmp, the largest first. It fails if any two of them
+// can't be compared, like amounts which don't fit the same number of decimal places.
+func sortDesc[T any](items []T, cmp func(a, b T) (int, error)) error {
+	var err error
+	sort.SliceStable(items, func(i, j int) bool {
+		c, cerr := cmp(items[i], items[j])
+		if cerr != nil && err == nil {
+			err = cerr
+		}
+		return c > 0
+	})
+	return err
+}
+
+// reportError responds to an error building a report. Missing exchange rates are
+// reported to the client, everything else is logged.
+func reportError(c echo.Context, m *App, err error, msg string) error {
+	var noRateErr *fx.NoRateError
+	if errors.As(err, &noRateErr) {
+		return conversionError(c, m, err)
+	}
+
+	m.log.Error(msg, "error", err)
+	return c.JSON(http.StatusInternalServerError, Resp{
+		Error: msg,
 	})
 }
 
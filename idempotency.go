@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mr-karan/gullak/internal/db"
+)
+
+const (
+	// idempotencyKeyTTL is how long the response of a request is kept for its retries.
+	idempotencyKeyTTL = 24 * time.Hour
+
+	// idempotencyLockTimeout is how long a request holds its key without saving anything,
+	// after which it's assumed to have failed and a retry can take over. It's longer than
+	// any request should take, including parsing.
+	idempotencyLockTimeout = 5 * time.Minute
+
+	// maxIdempotencyKeyLen is the longest idempotency key which is accepted.
+	maxIdempotencyKeyLen = 255
+)
+
+var (
+	// errIdempotencyKeyReused is returned when a key is sent with another request.
+	errIdempotencyKeyReused = errors.New("Idempotency-Key was already used for another request")
+
+	// errIdempotencyKeyInProgress is returned when the request of a key isn't done yet.
+	errIdempotencyKeyInProgress = errors.New("a request with this Idempotency-Key is in progress, retry later")
+)
+
+// claimIdempotencyKey claims the idempotency key for the request to save the line. It
+// returns the response of the original request when the key was claimed before, in
+// which case the request mustn't be processed again.
+func (a *App) claimIdempotencyKey(ctx context.Context, key, line string) ([]byte, error) {
+	now := time.Now()
+	if err := a.queries.DeleteExpiredIdempotencyKeys(ctx, now.Add(-idempotencyKeyTTL)); err != nil {
+		return nil, fmt.Errorf("error deleting expired idempotency keys: %w", err)
+	}
+
+	// A request which holds the key for too long without saving anything has failed.
+	if err := a.queries.ReleaseIdempotencyKey(ctx, db.ReleaseIdempotencyKeyParams{
+		Key:    key,
+		Before: now.Add(-idempotencyLockTimeout),
+	}); err != nil {
+		return nil, fmt.Errorf("error releasing idempotency key: %w", err)
+	}
+
+	hash := requestHash(line)
+	n, err := a.queries.ClaimIdempotencyKey(ctx, db.ClaimIdempotencyKeyParams{
+		Key:         key,
+		CreatedAt:   now,
+		RequestHash: hash,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error saving idempotency key: %w", err)
+	}
+	if n == 1 {
+		return nil, nil
+	}
+
+	k, err := a.queries.GetIdempotencyKey(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The key was released in between, which only happens to a failed request.
+		return nil, errIdempotencyKeyInProgress
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving idempotency key: %w", err)
+	}
+	if k.RequestHash != hash {
+		return nil, errIdempotencyKeyReused
+	}
+	if k.Response != "" {
+		return []byte(k.Response), nil
+	}
+
+	// The transactions were saved, but the response wasn't. It's rebuilt from them.
+	if k.EntryID != nil {
+		transactions, err := a.queries.ListTransactionsByEntry(ctx, k.EntryID)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving transactions: %w", err)
+		}
+		items := toItems(transactions)
+		if err := a.withTags(ctx, items); err != nil {
+			return nil, err
+		}
+		return json.Marshal(Resp{
+			Message: "Expenses saved",
+			Data:    items,
+		})
+	}
+	return nil, errIdempotencyKeyInProgress
+}
+
+// completeIdempotencyKey saves the response of the request of the key, for its retries.
+func (a *App) completeIdempotencyKey(ctx context.Context, key string, resp Resp) error {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return a.queries.SetIdempotencyKeyResponse(ctx, db.SetIdempotencyKeyResponseParams{
+		Response: string(b),
+		Key:      key,
+	})
+}
+
+// releaseIdempotencyKey lets the request of the key be retried, when it has failed
+// without saving anything.
+func (a *App) releaseIdempotencyKey(ctx context.Context, key string) error {
+	// A time ahead of now releases the key however recently it was claimed.
+	return a.queries.ReleaseIdempotencyKey(ctx, db.ReleaseIdempotencyKeyParams{
+		Key:    key,
+		Before: time.Now().Add(time.Second),
+	})
+}
+
+// requestHash returns the hash of a request to save the line.
+func requestHash(line string) string {
+	h := sha256.Sum256([]byte(line))
+	return hex.EncodeToString(h[:])
+}
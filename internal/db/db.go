@@ -24,35 +24,339 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.accountDailyTotalsStmt, err = db.PrepareContext(ctx, accountDailyTotals); err != nil {
+		return nil, fmt.Errorf("error preparing query AccountDailyTotals: %w", err)
+	}
+	if q.addCategoryAliasStmt, err = db.PrepareContext(ctx, addCategoryAlias); err != nil {
+		return nil, fmt.Errorf("error preparing query AddCategoryAlias: %w", err)
+	}
+	if q.addTransactionTagStmt, err = db.PrepareContext(ctx, addTransactionTag); err != nil {
+		return nil, fmt.Errorf("error preparing query AddTransactionTag: %w", err)
+	}
+	if q.advanceRecurringRuleStmt, err = db.PrepareContext(ctx, advanceRecurringRule); err != nil {
+		return nil, fmt.Errorf("error preparing query AdvanceRecurringRule: %w", err)
+	}
+	if q.cashFlowStmt, err = db.PrepareContext(ctx, cashFlow); err != nil {
+		return nil, fmt.Errorf("error preparing query CashFlow: %w", err)
+	}
+	if q.claimIdempotencyKeyStmt, err = db.PrepareContext(ctx, claimIdempotencyKey); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimIdempotencyKey: %w", err)
+	}
+	if q.countAttachmentsByHashStmt, err = db.PrepareContext(ctx, countAttachmentsByHash); err != nil {
+		return nil, fmt.Errorf("error preparing query CountAttachmentsByHash: %w", err)
+	}
+	if q.countRulesByAccountStmt, err = db.PrepareContext(ctx, countRulesByAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query CountRulesByAccount: %w", err)
+	}
+	if q.countTransactionsStmt, err = db.PrepareContext(ctx, countTransactions); err != nil {
+		return nil, fmt.Errorf("error preparing query CountTransactions: %w", err)
+	}
+	if q.createAccountStmt, err = db.PrepareContext(ctx, createAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAccount: %w", err)
+	}
+	if q.createAttachmentStmt, err = db.PrepareContext(ctx, createAttachment); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAttachment: %w", err)
+	}
+	if q.createBudgetStmt, err = db.PrepareContext(ctx, createBudget); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateBudget: %w", err)
+	}
+	if q.createCategoryStmt, err = db.PrepareContext(ctx, createCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateCategory: %w", err)
+	}
+	if q.createEntryStmt, err = db.PrepareContext(ctx, createEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateEntry: %w", err)
+	}
+	if q.createRecurringRuleStmt, err = db.PrepareContext(ctx, createRecurringRule); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateRecurringRule: %w", err)
+	}
+	if q.createRecurringTransactionStmt, err = db.PrepareContext(ctx, createRecurringTransaction); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateRecurringTransaction: %w", err)
+	}
+	if q.createRuleStmt, err = db.PrepareContext(ctx, createRule); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateRule: %w", err)
+	}
 	if q.createTransactionStmt, err = db.PrepareContext(ctx, createTransaction); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateTransaction: %w", err)
 	}
 	if q.dailySpendingStmt, err = db.PrepareContext(ctx, dailySpending); err != nil {
 		return nil, fmt.Errorf("error preparing query DailySpending: %w", err)
 	}
+	if q.deleteAccountStmt, err = db.PrepareContext(ctx, deleteAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAccount: %w", err)
+	}
+	if q.deleteAttachmentStmt, err = db.PrepareContext(ctx, deleteAttachment); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAttachment: %w", err)
+	}
+	if q.deleteBudgetStmt, err = db.PrepareContext(ctx, deleteBudget); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteBudget: %w", err)
+	}
+	if q.deleteCategoryStmt, err = db.PrepareContext(ctx, deleteCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteCategory: %w", err)
+	}
+	if q.deleteCategoryAliasesStmt, err = db.PrepareContext(ctx, deleteCategoryAliases); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteCategoryAliases: %w", err)
+	}
+	if q.deleteExpiredIdempotencyKeysStmt, err = db.PrepareContext(ctx, deleteExpiredIdempotencyKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredIdempotencyKeys: %w", err)
+	}
+	if q.deleteRecurringRuleStmt, err = db.PrepareContext(ctx, deleteRecurringRule); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteRecurringRule: %w", err)
+	}
+	if q.deleteRuleStmt, err = db.PrepareContext(ctx, deleteRule); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteRule: %w", err)
+	}
 	if q.deleteTransactionStmt, err = db.PrepareContext(ctx, deleteTransaction); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteTransaction: %w", err)
 	}
+	if q.deleteTransactionTagsStmt, err = db.PrepareContext(ctx, deleteTransactionTags); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteTransactionTags: %w", err)
+	}
+	if q.getAccountStmt, err = db.PrepareContext(ctx, getAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAccount: %w", err)
+	}
+	if q.getAttachmentStmt, err = db.PrepareContext(ctx, getAttachment); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAttachment: %w", err)
+	}
+	if q.getBudgetStmt, err = db.PrepareContext(ctx, getBudget); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBudget: %w", err)
+	}
+	if q.getCategoryStmt, err = db.PrepareContext(ctx, getCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCategory: %w", err)
+	}
+	if q.getEntryStmt, err = db.PrepareContext(ctx, getEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEntry: %w", err)
+	}
+	if q.getExchangeRateStmt, err = db.PrepareContext(ctx, getExchangeRate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetExchangeRate: %w", err)
+	}
+	if q.getIdempotencyKeyStmt, err = db.PrepareContext(ctx, getIdempotencyKey); err != nil {
+		return nil, fmt.Errorf("error preparing query GetIdempotencyKey: %w", err)
+	}
+	if q.getRecurringRuleStmt, err = db.PrepareContext(ctx, getRecurringRule); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRecurringRule: %w", err)
+	}
+	if q.getRuleStmt, err = db.PrepareContext(ctx, getRule); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRule: %w", err)
+	}
 	if q.getTransactionStmt, err = db.PrepareContext(ctx, getTransaction); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTransaction: %w", err)
 	}
+	if q.listAccountsStmt, err = db.PrepareContext(ctx, listAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccounts: %w", err)
+	}
+	if q.listAttachmentsByTransactionStmt, err = db.PrepareContext(ctx, listAttachmentsByTransaction); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAttachmentsByTransaction: %w", err)
+	}
+	if q.listBudgetsStmt, err = db.PrepareContext(ctx, listBudgets); err != nil {
+		return nil, fmt.Errorf("error preparing query ListBudgets: %w", err)
+	}
+	if q.listCategoriesStmt, err = db.PrepareContext(ctx, listCategories); err != nil {
+		return nil, fmt.Errorf("error preparing query ListCategories: %w", err)
+	}
+	if q.listCategoryAliasesStmt, err = db.PrepareContext(ctx, listCategoryAliases); err != nil {
+		return nil, fmt.Errorf("error preparing query ListCategoryAliases: %w", err)
+	}
+	if q.listDueRecurringRulesStmt, err = db.PrepareContext(ctx, listDueRecurringRules); err != nil {
+		return nil, fmt.Errorf("error preparing query ListDueRecurringRules: %w", err)
+	}
+	if q.listDuplicateCandidatesStmt, err = db.PrepareContext(ctx, listDuplicateCandidates); err != nil {
+		return nil, fmt.Errorf("error preparing query ListDuplicateCandidates: %w", err)
+	}
+	if q.listDuplicateTransactionsStmt, err = db.PrepareContext(ctx, listDuplicateTransactions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListDuplicateTransactions: %w", err)
+	}
+	if q.listExchangeRateBasesStmt, err = db.PrepareContext(ctx, listExchangeRateBases); err != nil {
+		return nil, fmt.Errorf("error preparing query ListExchangeRateBases: %w", err)
+	}
+	if q.listRecurringRulesStmt, err = db.PrepareContext(ctx, listRecurringRules); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRecurringRules: %w", err)
+	}
+	if q.listRulesStmt, err = db.PrepareContext(ctx, listRules); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRules: %w", err)
+	}
+	if q.listTagsStmt, err = db.PrepareContext(ctx, listTags); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTags: %w", err)
+	}
+	if q.listTagsByTransactionStmt, err = db.PrepareContext(ctx, listTagsByTransaction); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTagsByTransaction: %w", err)
+	}
+	if q.listTransactionTagsStmt, err = db.PrepareContext(ctx, listTransactionTags); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTransactionTags: %w", err)
+	}
 	if q.listTransactionsStmt, err = db.PrepareContext(ctx, listTransactions); err != nil {
 		return nil, fmt.Errorf("error preparing query ListTransactions: %w", err)
 	}
+	if q.listTransactionsByEntryStmt, err = db.PrepareContext(ctx, listTransactionsByEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTransactionsByEntry: %w", err)
+	}
 	if q.monthlySpendingSummaryStmt, err = db.PrepareContext(ctx, monthlySpendingSummary); err != nil {
 		return nil, fmt.Errorf("error preparing query MonthlySpendingSummary: %w", err)
 	}
+	if q.moveAttachmentsStmt, err = db.PrepareContext(ctx, moveAttachments); err != nil {
+		return nil, fmt.Errorf("error preparing query MoveAttachments: %w", err)
+	}
+	if q.releaseIdempotencyKeyStmt, err = db.PrepareContext(ctx, releaseIdempotencyKey); err != nil {
+		return nil, fmt.Errorf("error preparing query ReleaseIdempotencyKey: %w", err)
+	}
+	if q.removeTransactionTagStmt, err = db.PrepareContext(ctx, removeTransactionTag); err != nil {
+		return nil, fmt.Errorf("error preparing query RemoveTransactionTag: %w", err)
+	}
+	if q.renameBudgetsCategoryStmt, err = db.PrepareContext(ctx, renameBudgetsCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query RenameBudgetsCategory: %w", err)
+	}
+	if q.renameCorrectionsCategoryStmt, err = db.PrepareContext(ctx, renameCorrectionsCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query RenameCorrectionsCategory: %w", err)
+	}
+	if q.renameRecurringRulesCategoryStmt, err = db.PrepareContext(ctx, renameRecurringRulesCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query RenameRecurringRulesCategory: %w", err)
+	}
+	if q.renameRulesCategoryStmt, err = db.PrepareContext(ctx, renameRulesCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query RenameRulesCategory: %w", err)
+	}
+	if q.renameTransactionsCategoryStmt, err = db.PrepareContext(ctx, renameTransactionsCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query RenameTransactionsCategory: %w", err)
+	}
+	if q.searchTransactionsStmt, err = db.PrepareContext(ctx, searchTransactions); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchTransactions: %w", err)
+	}
+	if q.setIdempotencyKeyEntryStmt, err = db.PrepareContext(ctx, setIdempotencyKeyEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query SetIdempotencyKeyEntry: %w", err)
+	}
+	if q.setIdempotencyKeyResponseStmt, err = db.PrepareContext(ctx, setIdempotencyKeyResponse); err != nil {
+		return nil, fmt.Errorf("error preparing query SetIdempotencyKeyResponse: %w", err)
+	}
+	if q.setTransactionDuplicateStmt, err = db.PrepareContext(ctx, setTransactionDuplicate); err != nil {
+		return nil, fmt.Errorf("error preparing query SetTransactionDuplicate: %w", err)
+	}
+	if q.similarCategoryCorrectionsStmt, err = db.PrepareContext(ctx, similarCategoryCorrections); err != nil {
+		return nil, fmt.Errorf("error preparing query SimilarCategoryCorrections: %w", err)
+	}
+	if q.tagTotalsStmt, err = db.PrepareContext(ctx, tagTotals); err != nil {
+		return nil, fmt.Errorf("error preparing query TagTotals: %w", err)
+	}
 	if q.topExpenseCategoriesStmt, err = db.PrepareContext(ctx, topExpenseCategories); err != nil {
 		return nil, fmt.Errorf("error preparing query TopExpenseCategories: %w", err)
 	}
+	if q.touchTransactionStmt, err = db.PrepareContext(ctx, touchTransaction); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchTransaction: %w", err)
+	}
+	if q.updateAccountStmt, err = db.PrepareContext(ctx, updateAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAccount: %w", err)
+	}
+	if q.updateBudgetStmt, err = db.PrepareContext(ctx, updateBudget); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateBudget: %w", err)
+	}
+	if q.updateCategoryStmt, err = db.PrepareContext(ctx, updateCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateCategory: %w", err)
+	}
+	if q.updateRecurringRuleStmt, err = db.PrepareContext(ctx, updateRecurringRule); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateRecurringRule: %w", err)
+	}
+	if q.updateRuleStmt, err = db.PrepareContext(ctx, updateRule); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateRule: %w", err)
+	}
 	if q.updateTransactionStmt, err = db.PrepareContext(ctx, updateTransaction); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateTransaction: %w", err)
 	}
+	if q.upsertCategoryCorrectionStmt, err = db.PrepareContext(ctx, upsertCategoryCorrection); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertCategoryCorrection: %w", err)
+	}
+	if q.upsertExchangeRateStmt, err = db.PrepareContext(ctx, upsertExchangeRate); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertExchangeRate: %w", err)
+	}
+	if q.upsertTagStmt, err = db.PrepareContext(ctx, upsertTag); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertTag: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.accountDailyTotalsStmt != nil {
+		if cerr := q.accountDailyTotalsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing accountDailyTotalsStmt: %w", cerr)
+		}
+	}
+	if q.addCategoryAliasStmt != nil {
+		if cerr := q.addCategoryAliasStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addCategoryAliasStmt: %w", cerr)
+		}
+	}
+	if q.addTransactionTagStmt != nil {
+		if cerr := q.addTransactionTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addTransactionTagStmt: %w", cerr)
+		}
+	}
+	if q.advanceRecurringRuleStmt != nil {
+		if cerr := q.advanceRecurringRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing advanceRecurringRuleStmt: %w", cerr)
+		}
+	}
+	if q.cashFlowStmt != nil {
+		if cerr := q.cashFlowStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing cashFlowStmt: %w", cerr)
+		}
+	}
+	if q.claimIdempotencyKeyStmt != nil {
+		if cerr := q.claimIdempotencyKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimIdempotencyKeyStmt: %w", cerr)
+		}
+	}
+	if q.countAttachmentsByHashStmt != nil {
+		if cerr := q.countAttachmentsByHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countAttachmentsByHashStmt: %w", cerr)
+		}
+	}
+	if q.countRulesByAccountStmt != nil {
+		if cerr := q.countRulesByAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countRulesByAccountStmt: %w", cerr)
+		}
+	}
+	if q.countTransactionsStmt != nil {
+		if cerr := q.countTransactionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countTransactionsStmt: %w", cerr)
+		}
+	}
+	if q.createAccountStmt != nil {
+		if cerr := q.createAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAccountStmt: %w", cerr)
+		}
+	}
+	if q.createAttachmentStmt != nil {
+		if cerr := q.createAttachmentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAttachmentStmt: %w", cerr)
+		}
+	}
+	if q.createBudgetStmt != nil {
+		if cerr := q.createBudgetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createBudgetStmt: %w", cerr)
+		}
+	}
+	if q.createCategoryStmt != nil {
+		if cerr := q.createCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createCategoryStmt: %w", cerr)
+		}
+	}
+	if q.createEntryStmt != nil {
+		if cerr := q.createEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createEntryStmt: %w", cerr)
+		}
+	}
+	if q.createRecurringRuleStmt != nil {
+		if cerr := q.createRecurringRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createRecurringRuleStmt: %w", cerr)
+		}
+	}
+	if q.createRecurringTransactionStmt != nil {
+		if cerr := q.createRecurringTransactionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createRecurringTransactionStmt: %w", cerr)
+		}
+	}
+	if q.createRuleStmt != nil {
+		if cerr := q.createRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createRuleStmt: %w", cerr)
+		}
+	}
 	if q.createTransactionStmt != nil {
 		if cerr := q.createTransactionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createTransactionStmt: %w", cerr)
@@ -63,36 +367,316 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing dailySpendingStmt: %w", cerr)
 		}
 	}
+	if q.deleteAccountStmt != nil {
+		if cerr := q.deleteAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAccountStmt: %w", cerr)
+		}
+	}
+	if q.deleteAttachmentStmt != nil {
+		if cerr := q.deleteAttachmentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAttachmentStmt: %w", cerr)
+		}
+	}
+	if q.deleteBudgetStmt != nil {
+		if cerr := q.deleteBudgetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteBudgetStmt: %w", cerr)
+		}
+	}
+	if q.deleteCategoryStmt != nil {
+		if cerr := q.deleteCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteCategoryStmt: %w", cerr)
+		}
+	}
+	if q.deleteCategoryAliasesStmt != nil {
+		if cerr := q.deleteCategoryAliasesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteCategoryAliasesStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredIdempotencyKeysStmt != nil {
+		if cerr := q.deleteExpiredIdempotencyKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredIdempotencyKeysStmt: %w", cerr)
+		}
+	}
+	if q.deleteRecurringRuleStmt != nil {
+		if cerr := q.deleteRecurringRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteRecurringRuleStmt: %w", cerr)
+		}
+	}
+	if q.deleteRuleStmt != nil {
+		if cerr := q.deleteRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteRuleStmt: %w", cerr)
+		}
+	}
 	if q.deleteTransactionStmt != nil {
 		if cerr := q.deleteTransactionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteTransactionStmt: %w", cerr)
 		}
 	}
+	if q.deleteTransactionTagsStmt != nil {
+		if cerr := q.deleteTransactionTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteTransactionTagsStmt: %w", cerr)
+		}
+	}
+	if q.getAccountStmt != nil {
+		if cerr := q.getAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAccountStmt: %w", cerr)
+		}
+	}
+	if q.getAttachmentStmt != nil {
+		if cerr := q.getAttachmentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAttachmentStmt: %w", cerr)
+		}
+	}
+	if q.getBudgetStmt != nil {
+		if cerr := q.getBudgetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBudgetStmt: %w", cerr)
+		}
+	}
+	if q.getCategoryStmt != nil {
+		if cerr := q.getCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCategoryStmt: %w", cerr)
+		}
+	}
+	if q.getEntryStmt != nil {
+		if cerr := q.getEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEntryStmt: %w", cerr)
+		}
+	}
+	if q.getExchangeRateStmt != nil {
+		if cerr := q.getExchangeRateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getExchangeRateStmt: %w", cerr)
+		}
+	}
+	if q.getIdempotencyKeyStmt != nil {
+		if cerr := q.getIdempotencyKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getIdempotencyKeyStmt: %w", cerr)
+		}
+	}
+	if q.getRecurringRuleStmt != nil {
+		if cerr := q.getRecurringRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRecurringRuleStmt: %w", cerr)
+		}
+	}
+	if q.getRuleStmt != nil {
+		if cerr := q.getRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRuleStmt: %w", cerr)
+		}
+	}
 	if q.getTransactionStmt != nil {
 		if cerr := q.getTransactionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getTransactionStmt: %w", cerr)
 		}
 	}
+	if q.listAccountsStmt != nil {
+		if cerr := q.listAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccountsStmt: %w", cerr)
+		}
+	}
+	if q.listAttachmentsByTransactionStmt != nil {
+		if cerr := q.listAttachmentsByTransactionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAttachmentsByTransactionStmt: %w", cerr)
+		}
+	}
+	if q.listBudgetsStmt != nil {
+		if cerr := q.listBudgetsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listBudgetsStmt: %w", cerr)
+		}
+	}
+	if q.listCategoriesStmt != nil {
+		if cerr := q.listCategoriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listCategoriesStmt: %w", cerr)
+		}
+	}
+	if q.listCategoryAliasesStmt != nil {
+		if cerr := q.listCategoryAliasesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listCategoryAliasesStmt: %w", cerr)
+		}
+	}
+	if q.listDueRecurringRulesStmt != nil {
+		if cerr := q.listDueRecurringRulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listDueRecurringRulesStmt: %w", cerr)
+		}
+	}
+	if q.listDuplicateCandidatesStmt != nil {
+		if cerr := q.listDuplicateCandidatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listDuplicateCandidatesStmt: %w", cerr)
+		}
+	}
+	if q.listDuplicateTransactionsStmt != nil {
+		if cerr := q.listDuplicateTransactionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listDuplicateTransactionsStmt: %w", cerr)
+		}
+	}
+	if q.listExchangeRateBasesStmt != nil {
+		if cerr := q.listExchangeRateBasesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listExchangeRateBasesStmt: %w", cerr)
+		}
+	}
+	if q.listRecurringRulesStmt != nil {
+		if cerr := q.listRecurringRulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRecurringRulesStmt: %w", cerr)
+		}
+	}
+	if q.listRulesStmt != nil {
+		if cerr := q.listRulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRulesStmt: %w", cerr)
+		}
+	}
+	if q.listTagsStmt != nil {
+		if cerr := q.listTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTagsStmt: %w", cerr)
+		}
+	}
+	if q.listTagsByTransactionStmt != nil {
+		if cerr := q.listTagsByTransactionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTagsByTransactionStmt: %w", cerr)
+		}
+	}
+	if q.listTransactionTagsStmt != nil {
+		if cerr := q.listTransactionTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTransactionTagsStmt: %w", cerr)
+		}
+	}
 	if q.listTransactionsStmt != nil {
 		if cerr := q.listTransactionsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listTransactionsStmt: %w", cerr)
 		}
 	}
+	if q.listTransactionsByEntryStmt != nil {
+		if cerr := q.listTransactionsByEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTransactionsByEntryStmt: %w", cerr)
+		}
+	}
 	if q.monthlySpendingSummaryStmt != nil {
 		if cerr := q.monthlySpendingSummaryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing monthlySpendingSummaryStmt: %w", cerr)
 		}
 	}
+	if q.moveAttachmentsStmt != nil {
+		if cerr := q.moveAttachmentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing moveAttachmentsStmt: %w", cerr)
+		}
+	}
+	if q.releaseIdempotencyKeyStmt != nil {
+		if cerr := q.releaseIdempotencyKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing releaseIdempotencyKeyStmt: %w", cerr)
+		}
+	}
+	if q.removeTransactionTagStmt != nil {
+		if cerr := q.removeTransactionTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing removeTransactionTagStmt: %w", cerr)
+		}
+	}
+	if q.renameBudgetsCategoryStmt != nil {
+		if cerr := q.renameBudgetsCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing renameBudgetsCategoryStmt: %w", cerr)
+		}
+	}
+	if q.renameCorrectionsCategoryStmt != nil {
+		if cerr := q.renameCorrectionsCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing renameCorrectionsCategoryStmt: %w", cerr)
+		}
+	}
+	if q.renameRecurringRulesCategoryStmt != nil {
+		if cerr := q.renameRecurringRulesCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing renameRecurringRulesCategoryStmt: %w", cerr)
+		}
+	}
+	if q.renameRulesCategoryStmt != nil {
+		if cerr := q.renameRulesCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing renameRulesCategoryStmt: %w", cerr)
+		}
+	}
+	if q.renameTransactionsCategoryStmt != nil {
+		if cerr := q.renameTransactionsCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing renameTransactionsCategoryStmt: %w", cerr)
+		}
+	}
+	if q.searchTransactionsStmt != nil {
+		if cerr := q.searchTransactionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchTransactionsStmt: %w", cerr)
+		}
+	}
+	if q.setIdempotencyKeyEntryStmt != nil {
+		if cerr := q.setIdempotencyKeyEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setIdempotencyKeyEntryStmt: %w", cerr)
+		}
+	}
+	if q.setIdempotencyKeyResponseStmt != nil {
+		if cerr := q.setIdempotencyKeyResponseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setIdempotencyKeyResponseStmt: %w", cerr)
+		}
+	}
+	if q.setTransactionDuplicateStmt != nil {
+		if cerr := q.setTransactionDuplicateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setTransactionDuplicateStmt: %w", cerr)
+		}
+	}
+	if q.similarCategoryCorrectionsStmt != nil {
+		if cerr := q.similarCategoryCorrectionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing similarCategoryCorrectionsStmt: %w", cerr)
+		}
+	}
+	if q.tagTotalsStmt != nil {
+		if cerr := q.tagTotalsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing tagTotalsStmt: %w", cerr)
+		}
+	}
 	if q.topExpenseCategoriesStmt != nil {
 		if cerr := q.topExpenseCategoriesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing topExpenseCategoriesStmt: %w", cerr)
 		}
 	}
+	if q.touchTransactionStmt != nil {
+		if cerr := q.touchTransactionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchTransactionStmt: %w", cerr)
+		}
+	}
+	if q.updateAccountStmt != nil {
+		if cerr := q.updateAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAccountStmt: %w", cerr)
+		}
+	}
+	if q.updateBudgetStmt != nil {
+		if cerr := q.updateBudgetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateBudgetStmt: %w", cerr)
+		}
+	}
+	if q.updateCategoryStmt != nil {
+		if cerr := q.updateCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateCategoryStmt: %w", cerr)
+		}
+	}
+	if q.updateRecurringRuleStmt != nil {
+		if cerr := q.updateRecurringRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateRecurringRuleStmt: %w", cerr)
+		}
+	}
+	if q.updateRuleStmt != nil {
+		if cerr := q.updateRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateRuleStmt: %w", cerr)
+		}
+	}
 	if q.updateTransactionStmt != nil {
 		if cerr := q.updateTransactionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateTransactionStmt: %w", cerr)
 		}
 	}
+	if q.upsertCategoryCorrectionStmt != nil {
+		if cerr := q.upsertCategoryCorrectionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertCategoryCorrectionStmt: %w", cerr)
+		}
+	}
+	if q.upsertExchangeRateStmt != nil {
+		if cerr := q.upsertExchangeRateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertExchangeRateStmt: %w", cerr)
+		}
+	}
+	if q.upsertTagStmt != nil {
+		if cerr := q.upsertTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertTagStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -130,29 +714,175 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                         DBTX
-	tx                         *sql.Tx
-	createTransactionStmt      *sql.Stmt
-	dailySpendingStmt          *sql.Stmt
-	deleteTransactionStmt      *sql.Stmt
-	getTransactionStmt         *sql.Stmt
-	listTransactionsStmt       *sql.Stmt
-	monthlySpendingSummaryStmt *sql.Stmt
-	topExpenseCategoriesStmt   *sql.Stmt
-	updateTransactionStmt      *sql.Stmt
+	db                               DBTX
+	tx                               *sql.Tx
+	accountDailyTotalsStmt           *sql.Stmt
+	addCategoryAliasStmt             *sql.Stmt
+	addTransactionTagStmt            *sql.Stmt
+	advanceRecurringRuleStmt         *sql.Stmt
+	cashFlowStmt                     *sql.Stmt
+	claimIdempotencyKeyStmt          *sql.Stmt
+	countAttachmentsByHashStmt       *sql.Stmt
+	countRulesByAccountStmt          *sql.Stmt
+	countTransactionsStmt            *sql.Stmt
+	createAccountStmt                *sql.Stmt
+	createAttachmentStmt             *sql.Stmt
+	createBudgetStmt                 *sql.Stmt
+	createCategoryStmt               *sql.Stmt
+	createEntryStmt                  *sql.Stmt
+	createRecurringRuleStmt          *sql.Stmt
+	createRecurringTransactionStmt   *sql.Stmt
+	createRuleStmt                   *sql.Stmt
+	createTransactionStmt            *sql.Stmt
+	dailySpendingStmt                *sql.Stmt
+	deleteAccountStmt                *sql.Stmt
+	deleteAttachmentStmt             *sql.Stmt
+	deleteBudgetStmt                 *sql.Stmt
+	deleteCategoryStmt               *sql.Stmt
+	deleteCategoryAliasesStmt        *sql.Stmt
+	deleteExpiredIdempotencyKeysStmt *sql.Stmt
+	deleteRecurringRuleStmt          *sql.Stmt
+	deleteRuleStmt                   *sql.Stmt
+	deleteTransactionStmt            *sql.Stmt
+	deleteTransactionTagsStmt        *sql.Stmt
+	getAccountStmt                   *sql.Stmt
+	getAttachmentStmt                *sql.Stmt
+	getBudgetStmt                    *sql.Stmt
+	getCategoryStmt                  *sql.Stmt
+	getEntryStmt                     *sql.Stmt
+	getExchangeRateStmt              *sql.Stmt
+	getIdempotencyKeyStmt            *sql.Stmt
+	getRecurringRuleStmt             *sql.Stmt
+	getRuleStmt                      *sql.Stmt
+	getTransactionStmt               *sql.Stmt
+	listAccountsStmt                 *sql.Stmt
+	listAttachmentsByTransactionStmt *sql.Stmt
+	listBudgetsStmt                  *sql.Stmt
+	listCategoriesStmt               *sql.Stmt
+	listCategoryAliasesStmt          *sql.Stmt
+	listDueRecurringRulesStmt        *sql.Stmt
+	listDuplicateCandidatesStmt      *sql.Stmt
+	listDuplicateTransactionsStmt    *sql.Stmt
+	listExchangeRateBasesStmt        *sql.Stmt
+	listRecurringRulesStmt           *sql.Stmt
+	listRulesStmt                    *sql.Stmt
+	listTagsStmt                     *sql.Stmt
+	listTagsByTransactionStmt        *sql.Stmt
+	listTransactionTagsStmt          *sql.Stmt
+	listTransactionsStmt             *sql.Stmt
+	listTransactionsByEntryStmt      *sql.Stmt
+	monthlySpendingSummaryStmt       *sql.Stmt
+	moveAttachmentsStmt              *sql.Stmt
+	releaseIdempotencyKeyStmt        *sql.Stmt
+	removeTransactionTagStmt         *sql.Stmt
+	renameBudgetsCategoryStmt        *sql.Stmt
+	renameCorrectionsCategoryStmt    *sql.Stmt
+	renameRecurringRulesCategoryStmt *sql.Stmt
+	renameRulesCategoryStmt          *sql.Stmt
+	renameTransactionsCategoryStmt   *sql.Stmt
+	searchTransactionsStmt           *sql.Stmt
+	setIdempotencyKeyEntryStmt       *sql.Stmt
+	setIdempotencyKeyResponseStmt    *sql.Stmt
+	setTransactionDuplicateStmt      *sql.Stmt
+	similarCategoryCorrectionsStmt   *sql.Stmt
+	tagTotalsStmt                    *sql.Stmt
+	topExpenseCategoriesStmt         *sql.Stmt
+	touchTransactionStmt             *sql.Stmt
+	updateAccountStmt                *sql.Stmt
+	updateBudgetStmt                 *sql.Stmt
+	updateCategoryStmt               *sql.Stmt
+	updateRecurringRuleStmt          *sql.Stmt
+	updateRuleStmt                   *sql.Stmt
+	updateTransactionStmt            *sql.Stmt
+	upsertCategoryCorrectionStmt     *sql.Stmt
+	upsertExchangeRateStmt           *sql.Stmt
+	upsertTagStmt                    *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                         tx,
-		tx:                         tx,
-		createTransactionStmt:      q.createTransactionStmt,
-		dailySpendingStmt:          q.dailySpendingStmt,
-		deleteTransactionStmt:      q.deleteTransactionStmt,
-		getTransactionStmt:         q.getTransactionStmt,
-		listTransactionsStmt:       q.listTransactionsStmt,
-		monthlySpendingSummaryStmt: q.monthlySpendingSummaryStmt,
-		topExpenseCategoriesStmt:   q.topExpenseCategoriesStmt,
-		updateTransactionStmt:      q.updateTransactionStmt,
+		db:                               tx,
+		tx:                               tx,
+		accountDailyTotalsStmt:           q.accountDailyTotalsStmt,
+		addCategoryAliasStmt:             q.addCategoryAliasStmt,
+		addTransactionTagStmt:            q.addTransactionTagStmt,
+		advanceRecurringRuleStmt:         q.advanceRecurringRuleStmt,
+		cashFlowStmt:                     q.cashFlowStmt,
+		claimIdempotencyKeyStmt:          q.claimIdempotencyKeyStmt,
+		countAttachmentsByHashStmt:       q.countAttachmentsByHashStmt,
+		countRulesByAccountStmt:          q.countRulesByAccountStmt,
+		countTransactionsStmt:            q.countTransactionsStmt,
+		createAccountStmt:                q.createAccountStmt,
+		createAttachmentStmt:             q.createAttachmentStmt,
+		createBudgetStmt:                 q.createBudgetStmt,
+		createCategoryStmt:               q.createCategoryStmt,
+		createEntryStmt:                  q.createEntryStmt,
+		createRecurringRuleStmt:          q.createRecurringRuleStmt,
+		createRecurringTransactionStmt:   q.createRecurringTransactionStmt,
+		createRuleStmt:                   q.createRuleStmt,
+		createTransactionStmt:            q.createTransactionStmt,
+		dailySpendingStmt:                q.dailySpendingStmt,
+		deleteAccountStmt:                q.deleteAccountStmt,
+		deleteAttachmentStmt:             q.deleteAttachmentStmt,
+		deleteBudgetStmt:                 q.deleteBudgetStmt,
+		deleteCategoryStmt:               q.deleteCategoryStmt,
+		deleteCategoryAliasesStmt:        q.deleteCategoryAliasesStmt,
+		deleteExpiredIdempotencyKeysStmt: q.deleteExpiredIdempotencyKeysStmt,
+		deleteRecurringRuleStmt:          q.deleteRecurringRuleStmt,
+		deleteRuleStmt:                   q.deleteRuleStmt,
+		deleteTransactionStmt:            q.deleteTransactionStmt,
+		deleteTransactionTagsStmt:        q.deleteTransactionTagsStmt,
+		getAccountStmt:                   q.getAccountStmt,
+		getAttachmentStmt:                q.getAttachmentStmt,
+		getBudgetStmt:                    q.getBudgetStmt,
+		getCategoryStmt:                  q.getCategoryStmt,
+		getEntryStmt:                     q.getEntryStmt,
+		getExchangeRateStmt:              q.getExchangeRateStmt,
+		getIdempotencyKeyStmt:            q.getIdempotencyKeyStmt,
+		getRecurringRuleStmt:             q.getRecurringRuleStmt,
+		getRuleStmt:                      q.getRuleStmt,
+		getTransactionStmt:               q.getTransactionStmt,
+		listAccountsStmt:                 q.listAccountsStmt,
+		listAttachmentsByTransactionStmt: q.listAttachmentsByTransactionStmt,
+		listBudgetsStmt:                  q.listBudgetsStmt,
+		listCategoriesStmt:               q.listCategoriesStmt,
+		listCategoryAliasesStmt:          q.listCategoryAliasesStmt,
+		listDueRecurringRulesStmt:        q.listDueRecurringRulesStmt,
+		listDuplicateCandidatesStmt:      q.listDuplicateCandidatesStmt,
+		listDuplicateTransactionsStmt:    q.listDuplicateTransactionsStmt,
+		listExchangeRateBasesStmt:        q.listExchangeRateBasesStmt,
+		listRecurringRulesStmt:           q.listRecurringRulesStmt,
+		listRulesStmt:                    q.listRulesStmt,
+		listTagsStmt:                     q.listTagsStmt,
+		listTagsByTransactionStmt:        q.listTagsByTransactionStmt,
+		listTransactionTagsStmt:          q.listTransactionTagsStmt,
+		listTransactionsStmt:             q.listTransactionsStmt,
+		listTransactionsByEntryStmt:      q.listTransactionsByEntryStmt,
+		monthlySpendingSummaryStmt:       q.monthlySpendingSummaryStmt,
+		moveAttachmentsStmt:              q.moveAttachmentsStmt,
+		releaseIdempotencyKeyStmt:        q.releaseIdempotencyKeyStmt,
+		removeTransactionTagStmt:         q.removeTransactionTagStmt,
+		renameBudgetsCategoryStmt:        q.renameBudgetsCategoryStmt,
+		renameCorrectionsCategoryStmt:    q.renameCorrectionsCategoryStmt,
+		renameRecurringRulesCategoryStmt: q.renameRecurringRulesCategoryStmt,
+		renameRulesCategoryStmt:          q.renameRulesCategoryStmt,
+		renameTransactionsCategoryStmt:   q.renameTransactionsCategoryStmt,
+		searchTransactionsStmt:           q.searchTransactionsStmt,
+		setIdempotencyKeyEntryStmt:       q.setIdempotencyKeyEntryStmt,
+		setIdempotencyKeyResponseStmt:    q.setIdempotencyKeyResponseStmt,
+		setTransactionDuplicateStmt:      q.setTransactionDuplicateStmt,
+		similarCategoryCorrectionsStmt:   q.similarCategoryCorrectionsStmt,
+		tagTotalsStmt:                    q.tagTotalsStmt,
+		topExpenseCategoriesStmt:         q.topExpenseCategoriesStmt,
+		touchTransactionStmt:             q.touchTransactionStmt,
+		updateAccountStmt:                q.updateAccountStmt,
+		updateBudgetStmt:                 q.updateBudgetStmt,
+		updateCategoryStmt:               q.updateCategoryStmt,
+		updateRecurringRuleStmt:          q.updateRecurringRuleStmt,
+		updateRuleStmt:                   q.updateRuleStmt,
+		updateTransactionStmt:            q.updateTransactionStmt,
+		upsertCategoryCorrectionStmt:     q.upsertCategoryCorrectionStmt,
+		upsertExchangeRateStmt:           q.upsertExchangeRateStmt,
+		upsertTagStmt:                    q.upsertTagStmt,
 	}
 }
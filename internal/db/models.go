@@ -8,13 +8,138 @@ import (
 	"time"
 )
 
+type Account struct {
+	ID             int64     `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Name           string    `json:"name"`
+	Kind           string    `json:"kind"`
+	Currency       string    `json:"currency"`
+	OpeningBalance int64     `json:"opening_balance"`
+}
+
+type Attachment struct {
+	ID            int64     `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	TransactionID int64     `json:"transaction_id"`
+	Filename      string    `json:"filename"`
+	MimeType      string    `json:"mime_type"`
+	Size          int64     `json:"size"`
+	Sha256        string    `json:"sha256"`
+}
+
+type Budget struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Category  string    `json:"category"`
+	Period    string    `json:"period"`
+	Amount    int64     `json:"amount"`
+	Currency  string    `json:"currency"`
+	Rollover  bool      `json:"rollover"`
+}
+
+type Category struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+	ParentID  *int64    `json:"parent_id"`
+	Color     string    `json:"color"`
+}
+
+type CategoryAlias struct {
+	Alias      string `json:"alias"`
+	CategoryID int64  `json:"category_id"`
+}
+
+type CategoryCorrection struct {
+	ID          int64     `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+}
+
+type Entry struct {
+	ID               int64     `json:"id"`
+	CreatedAt        time.Time `json:"created_at"`
+	Line             string    `json:"line"`
+	Parser           string    `json:"parser"`
+	Model            string    `json:"model"`
+	PromptVersion    string    `json:"prompt_version"`
+	LatencyMs        int64     `json:"latency_ms"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+}
+
+type ExchangeRate struct {
+	Date  time.Time `json:"date"`
+	Base  string    `json:"base"`
+	Quote string    `json:"quote"`
+	Rate  float64   `json:"rate"`
+}
+
+type IdempotencyKey struct {
+	Key         string    `json:"key"`
+	CreatedAt   time.Time `json:"created_at"`
+	RequestHash string    `json:"request_hash"`
+	EntryID     *int64    `json:"entry_id"`
+	Response    string    `json:"response"`
+}
+
+type RecurringRule struct {
+	ID          int64      `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Description string     `json:"description"`
+	Category    string     `json:"category"`
+	Type        string     `json:"type"`
+	Amount      int64      `json:"amount"`
+	Currency    string     `json:"currency"`
+	AccountID   *int64     `json:"account_id"`
+	Rrule       string     `json:"rrule"`
+	StartDate   time.Time  `json:"start_date"`
+	NextDue     *time.Time `json:"next_due"`
+	Occurrences int64      `json:"occurrences"`
+}
+
+type Rule struct {
+	ID                 int64     `json:"id"`
+	CreatedAt          time.Time `json:"created_at"`
+	Name               string    `json:"name"`
+	Priority           int64     `json:"priority"`
+	DescriptionPattern string    `json:"description_pattern"`
+	MinAmount          *int64    `json:"min_amount"`
+	MaxAmount          *int64    `json:"max_amount"`
+	AccountID          *int64    `json:"account_id"`
+	Currency           string    `json:"currency"`
+	SetCategory        string    `json:"set_category"`
+	AddTags            string    `json:"add_tags"`
+	SetAccountID       *int64    `json:"set_account_id"`
+	Confirm            bool      `json:"confirm"`
+}
+
+type Tag struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
 type Transaction struct {
-	ID              int64     `json:"id"`
-	CreatedAt       time.Time `json:"created_at"`
-	TransactionDate time.Time `json:"transaction_date"`
-	Currency        string    `json:"currency"`
-	Amount          float64   `json:"amount"`
-	Category        string    `json:"category"`
-	Description     string    `json:"description"`
-	Confirm         bool      `json:"confirm"`
+	ID                int64     `json:"id"`
+	CreatedAt         time.Time `json:"created_at"`
+	TransactionDate   time.Time `json:"transaction_date"`
+	Currency          string    `json:"currency"`
+	Amount            int64     `json:"amount"`
+	Category          string    `json:"category"`
+	Description       string    `json:"description"`
+	Confirm           bool      `json:"confirm"`
+	NeedsReparse      bool      `json:"needs_reparse"`
+	EntryID           *int64    `json:"entry_id"`
+	AccountID         *int64    `json:"account_id"`
+	Type              string    `json:"type"`
+	TransferAccountID *int64    `json:"transfer_account_id"`
+	RecurringRuleID   *int64    `json:"recurring_rule_id"`
+	Version           int64     `json:"version"`
+	DuplicateOf       *int64    `json:"duplicate_of"`
+}
+
+type TransactionTag struct {
+	TransactionID int64 `json:"transaction_id"`
+	TagID         int64 `json:"tag_id"`
 }
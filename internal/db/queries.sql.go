@@ -7,44 +7,2003 @@ package db
 
 import (
 	"context"
-	"database/sql"
 	"time"
 )
 
+const accountDailyTotals = `-- name: AccountDailyTotals :many
+SELECT
+    transaction_date,
+    currency,
+    CAST(COALESCE(SUM(CASE WHEN type = 'income' OR (type = 'transfer' AND transfer_account_id = ?1) THEN amount ELSE 0 END), 0) AS INTEGER) AS inflow,
+    CAST(COALESCE(SUM(CASE WHEN type = 'expense' OR (type = 'transfer' AND account_id = ?1) THEN amount ELSE 0 END), 0) AS INTEGER) AS outflow
+FROM transactions
+WHERE (account_id = ?1 OR transfer_account_id = ?1) AND transaction_date <= ?2 AND confirm = 1
+GROUP BY transaction_date, currency
+ORDER BY transaction_date ASC
+`
+
+type AccountDailyTotalsParams struct {
+	AccountID *int64    `json:"account_id"`
+	EndDate   time.Time `json:"end_date"`
+}
+
+type AccountDailyTotalsRow struct {
+	TransactionDate time.Time `json:"transaction_date"`
+	Currency        string    `json:"currency"`
+	Inflow          int64     `json:"inflow"`
+	Outflow         int64     `json:"outflow"`
+}
+
+// Retrieves the money which came in to and went out of an account for each day and currency
+// up to a date. Income and transfers to the account are inflows, expenses and transfers
+// from it are outflows. Used to compute running balances, so there's no start date.
+func (q *Queries) AccountDailyTotals(ctx context.Context, arg AccountDailyTotalsParams) ([]AccountDailyTotalsRow, error) {
+	rows, err := q.query(ctx, q.accountDailyTotalsStmt, accountDailyTotals, arg.AccountID, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountDailyTotalsRow{}
+	for rows.Next() {
+		var i AccountDailyTotalsRow
+		if err := rows.Scan(
+			&i.TransactionDate,
+			&i.Currency,
+			&i.Inflow,
+			&i.Outflow,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const addCategoryAlias = `-- name: AddCategoryAlias :exec
+INSERT INTO category_aliases (alias, category_id) VALUES (?, ?)
+`
+
+type AddCategoryAliasParams struct {
+	Alias      string `json:"alias"`
+	CategoryID int64  `json:"category_id"`
+}
+
+// Adds an alias to a category.
+func (q *Queries) AddCategoryAlias(ctx context.Context, arg AddCategoryAliasParams) error {
+	_, err := q.exec(ctx, q.addCategoryAliasStmt, addCategoryAlias, arg.Alias, arg.CategoryID)
+	return err
+}
+
+const addTransactionTag = `-- name: AddTransactionTag :exec
+INSERT INTO transaction_tags (transaction_id, tag_id) VALUES (?, ?)
+ON CONFLICT DO NOTHING
+`
+
+type AddTransactionTagParams struct {
+	TransactionID int64 `json:"transaction_id"`
+	TagID         int64 `json:"tag_id"`
+}
+
+// Tags a transaction.
+func (q *Queries) AddTransactionTag(ctx context.Context, arg AddTransactionTagParams) error {
+	_, err := q.exec(ctx, q.addTransactionTagStmt, addTransactionTag, arg.TransactionID, arg.TagID)
+	return err
+}
+
+const advanceRecurringRule = `-- name: AdvanceRecurringRule :exec
+UPDATE recurring_rules SET next_due = ?, occurrences = ? WHERE id = ?
+`
+
+type AdvanceRecurringRuleParams struct {
+	NextDue     *time.Time `json:"next_due"`
+	Occurrences int64      `json:"occurrences"`
+	ID          int64      `json:"id"`
+}
+
+// Moves a recurring rule to its next due date.
+func (q *Queries) AdvanceRecurringRule(ctx context.Context, arg AdvanceRecurringRuleParams) error {
+	_, err := q.exec(ctx, q.advanceRecurringRuleStmt, advanceRecurringRule, arg.NextDue, arg.Occurrences, arg.ID)
+	return err
+}
+
+const cashFlow = `-- name: CashFlow :many
+SELECT
+    transaction_date,
+    currency,
+    type,
+    CAST(COALESCE(SUM(amount), 0) AS INTEGER) AS total
+FROM transactions
+WHERE transaction_date BETWEEN ?1 AND ?2 AND confirm = 1 AND type IN ('expense', 'income')
+  AND (?3 IS NULL OR account_id = ?3)
+GROUP BY transaction_date, currency, type
+ORDER BY transaction_date ASC
+`
+
+type CashFlowParams struct {
+	StartDate time.Time   `json:"startDate"`
+	EndDate   time.Time   `json:"endDate"`
+	AccountID interface{} `json:"account_id"`
+}
+
+type CashFlowRow struct {
+	TransactionDate time.Time `json:"transaction_date"`
+	Currency        string    `json:"currency"`
+	Type            string    `json:"type"`
+	Total           int64     `json:"total"`
+}
+
+// Retrieves the total income and expenses for each day and currency within a specified
+// date range, optionally for a single account. Transfers between accounts are neither.
+func (q *Queries) CashFlow(ctx context.Context, arg CashFlowParams) ([]CashFlowRow, error) {
+	rows, err := q.query(ctx, q.cashFlowStmt, cashFlow, arg.StartDate, arg.EndDate, arg.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CashFlowRow{}
+	for rows.Next() {
+		var i CashFlowRow
+		if err := rows.Scan(
+			&i.TransactionDate,
+			&i.Currency,
+			&i.Type,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimIdempotencyKey = `-- name: ClaimIdempotencyKey :execrows
+INSERT INTO idempotency_keys (key, created_at, request_hash) VALUES (?, ?, ?)
+ON CONFLICT (key) DO NOTHING
+`
+
+type ClaimIdempotencyKeyParams struct {
+	Key         string    `json:"key"`
+	CreatedAt   time.Time `json:"created_at"`
+	RequestHash string    `json:"request_hash"`
+}
+
+// Saves an idempotency key, unless it exists already.
+func (q *Queries) ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) (int64, error) {
+	result, err := q.exec(ctx, q.claimIdempotencyKeyStmt, claimIdempotencyKey, arg.Key, arg.CreatedAt, arg.RequestHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countAttachmentsByHash = `-- name: CountAttachmentsByHash :one
+SELECT COUNT(*) FROM attachments WHERE sha256 = ?
+`
+
+// Counts the attachments whose content has the given hash.
+func (q *Queries) CountAttachmentsByHash(ctx context.Context, sha256 string) (int64, error) {
+	row := q.queryRow(ctx, q.countAttachmentsByHashStmt, countAttachmentsByHash, sha256)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRulesByAccount = `-- name: CountRulesByAccount :one
+SELECT COUNT(*) FROM rules WHERE account_id = ?1 OR set_account_id = ?1
+`
+
+// Counts the rules which match transactions of the account or move them to it.
+func (q *Queries) CountRulesByAccount(ctx context.Context, accountID *int64) (int64, error) {
+	row := q.queryRow(ctx, q.countRulesByAccountStmt, countRulesByAccount, accountID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countTransactions = `-- name: CountTransactions :one
+SELECT COUNT(*)
+FROM transactions
+WHERE (?1 IS NULL OR confirm = ?1)
+  AND (?2 IS NULL OR transaction_date >= ?2)
+  AND (?3 IS NULL OR transaction_date <= ?3)
+  AND (?4 IS NULL OR needs_reparse = ?4)
+  AND (?5 IS NULL OR account_id = ?5 OR transfer_account_id = ?5)
+  AND (?6 IS NULL OR type = ?6)
+  AND (?7 IS NULL OR id IN (SELECT tt.transaction_id FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tg.name = ?7))
+  AND (?8 IS NULL OR lower(category) IN (SELECT lower(value) FROM json_each(?8)))
+  AND (?9 IS NULL OR currency = ?9)
+  AND (?10 IS NULL OR amount >= COALESCE(json_extract(?10, '$."' || currency || '"'), json_extract(?10, '$."*"')))
+  AND (?11 IS NULL OR amount <= COALESCE(json_extract(?11, '$."' || currency || '"'), json_extract(?11, '$."*"')))
+  AND (?12 IS NULL OR description LIKE '%' || ?12 || '%' ESCAPE '\')
+`
+
+type CountTransactionsParams struct {
+	Confirm      interface{} `json:"confirm"`
+	StartDate    interface{} `json:"start_date"`
+	EndDate      interface{} `json:"end_date"`
+	NeedsReparse interface{} `json:"needs_reparse"`
+	AccountID    interface{} `json:"account_id"`
+	Type         interface{} `json:"type"`
+	Tag          interface{} `json:"tag"`
+	Categories   interface{} `json:"categories"`
+	Currency     interface{} `json:"currency"`
+	MinAmount    interface{} `json:"min_amount"`
+	MaxAmount    interface{} `json:"max_amount"`
+	Description  interface{} `json:"description"`
+}
+
+// Counts the transactions which match the filters of ListTransactions.
+func (q *Queries) CountTransactions(ctx context.Context, arg CountTransactionsParams) (int64, error) {
+	row := q.queryRow(ctx, q.countTransactionsStmt, countTransactions,
+		arg.Confirm,
+		arg.StartDate,
+		arg.EndDate,
+		arg.NeedsReparse,
+		arg.AccountID,
+		arg.Type,
+		arg.Tag,
+		arg.Categories,
+		arg.Currency,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Description,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAccount = `-- name: CreateAccount :one
+INSERT INTO accounts (created_at, name, kind, currency, opening_balance)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, created_at, name, kind, currency, opening_balance
+`
+
+type CreateAccountParams struct {
+	CreatedAt      time.Time `json:"created_at"`
+	Name           string    `json:"name"`
+	Kind           string    `json:"kind"`
+	Currency       string    `json:"currency"`
+	OpeningBalance int64     `json:"opening_balance"`
+}
+
+// Inserts a new account.
+func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	row := q.queryRow(ctx, q.createAccountStmt, createAccount,
+		arg.CreatedAt,
+		arg.Name,
+		arg.Kind,
+		arg.Currency,
+		arg.OpeningBalance,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Name,
+		&i.Kind,
+		&i.Currency,
+		&i.OpeningBalance,
+	)
+	return i, err
+}
+
+const createAttachment = `-- name: CreateAttachment :one
+INSERT INTO attachments (created_at, transaction_id, filename, mime_type, size, sha256)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, created_at, transaction_id, filename, mime_type, size, sha256
+`
+
+type CreateAttachmentParams struct {
+	CreatedAt     time.Time `json:"created_at"`
+	TransactionID int64     `json:"transaction_id"`
+	Filename      string    `json:"filename"`
+	MimeType      string    `json:"mime_type"`
+	Size          int64     `json:"size"`
+	Sha256        string    `json:"sha256"`
+}
+
+// Attaches a file in the blob store to a transaction.
+func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachment, error) {
+	row := q.queryRow(ctx, q.createAttachmentStmt, createAttachment,
+		arg.CreatedAt,
+		arg.TransactionID,
+		arg.Filename,
+		arg.MimeType,
+		arg.Size,
+		arg.Sha256,
+	)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.TransactionID,
+		&i.Filename,
+		&i.MimeType,
+		&i.Size,
+		&i.Sha256,
+	)
+	return i, err
+}
+
+const createBudget = `-- name: CreateBudget :one
+INSERT INTO budgets (created_at, category, period, amount, currency, rollover)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, created_at, category, period, amount, currency, rollover
+`
+
+type CreateBudgetParams struct {
+	CreatedAt time.Time `json:"created_at"`
+	Category  string    `json:"category"`
+	Period    string    `json:"period"`
+	Amount    int64     `json:"amount"`
+	Currency  string    `json:"currency"`
+	Rollover  bool      `json:"rollover"`
+}
+
+// Inserts a new budget.
+func (q *Queries) CreateBudget(ctx context.Context, arg CreateBudgetParams) (Budget, error) {
+	row := q.queryRow(ctx, q.createBudgetStmt, createBudget,
+		arg.CreatedAt,
+		arg.Category,
+		arg.Period,
+		arg.Amount,
+		arg.Currency,
+		arg.Rollover,
+	)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Category,
+		&i.Period,
+		&i.Amount,
+		&i.Currency,
+		&i.Rollover,
+	)
+	return i, err
+}
+
+const createCategory = `-- name: CreateCategory :one
+INSERT INTO categories (created_at, name, parent_id, color)
+VALUES (?, ?, ?, ?)
+RETURNING id, created_at, name, parent_id, color
+`
+
+type CreateCategoryParams struct {
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+	ParentID  *int64    `json:"parent_id"`
+	Color     string    `json:"color"`
+}
+
+// Inserts a new category.
+func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error) {
+	row := q.queryRow(ctx, q.createCategoryStmt, createCategory,
+		arg.CreatedAt,
+		arg.Name,
+		arg.ParentID,
+		arg.Color,
+	)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Name,
+		&i.ParentID,
+		&i.Color,
+	)
+	return i, err
+}
+
+const createEntry = `-- name: CreateEntry :one
+INSERT INTO entries (created_at, line, parser, model, prompt_version, latency_ms, prompt_tokens, completion_tokens)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, created_at, line, parser, model, prompt_version, latency_ms, prompt_tokens, completion_tokens
+`
+
+type CreateEntryParams struct {
+	CreatedAt        time.Time `json:"created_at"`
+	Line             string    `json:"line"`
+	Parser           string    `json:"parser"`
+	Model            string    `json:"model"`
+	PromptVersion    string    `json:"prompt_version"`
+	LatencyMs        int64     `json:"latency_ms"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+}
+
+// Saves an input line along with the details of how it was parsed.
+func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
+	row := q.queryRow(ctx, q.createEntryStmt, createEntry,
+		arg.CreatedAt,
+		arg.Line,
+		arg.Parser,
+		arg.Model,
+		arg.PromptVersion,
+		arg.LatencyMs,
+		arg.PromptTokens,
+		arg.CompletionTokens,
+	)
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Line,
+		&i.Parser,
+		&i.Model,
+		&i.PromptVersion,
+		&i.LatencyMs,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+	)
+	return i, err
+}
+
+const createRecurringRule = `-- name: CreateRecurringRule :one
+INSERT INTO recurring_rules (created_at, description, category, type, amount, currency, account_id, rrule, start_date, next_due, occurrences)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, created_at, description, category, type, amount, currency, account_id, rrule, start_date, next_due, occurrences
+`
+
+type CreateRecurringRuleParams struct {
+	CreatedAt   time.Time  `json:"created_at"`
+	Description string     `json:"description"`
+	Category    string     `json:"category"`
+	Type        string     `json:"type"`
+	Amount      int64      `json:"amount"`
+	Currency    string     `json:"currency"`
+	AccountID   *int64     `json:"account_id"`
+	Rrule       string     `json:"rrule"`
+	StartDate   time.Time  `json:"start_date"`
+	NextDue     *time.Time `json:"next_due"`
+	Occurrences int64      `json:"occurrences"`
+}
+
+// Inserts a new recurring rule.
+func (q *Queries) CreateRecurringRule(ctx context.Context, arg CreateRecurringRuleParams) (RecurringRule, error) {
+	row := q.queryRow(ctx, q.createRecurringRuleStmt, createRecurringRule,
+		arg.CreatedAt,
+		arg.Description,
+		arg.Category,
+		arg.Type,
+		arg.Amount,
+		arg.Currency,
+		arg.AccountID,
+		arg.Rrule,
+		arg.StartDate,
+		arg.NextDue,
+		arg.Occurrences,
+	)
+	var i RecurringRule
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Description,
+		&i.Category,
+		&i.Type,
+		&i.Amount,
+		&i.Currency,
+		&i.AccountID,
+		&i.Rrule,
+		&i.StartDate,
+		&i.NextDue,
+		&i.Occurrences,
+	)
+	return i, err
+}
+
+const createRecurringTransaction = `-- name: CreateRecurringTransaction :execrows
+INSERT INTO transactions (created_at, transaction_date, amount, currency, category, description, type, account_id, recurring_rule_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT DO NOTHING
+`
+
+type CreateRecurringTransactionParams struct {
+	CreatedAt       time.Time `json:"created_at"`
+	TransactionDate time.Time `json:"transaction_date"`
+	Amount          int64     `json:"amount"`
+	Currency        string    `json:"currency"`
+	Category        string    `json:"category"`
+	Description     string    `json:"description"`
+	Type            string    `json:"type"`
+	AccountID       *int64    `json:"account_id"`
+	RecurringRuleID *int64    `json:"recurring_rule_id"`
+}
+
+// Inserts the unconfirmed transaction of a recurring rule for a date. It does nothing
+// if the rule already has a transaction on that date.
+func (q *Queries) CreateRecurringTransaction(ctx context.Context, arg CreateRecurringTransactionParams) (int64, error) {
+	result, err := q.exec(ctx, q.createRecurringTransactionStmt, createRecurringTransaction,
+		arg.CreatedAt,
+		arg.TransactionDate,
+		arg.Amount,
+		arg.Currency,
+		arg.Category,
+		arg.Description,
+		arg.Type,
+		arg.AccountID,
+		arg.RecurringRuleID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createRule = `-- name: CreateRule :one
+INSERT INTO rules (created_at, name, priority, description_pattern, min_amount, max_amount, account_id, currency, set_category, add_tags, set_account_id, confirm)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, created_at, name, priority, description_pattern, min_amount, max_amount, account_id, currency, set_category, add_tags, set_account_id, confirm
+`
+
+type CreateRuleParams struct {
+	CreatedAt          time.Time `json:"created_at"`
+	Name               string    `json:"name"`
+	Priority           int64     `json:"priority"`
+	DescriptionPattern string    `json:"description_pattern"`
+	MinAmount          *int64    `json:"min_amount"`
+	MaxAmount          *int64    `json:"max_amount"`
+	AccountID          *int64    `json:"account_id"`
+	Currency           string    `json:"currency"`
+	SetCategory        string    `json:"set_category"`
+	AddTags            string    `json:"add_tags"`
+	SetAccountID       *int64    `json:"set_account_id"`
+	Confirm            bool      `json:"confirm"`
+}
+
+// Inserts a new rule.
+func (q *Queries) CreateRule(ctx context.Context, arg CreateRuleParams) (Rule, error) {
+	row := q.queryRow(ctx, q.createRuleStmt, createRule,
+		arg.CreatedAt,
+		arg.Name,
+		arg.Priority,
+		arg.DescriptionPattern,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.AccountID,
+		arg.Currency,
+		arg.SetCategory,
+		arg.AddTags,
+		arg.SetAccountID,
+		arg.Confirm,
+	)
+	var i Rule
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Name,
+		&i.Priority,
+		&i.DescriptionPattern,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.AccountID,
+		&i.Currency,
+		&i.SetCategory,
+		&i.AddTags,
+		&i.SetAccountID,
+		&i.Confirm,
+	)
+	return i, err
+}
+
 const createTransaction = `-- name: CreateTransaction :many
-INSERT INTO transactions (created_at, transaction_date, amount, currency, category, description, confirm)
-VALUES (?, ?, ?, ?, ?, ?, ?)
-RETURNING id, created_at, transaction_date, currency, amount, category, description, confirm
+INSERT INTO transactions (created_at, transaction_date, amount, currency, category, description, confirm, needs_reparse, entry_id, account_id, type, transfer_account_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, created_at, transaction_date, currency, amount, category, description, confirm, needs_reparse, entry_id, account_id, type, transfer_account_id, recurring_rule_id, version, duplicate_of
 `
 
-type CreateTransactionParams struct {
-	CreatedAt       time.Time `json:"created_at"`
-	TransactionDate time.Time `json:"transaction_date"`
-	Amount          float64   `json:"amount"`
-	Currency        string    `json:"currency"`
-	Category        string    `json:"category"`
-	Description     string    `json:"description"`
-	Confirm         bool      `json:"confirm"`
+type CreateTransactionParams struct {
+	CreatedAt         time.Time `json:"created_at"`
+	TransactionDate   time.Time `json:"transaction_date"`
+	Amount            int64     `json:"amount"`
+	Currency          string    `json:"currency"`
+	Category          string    `json:"category"`
+	Description       string    `json:"description"`
+	Confirm           bool      `json:"confirm"`
+	NeedsReparse      bool      `json:"needs_reparse"`
+	EntryID           *int64    `json:"entry_id"`
+	AccountID         *int64    `json:"account_id"`
+	Type              string    `json:"type"`
+	TransferAccountID *int64    `json:"transfer_account_id"`
+}
+
+// Inserts a new transaction into the database.
+func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) ([]Transaction, error) {
+	rows, err := q.query(ctx, q.createTransactionStmt, createTransaction,
+		arg.CreatedAt,
+		arg.TransactionDate,
+		arg.Amount,
+		arg.Currency,
+		arg.Category,
+		arg.Description,
+		arg.Confirm,
+		arg.NeedsReparse,
+		arg.EntryID,
+		arg.AccountID,
+		arg.Type,
+		arg.TransferAccountID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transaction{}
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.TransactionDate,
+			&i.Currency,
+			&i.Amount,
+			&i.Category,
+			&i.Description,
+			&i.Confirm,
+			&i.NeedsReparse,
+			&i.EntryID,
+			&i.AccountID,
+			&i.Type,
+			&i.TransferAccountID,
+			&i.RecurringRuleID,
+			&i.Version,
+			&i.DuplicateOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const dailySpending = `-- name: DailySpending :many
+SELECT
+    transaction_date,
+    currency,
+    CAST(COALESCE(SUM(amount), 0) AS INTEGER) AS total_spent
+FROM transactions
+WHERE transaction_date BETWEEN ?1 AND ?2 AND confirm = 1 AND type = 'expense'
+  AND (?3 IS NULL OR account_id = ?3)
+GROUP BY transaction_date, currency
+ORDER BY transaction_date ASC
+`
+
+type DailySpendingParams struct {
+	StartDate time.Time   `json:"startDate"`
+	EndDate   time.Time   `json:"endDate"`
+	AccountID interface{} `json:"account_id"`
+}
+
+type DailySpendingRow struct {
+	TransactionDate time.Time `json:"transaction_date"`
+	Currency        string    `json:"currency"`
+	TotalSpent      int64     `json:"total_spent"`
+}
+
+// Retrieves the sum total of all expenses for each day and currency within a specified date range,
+// optionally for a single account. Amounts are in minor units of the currency.
+func (q *Queries) DailySpending(ctx context.Context, arg DailySpendingParams) ([]DailySpendingRow, error) {
+	rows, err := q.query(ctx, q.dailySpendingStmt, dailySpending, arg.StartDate, arg.EndDate, arg.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DailySpendingRow{}
+	for rows.Next() {
+		var i DailySpendingRow
+		if err := rows.Scan(&i.TransactionDate, &i.Currency, &i.TotalSpent); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteAccount = `-- name: DeleteAccount :exec
+DELETE FROM accounts WHERE id = ?
+`
+
+// Deletes an account by ID. Its transactions are kept without an account.
+func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteAccountStmt, deleteAccount, id)
+	return err
+}
+
+const deleteAttachment = `-- name: DeleteAttachment :one
+DELETE FROM attachments WHERE id = ?1 AND transaction_id = ?2
+RETURNING sha256
+`
+
+type DeleteAttachmentParams struct {
+	ID            int64 `json:"id"`
+	TransactionID int64 `json:"transaction_id"`
+}
+
+// Deletes an attachment of a transaction, returning the hash of its content.
+func (q *Queries) DeleteAttachment(ctx context.Context, arg DeleteAttachmentParams) (string, error) {
+	row := q.queryRow(ctx, q.deleteAttachmentStmt, deleteAttachment, arg.ID, arg.TransactionID)
+	var sha256 string
+	err := row.Scan(&sha256)
+	return sha256, err
+}
+
+const deleteBudget = `-- name: DeleteBudget :exec
+DELETE FROM budgets WHERE id = ?
+`
+
+// Deletes a budget by ID.
+func (q *Queries) DeleteBudget(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteBudgetStmt, deleteBudget, id)
+	return err
+}
+
+const deleteCategory = `-- name: DeleteCategory :exec
+DELETE FROM categories WHERE id = ?
+`
+
+// Deletes a category by ID. Its subcategories are moved to the top level and
+// its transactions keep the name of the category.
+func (q *Queries) DeleteCategory(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteCategoryStmt, deleteCategory, id)
+	return err
+}
+
+const deleteCategoryAliases = `-- name: DeleteCategoryAliases :exec
+DELETE FROM category_aliases WHERE category_id = ?
+`
+
+// Removes all the aliases of a category.
+func (q *Queries) DeleteCategoryAliases(ctx context.Context, categoryID int64) error {
+	_, err := q.exec(ctx, q.deleteCategoryAliasesStmt, deleteCategoryAliases, categoryID)
+	return err
+}
+
+const deleteExpiredIdempotencyKeys = `-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys WHERE created_at < ?
+`
+
+// Deletes the idempotency keys created before the given time.
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.deleteExpiredIdempotencyKeysStmt, deleteExpiredIdempotencyKeys, createdAt)
+	return err
+}
+
+const deleteRecurringRule = `-- name: DeleteRecurringRule :exec
+DELETE FROM recurring_rules WHERE id = ?
+`
+
+// Deletes a recurring rule by ID. Its transactions are kept.
+func (q *Queries) DeleteRecurringRule(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteRecurringRuleStmt, deleteRecurringRule, id)
+	return err
+}
+
+const deleteRule = `-- name: DeleteRule :exec
+DELETE FROM rules WHERE id = ?
+`
+
+// Deletes a rule by ID.
+func (q *Queries) DeleteRule(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteRuleStmt, deleteRule, id)
+	return err
+}
+
+const deleteTransaction = `-- name: DeleteTransaction :exec
+DELETE FROM transactions WHERE id = ?
+`
+
+// Deletes a transaction by ID.
+func (q *Queries) DeleteTransaction(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteTransactionStmt, deleteTransaction, id)
+	return err
+}
+
+const deleteTransactionTags = `-- name: DeleteTransactionTags :exec
+DELETE FROM transaction_tags WHERE transaction_id = ?
+`
+
+// Removes all the tags of a transaction.
+func (q *Queries) DeleteTransactionTags(ctx context.Context, transactionID int64) error {
+	_, err := q.exec(ctx, q.deleteTransactionTagsStmt, deleteTransactionTags, transactionID)
+	return err
+}
+
+const getAccount = `-- name: GetAccount :one
+SELECT id, created_at, name, kind, currency, opening_balance FROM accounts WHERE id = ?
+`
+
+// Retrieves a single account by ID.
+func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.queryRow(ctx, q.getAccountStmt, getAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Name,
+		&i.Kind,
+		&i.Currency,
+		&i.OpeningBalance,
+	)
+	return i, err
+}
+
+const getAttachment = `-- name: GetAttachment :one
+SELECT id, created_at, transaction_id, filename, mime_type, size, sha256 FROM attachments WHERE id = ?1 AND transaction_id = ?2
+`
+
+type GetAttachmentParams struct {
+	ID            int64 `json:"id"`
+	TransactionID int64 `json:"transaction_id"`
+}
+
+// Retrieves an attachment of a transaction.
+func (q *Queries) GetAttachment(ctx context.Context, arg GetAttachmentParams) (Attachment, error) {
+	row := q.queryRow(ctx, q.getAttachmentStmt, getAttachment, arg.ID, arg.TransactionID)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.TransactionID,
+		&i.Filename,
+		&i.MimeType,
+		&i.Size,
+		&i.Sha256,
+	)
+	return i, err
+}
+
+const getBudget = `-- name: GetBudget :one
+SELECT id, created_at, category, period, amount, currency, rollover FROM budgets WHERE id = ?
+`
+
+// Retrieves a single budget by ID.
+func (q *Queries) GetBudget(ctx context.Context, id int64) (Budget, error) {
+	row := q.queryRow(ctx, q.getBudgetStmt, getBudget, id)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Category,
+		&i.Period,
+		&i.Amount,
+		&i.Currency,
+		&i.Rollover,
+	)
+	return i, err
+}
+
+const getCategory = `-- name: GetCategory :one
+SELECT id, created_at, name, parent_id, color FROM categories WHERE id = ?
+`
+
+// Retrieves a single category by ID.
+func (q *Queries) GetCategory(ctx context.Context, id int64) (Category, error) {
+	row := q.queryRow(ctx, q.getCategoryStmt, getCategory, id)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Name,
+		&i.ParentID,
+		&i.Color,
+	)
+	return i, err
+}
+
+const getEntry = `-- name: GetEntry :one
+SELECT id, created_at, line, parser, model, prompt_version, latency_ms, prompt_tokens, completion_tokens FROM entries WHERE id = ?
+`
+
+// Retrieves a single entry by ID.
+func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	row := q.queryRow(ctx, q.getEntryStmt, getEntry, id)
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Line,
+		&i.Parser,
+		&i.Model,
+		&i.PromptVersion,
+		&i.LatencyMs,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+	)
+	return i, err
+}
+
+const getExchangeRate = `-- name: GetExchangeRate :one
+SELECT rate FROM exchange_rates
+WHERE base = ?1 AND quote = ?2 AND date <= ?3
+ORDER BY date DESC
+LIMIT 1
+`
+
+type GetExchangeRateParams struct {
+	Base  string    `json:"base"`
+	Quote string    `json:"quote"`
+	Date  time.Time `json:"date"`
+}
+
+// Retrieves the latest rate of a currency pair on or before a date.
+func (q *Queries) GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (float64, error) {
+	row := q.queryRow(ctx, q.getExchangeRateStmt, getExchangeRate, arg.Base, arg.Quote, arg.Date)
+	var rate float64
+	err := row.Scan(&rate)
+	return rate, err
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT key, created_at, request_hash, entry_id, response FROM idempotency_keys WHERE key = ?
+`
+
+// Retrieves an idempotency key.
+func (q *Queries) GetIdempotencyKey(ctx context.Context, key string) (IdempotencyKey, error) {
+	row := q.queryRow(ctx, q.getIdempotencyKeyStmt, getIdempotencyKey, key)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Key,
+		&i.CreatedAt,
+		&i.RequestHash,
+		&i.EntryID,
+		&i.Response,
+	)
+	return i, err
+}
+
+const getRecurringRule = `-- name: GetRecurringRule :one
+SELECT id, created_at, description, category, type, amount, currency, account_id, rrule, start_date, next_due, occurrences FROM recurring_rules WHERE id = ?
+`
+
+// Retrieves a single recurring rule by ID.
+func (q *Queries) GetRecurringRule(ctx context.Context, id int64) (RecurringRule, error) {
+	row := q.queryRow(ctx, q.getRecurringRuleStmt, getRecurringRule, id)
+	var i RecurringRule
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Description,
+		&i.Category,
+		&i.Type,
+		&i.Amount,
+		&i.Currency,
+		&i.AccountID,
+		&i.Rrule,
+		&i.StartDate,
+		&i.NextDue,
+		&i.Occurrences,
+	)
+	return i, err
+}
+
+const getRule = `-- name: GetRule :one
+SELECT id, created_at, name, priority, description_pattern, min_amount, max_amount, account_id, currency, set_category, add_tags, set_account_id, confirm FROM rules WHERE id = ?
+`
+
+// Retrieves a single rule by ID.
+func (q *Queries) GetRule(ctx context.Context, id int64) (Rule, error) {
+	row := q.queryRow(ctx, q.getRuleStmt, getRule, id)
+	var i Rule
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Name,
+		&i.Priority,
+		&i.DescriptionPattern,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.AccountID,
+		&i.Currency,
+		&i.SetCategory,
+		&i.AddTags,
+		&i.SetAccountID,
+		&i.Confirm,
+	)
+	return i, err
+}
+
+const getTransaction = `-- name: GetTransaction :one
+SELECT id, created_at, transaction_date, currency, amount, category, description, confirm, needs_reparse, entry_id, account_id, type, transfer_account_id, recurring_rule_id, version, duplicate_of FROM transactions WHERE id = ?
+`
+
+// Retrieves a single transaction by ID.
+func (q *Queries) GetTransaction(ctx context.Context, id int64) (Transaction, error) {
+	row := q.queryRow(ctx, q.getTransactionStmt, getTransaction, id)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.TransactionDate,
+		&i.Currency,
+		&i.Amount,
+		&i.Category,
+		&i.Description,
+		&i.Confirm,
+		&i.NeedsReparse,
+		&i.EntryID,
+		&i.AccountID,
+		&i.Type,
+		&i.TransferAccountID,
+		&i.RecurringRuleID,
+		&i.Version,
+		&i.DuplicateOf,
+	)
+	return i, err
+}
+
+const listAccounts = `-- name: ListAccounts :many
+SELECT id, created_at, name, kind, currency, opening_balance FROM accounts ORDER BY name
+`
+
+// Retrieves all the accounts.
+func (q *Queries) ListAccounts(ctx context.Context) ([]Account, error) {
+	rows, err := q.query(ctx, q.listAccountsStmt, listAccounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Name,
+			&i.Kind,
+			&i.Currency,
+			&i.OpeningBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAttachmentsByTransaction = `-- name: ListAttachmentsByTransaction :many
+SELECT id, created_at, transaction_id, filename, mime_type, size, sha256 FROM attachments WHERE transaction_id = ? ORDER BY id
+`
+
+// Retrieves the attachments of a transaction.
+func (q *Queries) ListAttachmentsByTransaction(ctx context.Context, transactionID int64) ([]Attachment, error) {
+	rows, err := q.query(ctx, q.listAttachmentsByTransactionStmt, listAttachmentsByTransaction, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Attachment{}
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.TransactionID,
+			&i.Filename,
+			&i.MimeType,
+			&i.Size,
+			&i.Sha256,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBudgets = `-- name: ListBudgets :many
+SELECT id, created_at, category, period, amount, currency, rollover FROM budgets ORDER BY category, period
+`
+
+// Retrieves all the budgets.
+func (q *Queries) ListBudgets(ctx context.Context) ([]Budget, error) {
+	rows, err := q.query(ctx, q.listBudgetsStmt, listBudgets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Budget{}
+	for rows.Next() {
+		var i Budget
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Category,
+			&i.Period,
+			&i.Amount,
+			&i.Currency,
+			&i.Rollover,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategories = `-- name: ListCategories :many
+SELECT id, created_at, name, parent_id, color FROM categories ORDER BY name
+`
+
+// Retrieves all the categories.
+func (q *Queries) ListCategories(ctx context.Context) ([]Category, error) {
+	rows, err := q.query(ctx, q.listCategoriesStmt, listCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Category{}
+	for rows.Next() {
+		var i Category
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Name,
+			&i.ParentID,
+			&i.Color,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoryAliases = `-- name: ListCategoryAliases :many
+SELECT alias, category_id FROM category_aliases ORDER BY alias
+`
+
+// Retrieves the aliases of all the categories.
+func (q *Queries) ListCategoryAliases(ctx context.Context) ([]CategoryAlias, error) {
+	rows, err := q.query(ctx, q.listCategoryAliasesStmt, listCategoryAliases)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CategoryAlias{}
+	for rows.Next() {
+		var i CategoryAlias
+		if err := rows.Scan(
+			&i.Alias,
+			&i.CategoryID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueRecurringRules = `-- name: ListDueRecurringRules :many
+SELECT id, created_at, description, category, type, amount, currency, account_id, rrule, start_date, next_due, occurrences FROM recurring_rules WHERE next_due IS NOT NULL AND next_due <= ? ORDER BY next_due, id
+`
+
+// Retrieves the recurring rules which are due on or before a date.
+func (q *Queries) ListDueRecurringRules(ctx context.Context, nextDue *time.Time) ([]RecurringRule, error) {
+	rows, err := q.query(ctx, q.listDueRecurringRulesStmt, listDueRecurringRules, nextDue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RecurringRule{}
+	for rows.Next() {
+		var i RecurringRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Description,
+			&i.Category,
+			&i.Type,
+			&i.Amount,
+			&i.Currency,
+			&i.AccountID,
+			&i.Rrule,
+			&i.StartDate,
+			&i.NextDue,
+			&i.Occurrences,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDuplicateCandidates = `-- name: ListDuplicateCandidates :many
+SELECT id, created_at, transaction_date, currency, amount, category, description, confirm, needs_reparse, entry_id, account_id, type, transfer_account_id, recurring_rule_id, version, duplicate_of FROM transactions
+WHERE amount = ?1 AND currency = ?2 AND type = ?3
+  AND transaction_date >= ?4 AND transaction_date < ?5
+  AND (entry_id IS NULL OR entry_id <> ?6)
+  AND duplicate_of IS NULL
+  AND created_at >= ?7
+ORDER BY id
+`
+
+type ListDuplicateCandidatesParams struct {
+	Amount       int64     `json:"amount"`
+	Currency     string    `json:"currency"`
+	Type         string    `json:"type"`
+	DayStart     time.Time `json:"day_start"`
+	DayEnd       time.Time `json:"day_end"`
+	EntryID      *int64    `json:"entry_id"`
+	CreatedAfter time.Time `json:"created_after"`
+}
+
+// Retrieves the transactions of other entries which a new transaction could be a duplicate of:
+// the ones on the same day with the same amount, which were created after the given time.
+func (q *Queries) ListDuplicateCandidates(ctx context.Context, arg ListDuplicateCandidatesParams) ([]Transaction, error) {
+	rows, err := q.query(ctx, q.listDuplicateCandidatesStmt, listDuplicateCandidates,
+		arg.Amount,
+		arg.Currency,
+		arg.Type,
+		arg.DayStart,
+		arg.DayEnd,
+		arg.EntryID,
+		arg.CreatedAfter,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transaction{}
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.TransactionDate,
+			&i.Currency,
+			&i.Amount,
+			&i.Category,
+			&i.Description,
+			&i.Confirm,
+			&i.NeedsReparse,
+			&i.EntryID,
+			&i.AccountID,
+			&i.Type,
+			&i.TransferAccountID,
+			&i.RecurringRuleID,
+			&i.Version,
+			&i.DuplicateOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDuplicateTransactions = `-- name: ListDuplicateTransactions :many
+SELECT id, created_at, transaction_date, currency, amount, category, description, confirm, needs_reparse, entry_id, account_id, type, transfer_account_id, recurring_rule_id, version, duplicate_of FROM transactions WHERE duplicate_of IS NOT NULL ORDER BY transaction_date DESC, id DESC
+`
+
+// Retrieves the transactions which are flagged as duplicates.
+func (q *Queries) ListDuplicateTransactions(ctx context.Context) ([]Transaction, error) {
+	rows, err := q.query(ctx, q.listDuplicateTransactionsStmt, listDuplicateTransactions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transaction{}
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.TransactionDate,
+			&i.Currency,
+			&i.Amount,
+			&i.Category,
+			&i.Description,
+			&i.Confirm,
+			&i.NeedsReparse,
+			&i.EntryID,
+			&i.AccountID,
+			&i.Type,
+			&i.TransferAccountID,
+			&i.RecurringRuleID,
+			&i.Version,
+			&i.DuplicateOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExchangeRateBases = `-- name: ListExchangeRateBases :many
+SELECT DISTINCT base FROM exchange_rates ORDER BY base
+`
+
+// Lists the base currencies which have rates. These are used to convert
+// between two currencies which don't have a direct rate.
+func (q *Queries) ListExchangeRateBases(ctx context.Context) ([]string, error) {
+	rows, err := q.query(ctx, q.listExchangeRateBasesStmt, listExchangeRateBases)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var base string
+		if err := rows.Scan(&base); err != nil {
+			return nil, err
+		}
+		items = append(items, base)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecurringRules = `-- name: ListRecurringRules :many
+SELECT id, created_at, description, category, type, amount, currency, account_id, rrule, start_date, next_due, occurrences FROM recurring_rules ORDER BY id
+`
+
+// Retrieves all the recurring rules.
+func (q *Queries) ListRecurringRules(ctx context.Context) ([]RecurringRule, error) {
+	rows, err := q.query(ctx, q.listRecurringRulesStmt, listRecurringRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RecurringRule{}
+	for rows.Next() {
+		var i RecurringRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Description,
+			&i.Category,
+			&i.Type,
+			&i.Amount,
+			&i.Currency,
+			&i.AccountID,
+			&i.Rrule,
+			&i.StartDate,
+			&i.NextDue,
+			&i.Occurrences,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRules = `-- name: ListRules :many
+SELECT id, created_at, name, priority, description_pattern, min_amount, max_amount, account_id, currency, set_category, add_tags, set_account_id, confirm FROM rules ORDER BY priority, id
+`
+
+// Retrieves all the rules in the order they're applied.
+func (q *Queries) ListRules(ctx context.Context) ([]Rule, error) {
+	rows, err := q.query(ctx, q.listRulesStmt, listRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Rule{}
+	for rows.Next() {
+		var i Rule
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Name,
+			&i.Priority,
+			&i.DescriptionPattern,
+			&i.MinAmount,
+			&i.MaxAmount,
+			&i.AccountID,
+			&i.Currency,
+			&i.SetCategory,
+			&i.AddTags,
+			&i.SetAccountID,
+			&i.Confirm,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT tg.id, tg.name, CAST(COUNT(tt.transaction_id) AS INTEGER) AS transactions
+FROM tags tg
+LEFT JOIN transaction_tags tt ON tt.tag_id = tg.id
+GROUP BY tg.id
+ORDER BY tg.name
+`
+
+type ListTagsRow struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Transactions int64  `json:"transactions"`
+}
+
+// Retrieves all the tags along with the number of transactions which have them.
+func (q *Queries) ListTags(ctx context.Context) ([]ListTagsRow, error) {
+	rows, err := q.query(ctx, q.listTagsStmt, listTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTagsRow{}
+	for rows.Next() {
+		var i ListTagsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Transactions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTagsByTransaction = `-- name: ListTagsByTransaction :many
+SELECT tg.name
+FROM tags tg
+JOIN transaction_tags tt ON tt.tag_id = tg.id
+WHERE tt.transaction_id = ?
+ORDER BY tg.name
+`
+
+// Retrieves the names of the tags of a transaction.
+func (q *Queries) ListTagsByTransaction(ctx context.Context, transactionID int64) ([]string, error) {
+	rows, err := q.query(ctx, q.listTagsByTransactionStmt, listTagsByTransaction, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactionTags = `-- name: ListTransactionTags :many
+SELECT tt.transaction_id, tg.name
+FROM transaction_tags tt
+JOIN tags tg ON tg.id = tt.tag_id
+WHERE (?1 IS NULL OR tt.transaction_id IN (SELECT value FROM json_each(?1)))
+ORDER BY tg.name
+`
+
+type ListTransactionTagsRow struct {
+	TransactionID int64  `json:"transaction_id"`
+	Name          string `json:"name"`
+}
+
+// Retrieves the tags of the transactions, optionally only of the ones whose IDs are in
+// the JSON array ids.
+func (q *Queries) ListTransactionTags(ctx context.Context, ids interface{}) ([]ListTransactionTagsRow, error) {
+	rows, err := q.query(ctx, q.listTransactionTagsStmt, listTransactionTags, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTransactionTagsRow{}
+	for rows.Next() {
+		var i ListTransactionTagsRow
+		if err := rows.Scan(
+			&i.TransactionID,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactions = `-- name: ListTransactions :many
+SELECT id, created_at, transaction_date, currency, amount, category, description, confirm, needs_reparse, entry_id, account_id, type, transfer_account_id, recurring_rule_id, version, duplicate_of
+FROM transactions
+WHERE (?1 IS NULL OR confirm = ?1)
+  AND (?2 IS NULL OR transaction_date >= ?2)
+  AND (?3 IS NULL OR transaction_date <= ?3)
+  AND (?4 IS NULL OR needs_reparse = ?4)
+  AND (?5 IS NULL OR account_id = ?5 OR transfer_account_id = ?5)
+  AND (?6 IS NULL OR type = ?6)
+  AND (?7 IS NULL OR id IN (SELECT tt.transaction_id FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tg.name = ?7))
+  AND (?8 IS NULL OR lower(category) IN (SELECT lower(value) FROM json_each(?8)))
+  AND (?9 IS NULL OR currency = ?9)
+  AND (?10 IS NULL OR amount >= COALESCE(json_extract(?10, '$."' || currency || '"'), json_extract(?10, '$."*"')))
+  AND (?11 IS NULL OR amount <= COALESCE(json_extract(?11, '$."' || currency || '"'), json_extract(?11, '$."*"')))
+  AND (?12 IS NULL OR description LIKE '%' || ?12 || '%' ESCAPE '\')
+  AND (?13 IS NULL OR CASE WHEN COALESCE(?14, '-date') LIKE '-%'
+    THEN (CASE WHEN ?14 IN ('amount', '-amount') THEN amount WHEN ?14 IN ('category', '-category') THEN lower(category) ELSE transaction_date END, id) < (?15, ?13)
+    ELSE (CASE WHEN ?14 IN ('amount', '-amount') THEN amount WHEN ?14 IN ('category', '-category') THEN lower(category) ELSE transaction_date END, id) > (?15, ?13)
+  END)
+ORDER BY
+  CASE WHEN ?14 = 'date' THEN transaction_date END,
+  CASE WHEN COALESCE(?14, '-date') = '-date' THEN transaction_date END DESC,
+  CASE WHEN ?14 = 'amount' THEN amount END,
+  CASE WHEN ?14 = '-amount' THEN amount END DESC,
+  CASE WHEN ?14 = 'category' THEN lower(category) END,
+  CASE WHEN ?14 = '-category' THEN lower(category) END DESC,
+  CASE WHEN COALESCE(?14, '-date') LIKE '-%' THEN id END DESC,
+  id
+LIMIT COALESCE(?16, -1)
+`
+
+type ListTransactionsParams struct {
+	Confirm      interface{} `json:"confirm"`
+	StartDate    interface{} `json:"start_date"`
+	EndDate      interface{} `json:"end_date"`
+	NeedsReparse interface{} `json:"needs_reparse"`
+	AccountID    interface{} `json:"account_id"`
+	Type         interface{} `json:"type"`
+	Tag          interface{} `json:"tag"`
+	Categories   interface{} `json:"categories"`
+	Currency     interface{} `json:"currency"`
+	MinAmount    interface{} `json:"min_amount"`
+	MaxAmount    interface{} `json:"max_amount"`
+	Description  interface{} `json:"description"`
+	CursorID     interface{} `json:"cursor_id"`
+	Sort         interface{} `json:"sort"`
+	CursorValue  interface{} `json:"cursor_value"`
+	Limit        interface{} `json:"limit"`
+}
+
+// Retrieves transactions optionally filtered by confirmation status, date range, re-parse flag, account, type, tag,
+// categories, currency, amount range and description, sorted by date (the default, newest first), amount or category.
+// The categories are a JSON array. The amount bounds are JSON objects of the bound in minor units of each currency,
+// with "*" for the currencies which aren't listed. A page starts after the cursor, which is the sort value and ID of
+// the last transaction of the previous page.
+func (q *Queries) ListTransactions(ctx context.Context, arg ListTransactionsParams) ([]Transaction, error) {
+	rows, err := q.query(ctx, q.listTransactionsStmt, listTransactions,
+		arg.Confirm,
+		arg.StartDate,
+		arg.EndDate,
+		arg.NeedsReparse,
+		arg.AccountID,
+		arg.Type,
+		arg.Tag,
+		arg.Categories,
+		arg.Currency,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Description,
+		arg.CursorID,
+		arg.Sort,
+		arg.CursorValue,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transaction{}
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.TransactionDate,
+			&i.Currency,
+			&i.Amount,
+			&i.Category,
+			&i.Description,
+			&i.Confirm,
+			&i.NeedsReparse,
+			&i.EntryID,
+			&i.AccountID,
+			&i.Type,
+			&i.TransferAccountID,
+			&i.RecurringRuleID,
+			&i.Version,
+			&i.DuplicateOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactionsByEntry = `-- name: ListTransactionsByEntry :many
+SELECT id, created_at, transaction_date, currency, amount, category, description, confirm, needs_reparse, entry_id, account_id, type, transfer_account_id, recurring_rule_id, version, duplicate_of FROM transactions WHERE entry_id = ? ORDER BY id
+`
+
+// Retrieves the transactions parsed from an entry.
+func (q *Queries) ListTransactionsByEntry(ctx context.Context, entryID *int64) ([]Transaction, error) {
+	rows, err := q.query(ctx, q.listTransactionsByEntryStmt, listTransactionsByEntry, entryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transaction{}
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.TransactionDate,
+			&i.Currency,
+			&i.Amount,
+			&i.Category,
+			&i.Description,
+			&i.Confirm,
+			&i.NeedsReparse,
+			&i.EntryID,
+			&i.AccountID,
+			&i.Type,
+			&i.TransferAccountID,
+			&i.RecurringRuleID,
+			&i.Version,
+			&i.DuplicateOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const monthlySpendingSummary = `-- name: MonthlySpendingSummary :many
+SELECT
+    strftime('%Y', transaction_date) AS year,
+    strftime('%m', transaction_date) AS month,
+    category,
+    currency,
+    CAST(COALESCE(SUM(amount), 0) AS INTEGER) AS total_spent
+FROM transactions
+WHERE type = 'expense'
+GROUP BY year, month, category, currency
+ORDER BY year DESC, month DESC, total_spent DESC
+`
+
+type MonthlySpendingSummaryRow struct {
+	Year       interface{} `json:"year"`
+	Month      interface{} `json:"month"`
+	Category   string      `json:"category"`
+	Currency   string      `json:"currency"`
+	TotalSpent int64       `json:"total_spent"`
+}
+
+// TODO: This is not live yet.
+// Returns the total spending grouped by month and category.
+func (q *Queries) MonthlySpendingSummary(ctx context.Context) ([]MonthlySpendingSummaryRow, error) {
+	rows, err := q.query(ctx, q.monthlySpendingSummaryStmt, monthlySpendingSummary)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []MonthlySpendingSummaryRow{}
+	for rows.Next() {
+		var i MonthlySpendingSummaryRow
+		if err := rows.Scan(
+			&i.Year,
+			&i.Month,
+			&i.Category,
+			&i.Currency,
+			&i.TotalSpent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveAttachments = `-- name: MoveAttachments :exec
+UPDATE attachments SET transaction_id = ?1
+WHERE transaction_id = ?2
+  AND sha256 NOT IN (SELECT sha256 FROM attachments WHERE transaction_id = ?1)
+`
+
+type MoveAttachmentsParams struct {
+	OriginalID  int64 `json:"original_id"`
+	DuplicateID int64 `json:"duplicate_id"`
+}
+
+// Moves the attachments of a duplicate transaction to its original, except the ones
+// whose content the original already has.
+func (q *Queries) MoveAttachments(ctx context.Context, arg MoveAttachmentsParams) error {
+	_, err := q.exec(ctx, q.moveAttachmentsStmt, moveAttachments, arg.OriginalID, arg.DuplicateID)
+	return err
+}
+
+const releaseIdempotencyKey = `-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE key = ?1 AND entry_id IS NULL AND created_at < ?2
+`
+
+type ReleaseIdempotencyKeyParams struct {
+	Key    string    `json:"key"`
+	Before time.Time `json:"before"`
+}
+
+// Deletes an idempotency key whose request hasn't saved anything, and was claimed before the given time.
+func (q *Queries) ReleaseIdempotencyKey(ctx context.Context, arg ReleaseIdempotencyKeyParams) error {
+	_, err := q.exec(ctx, q.releaseIdempotencyKeyStmt, releaseIdempotencyKey, arg.Key, arg.Before)
+	return err
+}
+
+const removeTransactionTag = `-- name: RemoveTransactionTag :exec
+DELETE FROM transaction_tags
+WHERE transaction_id = ?1 AND tag_id IN (SELECT id FROM tags WHERE name = ?2)
+`
+
+type RemoveTransactionTagParams struct {
+	TransactionID int64  `json:"transaction_id"`
+	Tag           string `json:"tag"`
+}
+
+// Removes a tag from a transaction.
+func (q *Queries) RemoveTransactionTag(ctx context.Context, arg RemoveTransactionTagParams) error {
+	_, err := q.exec(ctx, q.removeTransactionTagStmt, removeTransactionTag, arg.TransactionID, arg.Tag)
+	return err
+}
+
+const renameBudgetsCategory = `-- name: RenameBudgetsCategory :exec
+UPDATE budgets SET category = ?1 WHERE category = ?2
+`
+
+type RenameBudgetsCategoryParams struct {
+	NewName string `json:"new_name"`
+	OldName string `json:"old_name"`
+}
+
+// Moves the budgets of a category to its new name.
+func (q *Queries) RenameBudgetsCategory(ctx context.Context, arg RenameBudgetsCategoryParams) error {
+	_, err := q.exec(ctx, q.renameBudgetsCategoryStmt, renameBudgetsCategory, arg.NewName, arg.OldName)
+	return err
+}
+
+const renameCorrectionsCategory = `-- name: RenameCorrectionsCategory :exec
+UPDATE category_corrections SET category = ?1 WHERE category = ?2 COLLATE NOCASE
+`
+
+type RenameCorrectionsCategoryParams struct {
+	NewName string `json:"new_name"`
+	OldName string `json:"old_name"`
+}
+
+// Moves the category corrections of a category to its new name.
+func (q *Queries) RenameCorrectionsCategory(ctx context.Context, arg RenameCorrectionsCategoryParams) error {
+	_, err := q.exec(ctx, q.renameCorrectionsCategoryStmt, renameCorrectionsCategory, arg.NewName, arg.OldName)
+	return err
+}
+
+const renameRecurringRulesCategory = `-- name: RenameRecurringRulesCategory :exec
+UPDATE recurring_rules SET category = ?1 WHERE category = ?2 COLLATE NOCASE
+`
+
+type RenameRecurringRulesCategoryParams struct {
+	NewName string `json:"new_name"`
+	OldName string `json:"old_name"`
+}
+
+// Moves the recurring rules of a category to its new name.
+func (q *Queries) RenameRecurringRulesCategory(ctx context.Context, arg RenameRecurringRulesCategoryParams) error {
+	_, err := q.exec(ctx, q.renameRecurringRulesCategoryStmt, renameRecurringRulesCategory, arg.NewName, arg.OldName)
+	return err
+}
+
+const renameRulesCategory = `-- name: RenameRulesCategory :exec
+UPDATE rules SET set_category = ?1 WHERE set_category = ?2 COLLATE NOCASE
+`
+
+type RenameRulesCategoryParams struct {
+	NewName string `json:"new_name"`
+	OldName string `json:"old_name"`
+}
+
+// Moves the rules which set a category to its new name.
+func (q *Queries) RenameRulesCategory(ctx context.Context, arg RenameRulesCategoryParams) error {
+	_, err := q.exec(ctx, q.renameRulesCategoryStmt, renameRulesCategory, arg.NewName, arg.OldName)
+	return err
+}
+
+const renameTransactionsCategory = `-- name: RenameTransactionsCategory :exec
+UPDATE transactions SET category = ?1 WHERE category = ?2 COLLATE NOCASE
+`
+
+type RenameTransactionsCategoryParams struct {
+	NewName string `json:"new_name"`
+	OldName string `json:"old_name"`
+}
+
+// Moves the transactions of a category to its new name.
+func (q *Queries) RenameTransactionsCategory(ctx context.Context, arg RenameTransactionsCategoryParams) error {
+	_, err := q.exec(ctx, q.renameTransactionsCategoryStmt, renameTransactionsCategory, arg.NewName, arg.OldName)
+	return err
+}
+
+const searchTransactions = `-- name: SearchTransactions :many
+SELECT t.id, t.created_at, t.transaction_date, t.currency, t.amount, t.category, t.description, t.confirm, t.needs_reparse, t.entry_id, t.account_id, t.type, t.transfer_account_id, t.recurring_rule_id, t.version, t.duplicate_of,
+    CAST(snippet(transactions_fts, -1, char(2), char(3), '…', 12) AS TEXT) AS snippet,
+    CAST(-bm25(transactions_fts, 4.0, 2.0, 2.0, 1.0) AS REAL) AS score
+FROM transactions_fts f
+JOIN transactions t ON t.id = f.rowid
+WHERE transactions_fts MATCH ?1
+  AND (?2 IS NULL OR t.confirm = ?2)
+  AND (?3 IS NULL OR t.transaction_date >= ?3)
+  AND (?4 IS NULL OR t.transaction_date <= ?4)
+ORDER BY score DESC, t.transaction_date DESC
+LIMIT ?5
+`
+
+type SearchTransactionsParams struct {
+	Query     string      `json:"query"`
+	Confirm   interface{} `json:"confirm"`
+	StartDate interface{} `json:"start_date"`
+	EndDate   interface{} `json:"end_date"`
+	Limit     int64       `json:"limit"`
+}
+
+type SearchTransactionsRow struct {
+	ID                int64     `json:"id"`
+	CreatedAt         time.Time `json:"created_at"`
+	TransactionDate   time.Time `json:"transaction_date"`
+	Currency          string    `json:"currency"`
+	Amount            int64     `json:"amount"`
+	Category          string    `json:"category"`
+	Description       string    `json:"description"`
+	Confirm           bool      `json:"confirm"`
+	NeedsReparse      bool      `json:"needs_reparse"`
+	EntryID           *int64    `json:"entry_id"`
+	AccountID         *int64    `json:"account_id"`
+	Type              string    `json:"type"`
+	TransferAccountID *int64    `json:"transfer_account_id"`
+	RecurringRuleID   *int64    `json:"recurring_rule_id"`
+	Version           int64     `json:"version"`
+	DuplicateOf       *int64    `json:"duplicate_of"`
+	Snippet           string    `json:"snippet"`
+	Score             float64   `json:"score"`
 }
 
-// Inserts a new transaction into the database.
-func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) ([]Transaction, error) {
-	rows, err := q.query(ctx, q.createTransactionStmt, createTransaction,
-		arg.CreatedAt,
-		arg.TransactionDate,
-		arg.Amount,
-		arg.Currency,
-		arg.Category,
-		arg.Description,
+// Retrieves the transactions which match the full text query, optionally filtered by confirmation status and
+// date range, the most relevant first. The snippet is the matching text, with the matches between the
+// characters 0x02 and 0x03. A description match counts the most and a match in the line the least.
+func (q *Queries) SearchTransactions(ctx context.Context, arg SearchTransactionsParams) ([]SearchTransactionsRow, error) {
+	rows, err := q.query(ctx, q.searchTransactionsStmt, searchTransactions,
+		arg.Query,
 		arg.Confirm,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Limit,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Transaction{}
+	items := []SearchTransactionsRow{}
 	for rows.Next() {
-		var i Transaction
+		var i SearchTransactionsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
@@ -54,6 +2013,16 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 			&i.Category,
 			&i.Description,
 			&i.Confirm,
+			&i.NeedsReparse,
+			&i.EntryID,
+			&i.AccountID,
+			&i.Type,
+			&i.TransferAccountID,
+			&i.RecurringRuleID,
+			&i.Version,
+			&i.DuplicateOf,
+			&i.Snippet,
+			&i.Score,
 		); err != nil {
 			return nil, err
 		}
@@ -68,118 +2037,84 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 	return items, nil
 }
 
-const dailySpending = `-- name: DailySpending :many
-
-
-SELECT
-    transaction_date,
-    SUM(amount) AS total_spent
-FROM transactions
-WHERE transaction_date BETWEEN ?1 AND ?2 AND confirm = 1
-GROUP BY transaction_date
-ORDER BY transaction_date ASC
+const setIdempotencyKeyEntry = `-- name: SetIdempotencyKeyEntry :exec
+UPDATE idempotency_keys SET entry_id = ? WHERE key = ?
 `
 
-type DailySpendingParams struct {
-	StartDate time.Time `json:"startDate"`
-	EndDate   time.Time `json:"endDate"`
-}
-
-type DailySpendingRow struct {
-	TransactionDate time.Time       `json:"transaction_date"`
-	TotalSpent      sql.NullFloat64 `json:"total_spent"`
+type SetIdempotencyKeyEntryParams struct {
+	EntryID *int64 `json:"entry_id"`
+	Key     string `json:"key"`
 }
 
-// Can be adjusted to show more or fewer categories
-// Retrieves the sum total of all transactions for each day within a specified date range.
-func (q *Queries) DailySpending(ctx context.Context, arg DailySpendingParams) ([]DailySpendingRow, error) {
-	rows, err := q.query(ctx, q.dailySpendingStmt, dailySpending, arg.StartDate, arg.EndDate)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []DailySpendingRow{}
-	for rows.Next() {
-		var i DailySpendingRow
-		if err := rows.Scan(&i.TransactionDate, &i.TotalSpent); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+// Sets the entry which the request of an idempotency key saved.
+func (q *Queries) SetIdempotencyKeyEntry(ctx context.Context, arg SetIdempotencyKeyEntryParams) error {
+	_, err := q.exec(ctx, q.setIdempotencyKeyEntryStmt, setIdempotencyKeyEntry, arg.EntryID, arg.Key)
+	return err
 }
 
-const deleteTransaction = `-- name: DeleteTransaction :exec
-DELETE FROM transactions WHERE id = ?
+const setIdempotencyKeyResponse = `-- name: SetIdempotencyKeyResponse :exec
+UPDATE idempotency_keys SET response = ? WHERE key = ?
 `
 
-// Deletes a transaction by ID.
-func (q *Queries) DeleteTransaction(ctx context.Context, id int64) error {
-	_, err := q.exec(ctx, q.deleteTransactionStmt, deleteTransaction, id)
+type SetIdempotencyKeyResponseParams struct {
+	Response string `json:"response"`
+	Key      string `json:"key"`
+}
+
+// Sets the response of the request of an idempotency key.
+func (q *Queries) SetIdempotencyKeyResponse(ctx context.Context, arg SetIdempotencyKeyResponseParams) error {
+	_, err := q.exec(ctx, q.setIdempotencyKeyResponseStmt, setIdempotencyKeyResponse, arg.Response, arg.Key)
 	return err
 }
 
-const getTransaction = `-- name: GetTransaction :one
-SELECT id, created_at, transaction_date, currency, amount, category, description, confirm FROM transactions WHERE id = ?
+const setTransactionDuplicate = `-- name: SetTransactionDuplicate :exec
+UPDATE transactions SET duplicate_of = ? WHERE id = ?
 `
 
-// Retrieves a single transaction by ID.
-func (q *Queries) GetTransaction(ctx context.Context, id int64) (Transaction, error) {
-	row := q.queryRow(ctx, q.getTransactionStmt, getTransaction, id)
-	var i Transaction
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.TransactionDate,
-		&i.Currency,
-		&i.Amount,
-		&i.Category,
-		&i.Description,
-		&i.Confirm,
-	)
-	return i, err
+type SetTransactionDuplicateParams struct {
+	DuplicateOf *int64 `json:"duplicate_of"`
+	ID          int64  `json:"id"`
 }
 
-const listTransactions = `-- name: ListTransactions :many
-SELECT id, created_at, transaction_date, currency, amount, category, description, confirm
-FROM transactions
-WHERE (?1 IS NULL OR confirm = ?1)
-  AND (?2 IS NULL OR transaction_date >= ?2)
-  AND (?3 IS NULL OR transaction_date <= ?3)
-ORDER BY transaction_date DESC, created_at DESC
+// Flags a transaction as a duplicate of another, or clears the flag.
+func (q *Queries) SetTransactionDuplicate(ctx context.Context, arg SetTransactionDuplicateParams) error {
+	_, err := q.exec(ctx, q.setTransactionDuplicateStmt, setTransactionDuplicate, arg.DuplicateOf, arg.ID)
+	return err
+}
+
+const similarCategoryCorrections = `-- name: SimilarCategoryCorrections :many
+SELECT c.description, c.category
+FROM category_corrections_fts f
+JOIN category_corrections c ON c.id = f.rowid
+WHERE category_corrections_fts MATCH ?1
+ORDER BY bm25(category_corrections_fts), c.created_at DESC
+LIMIT ?2
 `
 
-type ListTransactionsParams struct {
-	Confirm   interface{} `json:"confirm"`
-	StartDate interface{} `json:"start_date"`
-	EndDate   interface{} `json:"end_date"`
+type SimilarCategoryCorrectionsParams struct {
+	Query string `json:"query"`
+	Limit int64  `json:"limit"`
 }
 
-// Retrieves transactions optionally filtered by confirmation status and date range.
-func (q *Queries) ListTransactions(ctx context.Context, arg ListTransactionsParams) ([]Transaction, error) {
-	rows, err := q.query(ctx, q.listTransactionsStmt, listTransactions, arg.Confirm, arg.StartDate, arg.EndDate)
+type SimilarCategoryCorrectionsRow struct {
+	Description string `json:"description"`
+	Category    string `json:"category"`
+}
+
+// Retrieves the category corrections whose description is the most similar to the
+// full text query, the most similar first.
+func (q *Queries) SimilarCategoryCorrections(ctx context.Context, arg SimilarCategoryCorrectionsParams) ([]SimilarCategoryCorrectionsRow, error) {
+	rows, err := q.query(ctx, q.similarCategoryCorrectionsStmt, similarCategoryCorrections, arg.Query, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Transaction{}
+	items := []SimilarCategoryCorrectionsRow{}
 	for rows.Next() {
-		var i Transaction
+		var i SimilarCategoryCorrectionsRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.TransactionDate,
-			&i.Currency,
-			&i.Amount,
-			&i.Category,
 			&i.Description,
-			&i.Confirm,
+			&i.Category,
 		); err != nil {
 			return nil, err
 		}
@@ -194,39 +2129,52 @@ func (q *Queries) ListTransactions(ctx context.Context, arg ListTransactionsPara
 	return items, nil
 }
 
-const monthlySpendingSummary = `-- name: MonthlySpendingSummary :many
+const tagTotals = `-- name: TagTotals :many
 SELECT
-    strftime('%Y', transaction_date) AS year,
-    strftime('%m', transaction_date) AS month,
-    category,
-    SUM(amount) AS total_spent
-FROM transactions
-GROUP BY year, month, category
-ORDER BY year DESC, month DESC, total_spent DESC
+    tg.name AS tag,
+    t.currency,
+    t.transaction_date,
+    CAST(COUNT(*) AS INTEGER) AS transactions,
+    CAST(COALESCE(SUM(t.amount), 0) AS INTEGER) AS total_spent
+FROM transactions t
+JOIN transaction_tags tt ON tt.transaction_id = t.id
+JOIN tags tg ON tg.id = tt.tag_id
+WHERE t.transaction_date BETWEEN ?1 AND ?2 AND t.confirm = 1 AND t.type = 'expense'
+  AND (?3 IS NULL OR t.account_id = ?3)
+GROUP BY tg.name, t.currency, t.transaction_date
 `
 
-type MonthlySpendingSummaryRow struct {
-	Year       interface{}     `json:"year"`
-	Month      interface{}     `json:"month"`
-	Category   string          `json:"category"`
-	TotalSpent sql.NullFloat64 `json:"total_spent"`
+type TagTotalsParams struct {
+	StartDate time.Time   `json:"startDate"`
+	EndDate   time.Time   `json:"endDate"`
+	AccountID interface{} `json:"account_id"`
 }
 
-// TODO: This is not live yet.
-// Returns the total spending grouped by month and category.
-func (q *Queries) MonthlySpendingSummary(ctx context.Context) ([]MonthlySpendingSummaryRow, error) {
-	rows, err := q.query(ctx, q.monthlySpendingSummaryStmt, monthlySpendingSummary)
+type TagTotalsRow struct {
+	Tag             string    `json:"tag"`
+	Currency        string    `json:"currency"`
+	TransactionDate time.Time `json:"transaction_date"`
+	Transactions    int64     `json:"transactions"`
+	TotalSpent      int64     `json:"total_spent"`
+}
+
+// Retrieves the total spent on expenses and the number of expenses per tag, currency
+// and day over a specified period, optionally for a single account. Amounts are in
+// minor units of the currency.
+func (q *Queries) TagTotals(ctx context.Context, arg TagTotalsParams) ([]TagTotalsRow, error) {
+	rows, err := q.query(ctx, q.tagTotalsStmt, tagTotals, arg.StartDate, arg.EndDate, arg.AccountID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []MonthlySpendingSummaryRow{}
+	items := []TagTotalsRow{}
 	for rows.Next() {
-		var i MonthlySpendingSummaryRow
+		var i TagTotalsRow
 		if err := rows.Scan(
-			&i.Year,
-			&i.Month,
-			&i.Category,
+			&i.Tag,
+			&i.Currency,
+			&i.TransactionDate,
+			&i.Transactions,
 			&i.TotalSpent,
 		); err != nil {
 			return nil, err
@@ -245,28 +2193,35 @@ func (q *Queries) MonthlySpendingSummary(ctx context.Context) ([]MonthlySpending
 const topExpenseCategories = `-- name: TopExpenseCategories :many
 SELECT
     category,
-    SUM(amount) AS total_spent
+    currency,
+    transaction_date,
+    CAST(COALESCE(SUM(amount), 0) AS INTEGER) AS total_spent
 FROM transactions
-WHERE transaction_date BETWEEN ?1 AND ?2 AND confirm = 1
-GROUP BY category
-ORDER BY total_spent DESC
-LIMIT 5
+WHERE transaction_date BETWEEN ?1 AND ?2 AND confirm = 1 AND type = 'expense'
+  AND (?3 IS NULL OR account_id = ?3)
+GROUP BY category, currency, transaction_date
 `
 
 type TopExpenseCategoriesParams struct {
-	StartDate time.Time `json:"startDate"`
-	EndDate   time.Time `json:"endDate"`
+	StartDate time.Time   `json:"startDate"`
+	EndDate   time.Time   `json:"endDate"`
+	AccountID interface{} `json:"account_id"`
 }
 
 type TopExpenseCategoriesRow struct {
-	Category   string          `json:"category"`
-	TotalSpent sql.NullFloat64 `json:"total_spent"`
+	Category        string    `json:"category"`
+	Currency        string    `json:"currency"`
+	TransactionDate time.Time `json:"transaction_date"`
+	TotalSpent      int64     `json:"total_spent"`
 }
 
-// Retrieves the top expense categories over a specified period.
-// Uses parameters: confirm, startDate, endDate to filter by transaction date range.
+// Retrieves the total spent on expenses per category, currency and day over a specified period.
+// Uses parameters: startDate, endDate to filter by transaction date range and
+// an optional account_id.
+// Amounts are in minor units of the currency. Totals are per day so that they
+// can be converted using the exchange rate of that day.
 func (q *Queries) TopExpenseCategories(ctx context.Context, arg TopExpenseCategoriesParams) ([]TopExpenseCategoriesRow, error) {
-	rows, err := q.query(ctx, q.topExpenseCategoriesStmt, topExpenseCategories, arg.StartDate, arg.EndDate)
+	rows, err := q.query(ctx, q.topExpenseCategoriesStmt, topExpenseCategories, arg.StartDate, arg.EndDate, arg.AccountID)
 	if err != nil {
 		return nil, err
 	}
@@ -274,7 +2229,12 @@ func (q *Queries) TopExpenseCategories(ctx context.Context, arg TopExpenseCatego
 	items := []TopExpenseCategoriesRow{}
 	for rows.Next() {
 		var i TopExpenseCategoriesRow
-		if err := rows.Scan(&i.Category, &i.TotalSpent); err != nil {
+		if err := rows.Scan(
+			&i.Category,
+			&i.Currency,
+			&i.TransactionDate,
+			&i.TotalSpent,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -288,32 +2248,331 @@ func (q *Queries) TopExpenseCategories(ctx context.Context, arg TopExpenseCatego
 	return items, nil
 }
 
-const updateTransaction = `-- name: UpdateTransaction :exec
-UPDATE transactions
-SET amount = ?, currency = ?, category = ?, description = ?, confirm = ?, transaction_date = ?
+const touchTransaction = `-- name: TouchTransaction :exec
+UPDATE transactions SET version = version + 1 WHERE id = ?
+`
+
+// Moves a transaction to the next version, when its tags change.
+func (q *Queries) TouchTransaction(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.touchTransactionStmt, touchTransaction, id)
+	return err
+}
+
+const updateAccount = `-- name: UpdateAccount :one
+UPDATE accounts
+SET name = ?, kind = ?, currency = ?, opening_balance = ?
+WHERE id = ?
+RETURNING id, created_at, name, kind, currency, opening_balance
+`
+
+type UpdateAccountParams struct {
+	Name           string `json:"name"`
+	Kind           string `json:"kind"`
+	Currency       string `json:"currency"`
+	OpeningBalance int64  `json:"opening_balance"`
+	ID             int64  `json:"id"`
+}
+
+// Updates an account by ID.
+func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	row := q.queryRow(ctx, q.updateAccountStmt, updateAccount,
+		arg.Name,
+		arg.Kind,
+		arg.Currency,
+		arg.OpeningBalance,
+		arg.ID,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Name,
+		&i.Kind,
+		&i.Currency,
+		&i.OpeningBalance,
+	)
+	return i, err
+}
+
+const updateBudget = `-- name: UpdateBudget :one
+UPDATE budgets
+SET category = ?, period = ?, amount = ?, currency = ?, rollover = ?
+WHERE id = ?
+RETURNING id, created_at, category, period, amount, currency, rollover
+`
+
+type UpdateBudgetParams struct {
+	Category string `json:"category"`
+	Period   string `json:"period"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Rollover bool   `json:"rollover"`
+	ID       int64  `json:"id"`
+}
+
+// Updates a budget by ID.
+func (q *Queries) UpdateBudget(ctx context.Context, arg UpdateBudgetParams) (Budget, error) {
+	row := q.queryRow(ctx, q.updateBudgetStmt, updateBudget,
+		arg.Category,
+		arg.Period,
+		arg.Amount,
+		arg.Currency,
+		arg.Rollover,
+		arg.ID,
+	)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Category,
+		&i.Period,
+		&i.Amount,
+		&i.Currency,
+		&i.Rollover,
+	)
+	return i, err
+}
+
+const updateCategory = `-- name: UpdateCategory :one
+UPDATE categories
+SET name = ?, parent_id = ?, color = ?
+WHERE id = ?
+RETURNING id, created_at, name, parent_id, color
+`
+
+type UpdateCategoryParams struct {
+	Name     string `json:"name"`
+	ParentID *int64 `json:"parent_id"`
+	Color    string `json:"color"`
+	ID       int64  `json:"id"`
+}
+
+// Updates a category by ID.
+func (q *Queries) UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (Category, error) {
+	row := q.queryRow(ctx, q.updateCategoryStmt, updateCategory,
+		arg.Name,
+		arg.ParentID,
+		arg.Color,
+		arg.ID,
+	)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Name,
+		&i.ParentID,
+		&i.Color,
+	)
+	return i, err
+}
+
+const updateRecurringRule = `-- name: UpdateRecurringRule :one
+UPDATE recurring_rules
+SET description = ?, category = ?, type = ?, amount = ?, currency = ?, account_id = ?, rrule = ?, start_date = ?, next_due = ?, occurrences = ?
+WHERE id = ?
+RETURNING id, created_at, description, category, type, amount, currency, account_id, rrule, start_date, next_due, occurrences
+`
+
+type UpdateRecurringRuleParams struct {
+	Description string     `json:"description"`
+	Category    string     `json:"category"`
+	Type        string     `json:"type"`
+	Amount      int64      `json:"amount"`
+	Currency    string     `json:"currency"`
+	AccountID   *int64     `json:"account_id"`
+	Rrule       string     `json:"rrule"`
+	StartDate   time.Time  `json:"start_date"`
+	NextDue     *time.Time `json:"next_due"`
+	Occurrences int64      `json:"occurrences"`
+	ID          int64      `json:"id"`
+}
+
+// Updates a recurring rule by ID.
+func (q *Queries) UpdateRecurringRule(ctx context.Context, arg UpdateRecurringRuleParams) (RecurringRule, error) {
+	row := q.queryRow(ctx, q.updateRecurringRuleStmt, updateRecurringRule,
+		arg.Description,
+		arg.Category,
+		arg.Type,
+		arg.Amount,
+		arg.Currency,
+		arg.AccountID,
+		arg.Rrule,
+		arg.StartDate,
+		arg.NextDue,
+		arg.Occurrences,
+		arg.ID,
+	)
+	var i RecurringRule
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Description,
+		&i.Category,
+		&i.Type,
+		&i.Amount,
+		&i.Currency,
+		&i.AccountID,
+		&i.Rrule,
+		&i.StartDate,
+		&i.NextDue,
+		&i.Occurrences,
+	)
+	return i, err
+}
+
+const updateRule = `-- name: UpdateRule :one
+UPDATE rules
+SET name = ?, priority = ?, description_pattern = ?, min_amount = ?, max_amount = ?, account_id = ?, currency = ?, set_category = ?, add_tags = ?, set_account_id = ?, confirm = ?
 WHERE id = ?
+RETURNING id, created_at, name, priority, description_pattern, min_amount, max_amount, account_id, currency, set_category, add_tags, set_account_id, confirm
+`
+
+type UpdateRuleParams struct {
+	Name               string `json:"name"`
+	Priority           int64  `json:"priority"`
+	DescriptionPattern string `json:"description_pattern"`
+	MinAmount          *int64 `json:"min_amount"`
+	MaxAmount          *int64 `json:"max_amount"`
+	AccountID          *int64 `json:"account_id"`
+	Currency           string `json:"currency"`
+	SetCategory        string `json:"set_category"`
+	AddTags            string `json:"add_tags"`
+	SetAccountID       *int64 `json:"set_account_id"`
+	Confirm            bool   `json:"confirm"`
+	ID                 int64  `json:"id"`
+}
+
+// Updates a rule by ID.
+func (q *Queries) UpdateRule(ctx context.Context, arg UpdateRuleParams) (Rule, error) {
+	row := q.queryRow(ctx, q.updateRuleStmt, updateRule,
+		arg.Name,
+		arg.Priority,
+		arg.DescriptionPattern,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.AccountID,
+		arg.Currency,
+		arg.SetCategory,
+		arg.AddTags,
+		arg.SetAccountID,
+		arg.Confirm,
+		arg.ID,
+	)
+	var i Rule
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Name,
+		&i.Priority,
+		&i.DescriptionPattern,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.AccountID,
+		&i.Currency,
+		&i.SetCategory,
+		&i.AddTags,
+		&i.SetAccountID,
+		&i.Confirm,
+	)
+	return i, err
+}
+
+const updateTransaction = `-- name: UpdateTransaction :execrows
+UPDATE transactions
+SET amount = ?, currency = ?, category = ?, description = ?, confirm = ?, transaction_date = ?, account_id = ?, type = ?, transfer_account_id = ?, version = version + 1
+WHERE id = ? AND version = ?
 `
 
 type UpdateTransactionParams struct {
-	Amount          float64   `json:"amount"`
-	Currency        string    `json:"currency"`
-	Category        string    `json:"category"`
-	Description     string    `json:"description"`
-	Confirm         bool      `json:"confirm"`
-	TransactionDate time.Time `json:"transaction_date"`
-	ID              int64     `json:"id"`
+	Amount            int64     `json:"amount"`
+	Currency          string    `json:"currency"`
+	Category          string    `json:"category"`
+	Description       string    `json:"description"`
+	Confirm           bool      `json:"confirm"`
+	TransactionDate   time.Time `json:"transaction_date"`
+	AccountID         *int64    `json:"account_id"`
+	Type              string    `json:"type"`
+	TransferAccountID *int64    `json:"transfer_account_id"`
+	ID                int64     `json:"id"`
+	Version           int64     `json:"version"`
 }
 
-// Updates a transaction by ID.
-func (q *Queries) UpdateTransaction(ctx context.Context, arg UpdateTransactionParams) error {
-	_, err := q.exec(ctx, q.updateTransactionStmt, updateTransaction,
+// Updates a transaction by ID if it's still at the given version, moving it to the next version.
+func (q *Queries) UpdateTransaction(ctx context.Context, arg UpdateTransactionParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateTransactionStmt, updateTransaction,
 		arg.Amount,
 		arg.Currency,
 		arg.Category,
 		arg.Description,
 		arg.Confirm,
 		arg.TransactionDate,
+		arg.AccountID,
+		arg.Type,
+		arg.TransferAccountID,
 		arg.ID,
+		arg.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertCategoryCorrection = `-- name: UpsertCategoryCorrection :exec
+INSERT INTO category_corrections (created_at, description, category)
+VALUES (?, ?, ?)
+ON CONFLICT (description) DO UPDATE SET category = excluded.category, created_at = excluded.created_at
+`
+
+type UpsertCategoryCorrectionParams struct {
+	CreatedAt   time.Time `json:"created_at"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+}
+
+// Saves the category which the user corrected a description to, replacing an older correction.
+func (q *Queries) UpsertCategoryCorrection(ctx context.Context, arg UpsertCategoryCorrectionParams) error {
+	_, err := q.exec(ctx, q.upsertCategoryCorrectionStmt, upsertCategoryCorrection, arg.CreatedAt, arg.Description, arg.Category)
+	return err
+}
+
+const upsertExchangeRate = `-- name: UpsertExchangeRate :exec
+INSERT INTO exchange_rates (date, base, quote, rate)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (date, base, quote) DO UPDATE SET rate = excluded.rate
+`
+
+type UpsertExchangeRateParams struct {
+	Date  time.Time `json:"date"`
+	Base  string    `json:"base"`
+	Quote string    `json:"quote"`
+	Rate  float64   `json:"rate"`
+}
+
+// Saves an exchange rate, replacing the existing rate for the same day and pair.
+func (q *Queries) UpsertExchangeRate(ctx context.Context, arg UpsertExchangeRateParams) error {
+	_, err := q.exec(ctx, q.upsertExchangeRateStmt, upsertExchangeRate,
+		arg.Date,
+		arg.Base,
+		arg.Quote,
+		arg.Rate,
 	)
 	return err
 }
+
+const upsertTag = `-- name: UpsertTag :one
+INSERT INTO tags (name) VALUES (?)
+ON CONFLICT (name) DO UPDATE SET name = tags.name
+RETURNING id, name
+`
+
+// Saves a tag, returning the existing tag of the same name.
+func (q *Queries) UpsertTag(ctx context.Context, name string) (Tag, error) {
+	row := q.queryRow(ctx, q.upsertTagStmt, upsertTag, name)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+	)
+	return i, err
+}
@@ -0,0 +1,236 @@
+// Package fx parses exchange rates and converts amounts between currencies.
+package fx
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// Rate is the price of one unit of Base in Quote on Date.
+type Rate struct {
+	Date  time.Time
+	Base  string
+	Quote string
+	Rate  float64
+}
+
+// ParseCSV reads rates from a CSV file with the header `date,base,quote,rate`.
+// Dates are in YYYY-MM-DD format.
+func ParseCSV(r io.Reader) ([]Rate, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading header: %w", err)
+	}
+
+	cols := map[string]int{}
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, c := range []string{"date", "base", "quote", "rate"} {
+		if _, ok := cols[c]; !ok {
+			return nil, fmt.Errorf("missing column %q, expected date,base,quote,rate", c)
+		}
+	}
+
+	var rates []Rate
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading line %d: %w", line, err)
+		}
+
+		rate, err := newRate(rec[cols["date"]], rec[cols["base"]], rec[cols["quote"]], rec[cols["rate"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// ecbEnvelope is the layout of the European Central Bank reference rates
+// (eurofxref-daily.xml, eurofxref-hist.xml). All rates are against EUR.
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECB reads rates from the European Central Bank's reference rates XML.
+func ParseECB(r io.Reader) ([]Rate, error) {
+	var env ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&env); err != nil {
+		return nil, fmt.Errorf("error decoding ECB XML: %w", err)
+	}
+
+	var rates []Rate
+	for _, d := range env.Days {
+		for _, c := range d.Rates {
+			rate, err := newRate(d.Time, "EUR", c.Currency, c.Rate)
+			if err != nil {
+				return nil, err
+			}
+			rates = append(rates, rate)
+		}
+	}
+
+	if len(rates) == 0 {
+		return nil, errors.New("no rates found in ECB XML")
+	}
+
+	return rates, nil
+}
+
+func newRate(date, base, quote, rate string) (Rate, error) {
+	d, err := time.Parse("2006-01-02", strings.TrimSpace(date))
+	if err != nil {
+		return Rate{}, fmt.Errorf("invalid date %q", date)
+	}
+
+	r, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+	if err != nil || r <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q", rate)
+	}
+
+	base, quote = strings.ToUpper(strings.TrimSpace(base)), strings.ToUpper(strings.TrimSpace(quote))
+	if !IsCurrency(base) || !IsCurrency(quote) {
+		return Rate{}, fmt.Errorf("invalid currency pair %q/%q", base, quote)
+	}
+
+	return Rate{Date: d, Base: base, Quote: quote, Rate: r}, nil
+}
+
+// IsCurrency reports whether s looks like an ISO 4217 currency code.
+func IsCurrency(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Lookup returns the latest rate of base in quote on or before date. ok is
+// false if there's no such rate.
+type Lookup func(ctx context.Context, base, quote string, date time.Time) (rate float64, ok bool, err error)
+
+// NoRateError is returned when an amount can't be converted for the lack of a rate.
+type NoRateError struct {
+	From, To string
+	Date     time.Time
+}
+
+func (e *NoRateError) Error() string {
+	return fmt.Sprintf("no exchange rate from %s to %s on or before %s", e.From, e.To, e.Date.Format("2006-01-02"))
+}
+
+type rateKey struct {
+	from, to string
+	date     time.Time
+}
+
+// Converter converts amounts between currencies using the rate on the date of
+// the amount. A pair is converted using a direct rate, the inverse rate, or
+// through one of the pivot currencies (eg: EUR for ECB rates). Rates are
+// cached, so a Converter is meant to be used for a single report.
+type Converter struct {
+	lookup Lookup
+	pivots []string
+	cache  map[rateKey]float64
+}
+
+func NewConverter(lookup Lookup, pivots []string) *Converter {
+	return &Converter{
+		lookup: lookup,
+		pivots: pivots,
+		cache:  map[rateKey]float64{},
+	}
+}
+
+// Convert converts an amount in minor units of from to minor units of to.
+func (c *Converter) Convert(ctx context.Context, minor int64, from, to string, date time.Time) (int64, error) {
+	if from == to || minor == 0 {
+		return minor, nil
+	}
+
+	rate, err := c.rate(ctx, from, to, date)
+	if err != nil {
+		return 0, err
+	}
+
+	scale := math.Pow10(models.CurrencyExponent(to) - models.CurrencyExponent(from))
+	return int64(math.Round(float64(minor) * rate * scale)), nil
+}
+
+func (c *Converter) rate(ctx context.Context, from, to string, date time.Time) (float64, error) {
+	key := rateKey{from: from, to: to, date: date}
+	if r, ok := c.cache[key]; ok {
+		return r, nil
+	}
+
+	r, ok, err := c.pair(ctx, from, to, date)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, p := range c.pivots {
+		if ok || p == from || p == to {
+			continue
+		}
+
+		var a, b float64
+		if a, ok, err = c.pair(ctx, from, p, date); err != nil {
+			return 0, err
+		} else if !ok {
+			continue
+		}
+		if b, ok, err = c.pair(ctx, p, to, date); err != nil {
+			return 0, err
+		}
+		r = a * b
+	}
+
+	if !ok {
+		return 0, &NoRateError{From: from, To: to, Date: date}
+	}
+
+	c.cache[key] = r
+	return r, nil
+}
+
+// pair finds the direct or the inverse rate of a pair.
+func (c *Converter) pair(ctx context.Context, from, to string, date time.Time) (float64, bool, error) {
+	if r, ok, err := c.lookup(ctx, from, to, date); err != nil || ok {
+		return r, ok, err
+	}
+
+	r, ok, err := c.lookup(ctx, to, from, date)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	return 1 / r, true, nil
+}
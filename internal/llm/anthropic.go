@@ -0,0 +1,121 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const (
+	defaultAnthropicURL = "https://api.anthropic.com"
+	anthropicVersion    = "2023-06-01"
+	anthropicMaxTokens  = 1024
+)
+
+// anthropic talks to the Anthropic Messages API (/v1/messages).
+type anthropic struct {
+	client  *http.Client
+	baseURL string
+	token   string
+	model   string
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	InputSchema jsonschema.Definition `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+}
+
+type anthropicContent struct {
+	Type  string          `json:"type"`
+	Text  string          `json:"text,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+type anthropicResponse struct {
+	Model   string             `json:"model"`
+	Content []anthropicContent `json:"content"`
+	Usage   struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func newAnthropic(cfg Config) *anthropic {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultAnthropicURL
+	}
+
+	return &anthropic{
+		client:  &http.Client{Timeout: cfg.Timeout},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   cfg.Token,
+		model:   cfg.Model,
+	}
+}
+
+func (a *anthropic) CallTool(ctx context.Context, req ToolRequest) (ToolResponse, error) {
+	body := anthropicRequest{
+		Model:     a.model,
+		MaxTokens: anthropicMaxTokens,
+		System:    req.System,
+		Messages:  []anthropicMessage{{Role: "user", Content: req.Prompt}},
+		Tools: []anthropicTool{{
+			Name:        req.Tool.Name,
+			Description: req.Tool.Description,
+			InputSchema: req.Tool.Parameters,
+		}},
+	}
+
+	headers := map[string]string{
+		"x-api-key":         a.token,
+		"anthropic-version": anthropicVersion,
+	}
+
+	var resp anthropicResponse
+	if err := postJSON(ctx, a.client, a.baseURL+"/v1/messages", headers, body, &resp); err != nil {
+		return ToolResponse{}, err
+	}
+
+	out := ToolResponse{
+		Model: resp.Model,
+		Usage: Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+		},
+	}
+
+	var text []string
+	for _, c := range resp.Content {
+		switch c.Type {
+		case "tool_use":
+			if c.Name == req.Tool.Name {
+				out.Arguments = c.Input
+				return out, nil
+			}
+		case "text":
+			text = append(text, c.Text)
+		}
+	}
+
+	out.Content = strings.Join(text, "\n")
+	return out, nil
+}
@@ -0,0 +1,314 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/gullak/pkg/models"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// Planner turns a question about the user's transactions into a query plan.
+type Planner interface {
+	Plan(ctx context.Context, question string, hints PlanHints) (models.QueryPlan, error)
+}
+
+// PlanHints are the names the user's transactions are filed under, for the planner
+// to pick the filters of a question from.
+type PlanHints struct {
+	Categories []string
+	Tags       []string
+	Accounts   []string
+}
+
+// NoPlanError is returned when the question isn't one about the transactions.
+type NoPlanError struct {
+	Message string
+}
+
+func (e *NoPlanError) Error() string {
+	return e.Message
+}
+
+// Plan turns the question into a query plan. If the provider fails and the offline
+// fallback is enabled, the question is planned by the offline parser instead. The plan
+// isn't validated, it's up to the caller to check it before running it.
+func (m *Manager) Plan(ctx context.Context, question string, hints PlanHints) (models.QueryPlan, error) {
+	if question == "" {
+		return models.QueryPlan{}, errors.New("empty question")
+	}
+
+	m.log.Debug("Planning question", "question", question, "provider", m.provider)
+	plan, err := m.parser.Plan(ctx, question, hints)
+	if err == nil {
+		return plan, nil
+	}
+
+	var noPlanErr *NoPlanError
+	if m.fallback == nil || errors.As(err, &noPlanErr) {
+		return models.QueryPlan{}, err
+	}
+
+	m.log.Warn("Error planning with the provider, using the offline parser", "provider", m.provider, "error", err)
+	return m.fallback.Plan(ctx, question, hints)
+}
+
+// planTool returns the tool offered to the model for planning a question. The filters
+// are restricted to the names in the hints, if any are given.
+func planTool(hints PlanHints) Tool {
+	list := func(names []string, desc string) jsonschema.Definition {
+		item := jsonschema.Definition{Type: jsonschema.String}
+		if len(names) > 0 {
+			item.Enum = names
+		}
+		return jsonschema.Definition{Type: jsonschema.Array, Description: desc, Items: &item}
+	}
+
+	return Tool{
+		Name:        "query_transactions",
+		Description: "Query the user's transactions to answer a question about them.",
+		Parameters: jsonschema.Definition{
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"metric": {
+					Type:        jsonschema.String,
+					Enum:        []string{models.MetricTotal, models.MetricCount, models.MetricAverage},
+					Description: "total for how much was spent or received, count for how many transactions, average for the average amount of a transaction",
+				},
+				"group_by": {
+					Type:        jsonschema.String,
+					Enum:        []string{"", models.GroupByCategory, models.GroupByTag, models.GroupByAccount, models.GroupByType, models.GroupByDay, models.GroupByWeek, models.GroupByMonth},
+					Description: "How to break the metric down if the question asks for a breakdown, a comparison or the top items (e.g., category for \"where did my money go\"), else empty",
+				},
+				"type": {
+					Type:        jsonschema.String,
+					Enum:        []string{models.TypeExpense, models.TypeIncome, models.TypeTransfer},
+					Description: "expense for money spent, income for money received, transfer for money moved between the user's own accounts",
+				},
+				"categories":  list(hints.Categories, "Categories the question is about, along with their subcategories, else empty"),
+				"tags":        list(hints.Tags, "Tags the question is about, else empty"),
+				"accounts":    list(hints.Accounts, "Accounts or payment methods the question is about, else empty"),
+				"description": {Type: jsonschema.String, Description: "A merchant or item the question is about which isn't a category (e.g., uber, netflix), else empty"},
+				"start_date":  {Type: jsonschema.String, Description: "First date of the period the question is about in ISO 8601 format (e.g., 2021-09-01), else empty for all time"},
+				"end_date":    {Type: jsonschema.String, Description: "Last date of the period the question is about in ISO 8601 format, else empty for all time"},
+				"limit":       {Type: jsonschema.Integer, Description: "Number of groups the question asks for (e.g., 3 for the top 3 categories), else 0"},
+			},
+			Required: []string{"metric", "type"},
+		},
+	}
+}
+
+const planPrompt = "You will be provided with a question by the user about their spends, income and transfers between accounts. Your task is to turn it into a query of their transactions. If the question isn't about the user's transactions then don't call the tool and say why. Today's date is %s, a %s."
+
+func (p *toolParser) Plan(ctx context.Context, question string, hints PlanHints) (models.QueryPlan, error) {
+	now := time.Now()
+	resp, err := p.provider.CallTool(ctx, ToolRequest{
+		System: fmt.Sprintf(planPrompt, now.Format("2006-01-02"), now.Weekday()),
+		Prompt: question,
+		Tool:   planTool(hints),
+	})
+	if err != nil {
+		p.log.Error("Completion error", "error", err)
+		return models.QueryPlan{}, fmt.Errorf("error completing the request")
+	}
+
+	if resp.Arguments != nil {
+		var plan models.QueryPlan
+		if err := json.Unmarshal(resp.Arguments, &plan); err != nil {
+			return models.QueryPlan{}, fmt.Errorf("error unmarshalling response: %s", err)
+		}
+		return plan, nil
+	}
+
+	if resp.Content != "" {
+		return models.QueryPlan{}, &NoPlanError{Message: resp.Content}
+	}
+
+	return models.QueryPlan{}, fmt.Errorf("no query found in response")
+}
+
+var (
+	reAskTotal   = regexp.MustCompile(`(?i)\b(?:how much|spent|spend|spending|spends|expenses?|cost|paid|pay|total|transferred)\b`)
+	reAskCount   = regexp.MustCompile(`(?i)\b(?:how many|number of|count)\b`)
+	reAskAverage = regexp.MustCompile(`(?i)\b(?:average|avg|typical|usually)\b`)
+	reAskIncome  = regexp.MustCompile(`(?i)\b(?:earn|earned|income|salary|received|receive|credited)\b`)
+	reAskGroupBy = regexp.MustCompile(`(?i)\b(?:by|per|each|every|which|top|breakdown of|split by)\s+(categor(?:y|ies)|tags?|accounts?|types?|days?|weeks?|months?)\b`)
+	reAskTop     = regexp.MustCompile(`(?i)\btop\s+(\d+)\b`)
+	reAskLastN   = regexp.MustCompile(`(?i)\b(?:last|past)\s+(\d+)\s+(days?|weeks?|months?)\b`)
+	reAskPeriod  = regexp.MustCompile(`(?i)\b(today|yesterday|this week|last week|this month|last month|this year|last year)\b`)
+	reAskMonth   = regexp.MustCompile(`(?i)\b(?:in|during|for|on)\s+(january|february|march|april|may|june|july|august|september|october|november|december)\b`)
+	reAskRange   = regexp.MustCompile(`\b(\d{4}-\d{2}-\d{2})\b(?:.*?\b(\d{4}-\d{2}-\d{2})\b)?`)
+)
+
+var months = map[string]time.Month{
+	"january": time.January, "february": time.February, "march": time.March, "april": time.April,
+	"may": time.May, "june": time.June, "july": time.July, "august": time.August,
+	"september": time.September, "october": time.October, "november": time.November, "december": time.December,
+}
+
+// Plan understands questions like "how much did I spend on food last month?", "how
+// many uber rides this year?" or "spending by category in march". The categories, tags
+// and accounts are the ones in the hints which the question mentions.
+func (o *Offline) Plan(_ context.Context, question string, hints PlanHints) (models.QueryPlan, error) {
+	today := o.now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+
+	if !reAskTotal.MatchString(question) && !reAskCount.MatchString(question) && !reAskAverage.MatchString(question) &&
+		!reAskIncome.MatchString(question) && !reAskGroupBy.MatchString(question) {
+		return models.QueryPlan{}, &NoPlanError{Message: `Question isn't about the transactions, ask something like "how much did I spend on food last month?"`}
+	}
+
+	plan := models.QueryPlan{
+		Metric: models.MetricTotal,
+		Type:   models.TypeExpense,
+	}
+	switch {
+	case reAskCount.MatchString(question):
+		plan.Metric = models.MetricCount
+	case reAskAverage.MatchString(question):
+		plan.Metric = models.MetricAverage
+	}
+	switch {
+	case reTransfer.MatchString(question):
+		plan.Type = models.TypeTransfer
+	case reAskIncome.MatchString(question):
+		plan.Type = models.TypeIncome
+	}
+
+	if m := reAskGroupBy.FindStringSubmatch(question); m != nil {
+		g := strings.ToLower(m[1])
+		switch {
+		case strings.HasPrefix(g, "categor"):
+			plan.GroupBy = models.GroupByCategory
+		default:
+			plan.GroupBy = strings.TrimSuffix(g, "s")
+		}
+	}
+	if m := reAskTop.FindStringSubmatch(question); m != nil {
+		plan.Limit, _ = strconv.Atoi(m[1])
+		if plan.GroupBy == "" {
+			plan.GroupBy = models.GroupByCategory
+		}
+	}
+
+	start, end := askPeriod(question, today)
+	if !start.IsZero() {
+		plan.StartDate, plan.EndDate = start.Format("2006-01-02"), end.Format("2006-01-02")
+	}
+
+	// The words of hashtags are tags rather than categories.
+	words := strings.FieldsFunc(strings.ToLower(reHashtag.ReplaceAllString(question, "")), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '-'
+	})
+	mentions := func(name string) bool {
+		name = strings.ToLower(name)
+		for i := range words {
+			// Names of more than one word are matched against as many words.
+			n := len(strings.Fields(name))
+			if i+n <= len(words) && strings.Join(words[i:i+n], " ") == name {
+				return true
+			}
+		}
+		return false
+	}
+	for _, c := range hints.Categories {
+		if mentions(c) {
+			plan.Categories = append(plan.Categories, c)
+		}
+	}
+	// A word like "coffee" is about the category it's filed under.
+	if len(plan.Categories) == 0 {
+		for _, w := range words {
+			c, ok := categoryKeywords[w]
+			if ok && slices.ContainsFunc(hints.Categories, func(h string) bool { return strings.EqualFold(h, c) }) {
+				plan.Categories = append(plan.Categories, c)
+				break
+			}
+		}
+	}
+	plan.Tags = Hashtags(question)
+	for _, t := range hints.Tags {
+		if !slices.Contains(plan.Tags, t) && mentions(t) {
+			plan.Tags = append(plan.Tags, t)
+		}
+	}
+	for _, a := range hints.Accounts {
+		if mentions(a) {
+			plan.Accounts = append(plan.Accounts, a)
+		}
+	}
+
+	return plan, nil
+}
+
+// askPeriod finds the period a question is about relative to today, eg: "last month"
+// or "in march". Both dates are zero when there's none, which is all time.
+func askPeriod(q string, today time.Time) (time.Time, time.Time) {
+	if m := reAskRange.FindStringSubmatch(q); m != nil {
+		start, err := time.ParseInLocation("2006-01-02", m[1], today.Location())
+		if err == nil {
+			end := start
+			if m[2] != "" {
+				if d, err := time.ParseInLocation("2006-01-02", m[2], today.Location()); err == nil {
+					end = d
+				}
+			}
+			return start, end
+		}
+	}
+
+	if m := reAskLastN.FindStringSubmatch(q); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch unit := strings.TrimSuffix(strings.ToLower(m[2]), "s"); unit {
+		case "week":
+			return today.AddDate(0, 0, -7*n+1), today
+		case "month":
+			return today.AddDate(0, -n, 1), today
+		default:
+			return today.AddDate(0, 0, -n+1), today
+		}
+	}
+
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	weekStart := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	yearStart := time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, today.Location())
+	if m := reAskPeriod.FindStringSubmatch(q); m != nil {
+		switch strings.ToLower(m[1]) {
+		case "today":
+			return today, today
+		case "yesterday":
+			return today.AddDate(0, 0, -1), today.AddDate(0, 0, -1)
+		case "this week":
+			return weekStart, today
+		case "last week":
+			return weekStart.AddDate(0, 0, -7), weekStart.AddDate(0, 0, -1)
+		case "this month":
+			return monthStart, today
+		case "last month":
+			return monthStart.AddDate(0, -1, 0), monthStart.AddDate(0, 0, -1)
+		case "this year":
+			return yearStart, today
+		case "last year":
+			return yearStart.AddDate(-1, 0, 0), yearStart.AddDate(0, 0, -1)
+		}
+	}
+
+	// A month is the latest one by that name, which is last year's for a month to come.
+	if m := reAskMonth.FindStringSubmatch(q); m != nil {
+		start := time.Date(today.Year(), months[strings.ToLower(m[1])], 1, 0, 0, 0, 0, today.Location())
+		if start.After(today) {
+			start = start.AddDate(-1, 0, 0)
+		}
+		return start, start.AddDate(0, 1, -1)
+	}
+
+	return time.Time{}, time.Time{}
+}
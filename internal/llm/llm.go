@@ -2,61 +2,260 @@ package llm
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/mr-karan/gullak/pkg/models"
 
-	"github.com/sashabaranov/go-openai"
 	"github.com/sashabaranov/go-openai/jsonschema"
 )
 
+const (
+	ProviderOpenAI    = "openai"
+	ProviderOllama    = "ollama"
+	ProviderAnthropic = "anthropic"
+	ProviderOffline   = "offline"
+
+	defaultTimeout = 10 * time.Second
+)
+
+// Config holds the settings for the configured LLM backend.
+type Config struct {
+	Provider string
+	BaseURL  string
+	Token    string
+	Model    string
+	Timeout  time.Duration
+
+	// OfflineFallback uses the offline parser when the provider fails.
+	OfflineFallback bool
+
+	// Vision is the model which reads photos of receipts.
+	Vision VisionConfig
+
+	// Transcription is the endpoint which transcribes voice notes.
+	Transcription TranscriptionConfig
+}
+
+// VisionConfig holds the settings for the OpenAI compatible model which reads photos
+// of receipts. The settings which aren't set are taken from Config when its provider
+// is openai, else receipts are only read when a model is set.
+type VisionConfig struct {
+	BaseURL string
+	Token   string
+	Model   string
+	Timeout time.Duration
+}
+
+// Parser extracts expenses from a message written in natural language.
+type Parser interface {
+	Parse(ctx context.Context, msg string, hints Hints) (Result, error)
+}
+
+// Hints tell a parser how the user files their expenses.
+type Hints struct {
+	// Categories are the categories which expenses are filed under. Any
+	// category goes when it's empty.
+	Categories []string
+
+	// Examples are the categories which the user corrected expenses similar
+	// to the message to. They're offered to the LLM as few-shot examples.
+	Examples []Example
+}
+
+// Example is an expense description and the category it belongs to.
+type Example struct {
+	Description string
+	Category    string
+}
+
+// Provider is a chat completion backend which can be offered a single tool to call.
+type Provider interface {
+	CallTool(ctx context.Context, req ToolRequest) (ToolResponse, error)
+}
+
+// Tool describes a function the model can call along with the JSON schema of its arguments.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  jsonschema.Definition
+}
+
+// ToolRequest is a provider agnostic completion request.
+type ToolRequest struct {
+	System string
+	Prompt string
+	Tool   Tool
+
+	// Images are sent along with the prompt. Only the openai provider supports them.
+	Images []Image
+}
+
+// Image is a photo sent to the model, eg: of a receipt.
+type Image struct {
+	MIMEType string
+	Data     []byte
+}
+
+// ToolResponse holds the arguments of the tool call, or the text reply if
+// the model chose not to call the tool.
+type ToolResponse struct {
+	Arguments []byte
+	Content   string
+	Model     string
+	Usage     Usage
+}
+
+// Usage is the number of tokens consumed by a completion.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Result holds the transactions parsed from a message along with details
+// of how they were parsed.
+type Result struct {
+	Transactions models.Transactions
+
+	// Parser is the name of the provider which parsed the message.
+	Parser        string
+	Model         string
+	PromptVersion string
+	Latency       time.Duration
+	Usage         Usage
+
+	// Offline is set when the transactions were produced by the offline
+	// parser instead of the LLM, so that they can be re-parsed later.
+	Offline bool
+}
+
+// backend parses expenses and plans questions about them.
+type backend interface {
+	Parser
+	Planner
+}
+
 type Manager struct {
-	log    *slog.Logger
-	client *openai.Client
-	model  string
+	log      *slog.Logger
+	parser   backend
+	fallback backend
+	provider string
+	model    string
+
+	// vision reads photos of receipts, it's nil when no vision model is configured.
+	vision *toolParser
+	// transcriber transcribes voice notes, it's nil when no endpoint is configured.
+	transcriber *transcriber
 }
 
-func New(token, baseURL, model string, timeout time.Duration, log *slog.Logger) (*Manager, error) {
-	// Initialize the OpenAI client.
-	cfg := openai.DefaultConfig(token)
-	if baseURL != "" {
-		cfg.BaseURL = baseURL
+func New(cfg Config, log *slog.Logger) (*Manager, error) {
+	if cfg.Provider == "" {
+		cfg.Provider = ProviderOpenAI
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
 	}
 
-	if timeout > 0 {
-		cfg.HTTPClient.Timeout = timeout
-	} else {
-		// Set a default timeout of 10 seconds.
-		cfg.HTTPClient.Timeout = 10 * time.Second
+	var parser backend
+	switch cfg.Provider {
+	case ProviderOpenAI:
+		if cfg.Token == "" || cfg.Model == "" {
+			return nil, errors.New("token and model are required for the openai provider")
+		}
+		parser = &toolParser{name: cfg.Provider, provider: newOpenAI(cfg), log: log}
+	case ProviderOllama:
+		if cfg.Model == "" {
+			return nil, errors.New("model is required for the ollama provider")
+		}
+		parser = &toolParser{name: cfg.Provider, provider: newOllama(cfg), log: log}
+	case ProviderAnthropic:
+		if cfg.Token == "" || cfg.Model == "" {
+			return nil, errors.New("token and model are required for the anthropic provider")
+		}
+		parser = &toolParser{name: cfg.Provider, provider: newAnthropic(cfg), log: log}
+	case ProviderOffline:
+		parser = NewOffline()
+	default:
+		return nil, fmt.Errorf("unknown llm provider: %s", cfg.Provider)
+	}
+
+	mgr := &Manager{
+		log:      log,
+		parser:   parser,
+		provider: cfg.Provider,
+		model:    cfg.Model,
+	}
+	if cfg.OfflineFallback && cfg.Provider != ProviderOffline {
+		mgr.fallback = NewOffline()
 	}
+	if vision, ok := visionConfig(cfg); ok {
+		mgr.vision = &toolParser{name: ProviderOpenAI, provider: newOpenAI(vision), log: log}
+	}
+	mgr.transcriber = newTranscriber(cfg)
 
-	client := openai.NewClientWithConfig(cfg)
+	return mgr, nil
+}
 
-	return &Manager{
-		client: client,
-		model:  model,
-		log:    log,
-	}, nil
+// Provider returns the name of the configured backend.
+func (m *Manager) Provider() string {
+	return m.provider
+}
 
+// Model returns the name of the configured model.
+func (m *Manager) Model() string {
+	return m.model
 }
 
-// Parse the message and extract the expenses.
-func (m *Manager) Parse(msg string) (models.Transactions, error) {
+// Parse the message and extract the expenses, filed the way the hints say. If the provider
+// fails and the offline fallback is enabled, the message is parsed by the offline parser
+// instead. Hashtags in the message are picked up as the tags of the expenses.
+func (m *Manager) Parse(ctx context.Context, msg string, hints Hints) (Result, error) {
 	if msg == "" {
-		return models.Transactions{}, errors.New("empty message")
+		return Result{}, errors.New("empty message")
+	}
+
+	m.log.Debug("Parsing expenses", "message", msg, "provider", m.provider)
+	start := time.Now()
+	res, err := m.parser.Parse(ctx, msg, hints)
+	if err == nil {
+		res.Latency = time.Since(start)
+		tagTransactions(msg, res.Transactions.Transactions)
+		return res, nil
 	}
 
-	m.log.Debug("Parsing expenses", "message", msg)
-	dialogue := []openai.ChatCompletionMessage{
-		{Role: openai.ChatMessageRoleSystem, Content: fmt.Sprintf("You will be provided with spends done by the user in natural language. Your task is to parse and categorise the expenses in valid categories, If the given input doesn't contain any data about the expenses then return an error. Today's date is %s", time.Now().Format("2006-01-02"))},
-		{Role: openai.ChatMessageRoleUser, Content: msg},
+	// The model understood the message but didn't find any expenses in it,
+	// the offline parser won't do any better.
+	var noTxErr *NoValidTransactionError
+	if m.fallback == nil || errors.As(err, &noTxErr) {
+		return Result{}, err
 	}
 
-	fnCategorizeExpenses := openai.FunctionDefinition{
+	m.log.Warn("Error parsing with the provider, using the offline parser", "provider", m.provider, "error", err)
+	start = time.Now()
+	res, err = m.fallback.Parse(ctx, msg, hints)
+	if err != nil {
+		return Result{}, err
+	}
+	res.Latency = time.Since(start)
+	tagTransactions(msg, res.Transactions.Transactions)
+
+	return res, nil
+}
+
+// fnCategorizeExpenses is the tool offered to the model for extracting expenses,
+// without a list of categories.
+var fnCategorizeExpenses = categorizeTool(nil)
+
+// categorizeTool returns the tool offered to the model for extracting expenses. The
+// category of an expense is restricted to the categories, if any are given.
+func categorizeTool(categories []string) Tool {
+	return Tool{
 		Name:        "categorize_expense",
 		Description: "Categorize expenses from the given input.",
 		Parameters: jsonschema.Definition{
@@ -76,63 +275,121 @@ func (m *Manager) Parse(msg string) (models.Transactions, error) {
 								Type:        jsonschema.Number,
 								Description: "Amount of the item",
 							},
+							"currency": {
+								Type:        jsonschema.String,
+								Description: "ISO 4217 code of the currency of the amount (e.g., INR, USD, EUR) if mentioned or implied by a symbol, else empty",
+							},
+							"type": {
+								Type:        jsonschema.String,
+								Enum:        []string{models.TypeExpense, models.TypeIncome, models.TypeTransfer},
+								Description: "expense for money spent, income for money received (e.g., salary, refund, interest), transfer for money moved between the user's own accounts",
+							},
+							"account": {
+								Type:        jsonschema.String,
+								Description: "Account or payment method the item was paid with, received in or transferred from if mentioned (e.g., HDFC card, UPI, cash), else empty",
+							},
+							"transfer_account": {
+								Type:        jsonschema.String,
+								Description: "Account the money was transferred to if the type is transfer, else empty",
+							},
 							"category": {
 								Type:        jsonschema.String,
-								Description: "One word category of the expense (e.g., food, travel, entertainment)",
+								Enum:        categories,
+								Description: "Category of the item, the most specific one which fits (e.g., groceries over food)",
 							},
 							"description": {
 								Type:        jsonschema.String,
-								Description: "Concise and short description of the item",
+								Description: "Concise and short description of the item, without hashtags",
+							},
+							"tags": {
+								Type:        jsonschema.Array,
+								Description: "Hashtags mentioned for the item without the # (e.g., trip-goa, reimbursable), else empty",
+								Items:       &jsonschema.Definition{Type: jsonschema.String},
+							},
+							"confidence": {
+								Type:        jsonschema.Number,
+								Description: "How sure you are of the amount, type and category of the item, from 0 (a guess) to 1 (certain)",
 							},
 						},
-						Required: []string{"transaction_date", "amount", "category", "description"},
+						Required: []string{"transaction_date", "amount", "type", "category", "description", "confidence"},
 					},
 				},
 			},
 			Required: []string{"transactions"},
 		},
 	}
+}
 
-	t := openai.Tool{
-		Type:     openai.ToolTypeFunction,
-		Function: &fnCategorizeExpenses,
-	}
+const parsePrompt = "You will be provided with spends, income and transfers between accounts done by the user in natural language. Your task is to parse and categorise the transactions in valid categories, If the given input doesn't contain any data about the transactions then return an error. Today's date is %s"
 
-	resp, err := m.client.CreateChatCompletion(context.TODO(),
-		openai.ChatCompletionRequest{
-			Model:    m.model,
-			Messages: dialogue,
-			Tools:    []openai.Tool{t},
-		},
-	)
+// promptVersion identifies the prompt and tool schema used for parsing. It's
+// stored alongside every parsed entry so that rows parsed by an older prompt
+// can be found later. The hints aren't part of it as they're the user's.
+var promptVersion = func() string {
+	b, _ := json.Marshal(fnCategorizeExpenses)
+	h := sha256.Sum256(append([]byte(parsePrompt), b...))
+	return hex.EncodeToString(h[:4])
+}()
 
-	if err != nil || len(resp.Choices) != 1 {
-		m.log.Error("Completion error", "error", err, "choices", len(resp.Choices))
-		return models.Transactions{}, fmt.Errorf("error completing the request")
+// examplesPrompt lists the examples for the system prompt. The examples go in the system
+// prompt rather than as past turns of the dialogue, as every provider has its own format
+// for tool calls.
+func examplesPrompt(examples []Example) string {
+	if len(examples) == 0 {
+		return ""
 	}
 
-	var transactions models.Transactions
+	var b strings.Builder
+	b.WriteString("\n\nThe user has filed these items under these categories before, file similar items the same way:")
+	for _, ex := range examples {
+		fmt.Fprintf(&b, "\n- %q: %s", ex.Description, ex.Category)
+	}
+	return b.String()
+}
 
-	for _, choice := range resp.Choices {
-		for _, toolCall := range choice.Message.ToolCalls {
-			if toolCall.Function.Name == fnCategorizeExpenses.Name {
-				if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &transactions); err != nil {
-					return models.Transactions{}, fmt.Errorf("error unmarshalling response: %s", err)
-				}
-				return transactions, nil
-			}
-		}
+// toolParser implements Parser on top of any chat completion Provider.
+type toolParser struct {
+	log      *slog.Logger
+	name     string
+	provider Provider
+}
+
+func (p *toolParser) Parse(ctx context.Context, msg string, hints Hints) (Result, error) {
+	return p.parse(ctx, ToolRequest{
+		System: fmt.Sprintf(parsePrompt, time.Now().Format("2006-01-02")) + examplesPrompt(hints.Examples),
+		Prompt: msg,
+		Tool:   categorizeTool(hints.Categories),
+	}, promptVersion)
+}
+
+// parse completes the request with the categorize_expense tool and extracts the
+// expenses from its arguments.
+func (p *toolParser) parse(ctx context.Context, req ToolRequest, version string) (Result, error) {
+	resp, err := p.provider.CallTool(ctx, req)
+	if err != nil {
+		p.log.Error("Completion error", "error", err)
+		return Result{}, fmt.Errorf("error completing the request")
 	}
 
-	if len(transactions.Transactions) == 0 {
-		for _, choice := range resp.Choices {
-			if choice.FinishReason == "stop" {
-				return models.Transactions{}, &NoValidTransactionError{Message: choice.Message.Content}
-			}
+	if resp.Arguments != nil {
+		var transactions models.Transactions
+		if err := json.Unmarshal(resp.Arguments, &transactions); err != nil {
+			return Result{}, fmt.Errorf("error unmarshalling response: %s", err)
 		}
+		return Result{
+			Transactions:  transactions,
+			Parser:        p.name,
+			Model:         resp.Model,
+			PromptVersion: version,
+			Usage:         resp.Usage,
+		}, nil
+	}
+
+	if resp.Content != "" {
+		return Result{}, &NoValidTransactionError{Message: resp.Content}
 	}
 
-	return models.Transactions{}, fmt.Errorf("no valid transactions found in response")
+	return Result{}, fmt.Errorf("no valid transactions found in response")
 }
 
 type NoValidTransactionError struct {
@@ -0,0 +1,378 @@
+package llm
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+const (
+	offlineCategory  = "misc"
+	incomeCategory   = "income"
+	transferCategory = "transfer"
+)
+
+var (
+	// reAmount matches an amount with an optional currency marker before or after it.
+	// For eg: ₹250, Rs. 1,200.50, 40 rs, INR 99, $12.5, 30 dollars.
+	reAmount = regexp.MustCompile(`(?i)(₹|\brs\.?|\binr\b|\$|\busd\b|€|\beur\b)?\s*(\d{1,3}(?:,\d{2,3})+(?:\.\d+)?|\d+(?:\.\d+)?)\s*(k\b)?\s*(₹|\brs\b\.?|\brupees?\b|\binr\b|\$|\busd\b|\bdollars?\b|€|\beur\b|\beuros?\b)?`)
+
+	reISODate    = regexp.MustCompile(`\b(\d{4}-\d{2}-\d{2})\b`)
+	reDaysAgo    = regexp.MustCompile(`(?i)\b(\d+)\s+days?\s+ago\b`)
+	reLastDay    = regexp.MustCompile(`(?i)\b(?:last|on|this)\s+(sunday|monday|tuesday|wednesday|thursday|friday|saturday)\b`)
+	reRelative   = regexp.MustCompile(`(?i)\b(day before yesterday|yesterday|today)\b`)
+	reAccount    = regexp.MustCompile(`(?i)\b(?:paid\s+)?(?:by|via|using|with|from)\s+((?:[a-z0-9]+\s+){0,2}?(?:credit card|debit card|card|upi|cash|wallet|netbanking))\b`)
+	reTransfer   = regexp.MustCompile(`(?i)\b(?:transferred|transfer|moved)\b`)
+	reTransferTo = regexp.MustCompile(`(?i)\bfrom\s+([a-z]+(?:\s+[a-z]+)?)\s+to\s+([a-z]+(?:\s+[a-z]+)?)\b`)
+	reIncome     = regexp.MustCompile(`(?i)\b(?:salary|received|credited|refund|bonus|interest|dividend|income|got paid)\b`)
+	reConnective = regexp.MustCompile(`(?i)^(?:(?:i|spent|paid|bought|got|for|on|received|transferred|moved)(?:\s+|$))+`)
+	reQuantity   = regexp.MustCompile(`^\s*\pL`)
+	reSeparator  = regexp.MustCompile(`(?i)\s*(?:;|\n|,\s|,$)\s*`)
+	reAnd        = regexp.MustCompile(`(?i)\s+and\s+`)
+)
+
+var currencySymbols = map[string]string{
+	"₹":       "INR",
+	"rs":      "INR",
+	"rs.":     "INR",
+	"rupee":   "INR",
+	"rupees":  "INR",
+	"inr":     "INR",
+	"$":       "USD",
+	"usd":     "USD",
+	"dollar":  "USD",
+	"dollars": "USD",
+	"€":       "EUR",
+	"eur":     "EUR",
+	"euro":    "EUR",
+	"euros":   "EUR",
+}
+
+// categoryKeywords maps words which commonly appear in expense descriptions
+// to a category. The first matching word in a description wins.
+var categoryKeywords = map[string]string{
+	"breakfast":   "food",
+	"lunch":       "food",
+	"dinner":      "food",
+	"snacks":      "food",
+	"coffee":      "food",
+	"tea":         "food",
+	"chai":        "food",
+	"pizza":       "food",
+	"burger":      "food",
+	"biryani":     "food",
+	"restaurant":  "food",
+	"cafe":        "food",
+	"swiggy":      "food",
+	"zomato":      "food",
+	"grocery":     "groceries",
+	"groceries":   "groceries",
+	"vegetables":  "groceries",
+	"fruits":      "groceries",
+	"milk":        "groceries",
+	"bigbasket":   "groceries",
+	"blinkit":     "groceries",
+	"zepto":       "groceries",
+	"uber":        "travel",
+	"ola":         "travel",
+	"cab":         "travel",
+	"taxi":        "travel",
+	"auto":        "travel",
+	"metro":       "travel",
+	"bus":         "travel",
+	"train":       "travel",
+	"flight":      "travel",
+	"petrol":      "fuel",
+	"diesel":      "fuel",
+	"fuel":        "fuel",
+	"movie":       "entertainment",
+	"movies":      "entertainment",
+	"netflix":     "entertainment",
+	"spotify":     "entertainment",
+	"concert":     "entertainment",
+	"amazon":      "shopping",
+	"flipkart":    "shopping",
+	"clothes":     "shopping",
+	"shoes":       "shopping",
+	"electricity": "utilities",
+	"water":       "utilities",
+	"internet":    "utilities",
+	"wifi":        "utilities",
+	"broadband":   "utilities",
+	"recharge":    "utilities",
+	"rent":        "rent",
+	"medicine":    "health",
+	"medicines":   "health",
+	"doctor":      "health",
+	"pharmacy":    "health",
+	"gym":         "health",
+	"books":       "education",
+	"course":      "education",
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Offline is a deterministic, rule based parser which runs in-process without
+// calling any model. It understands amounts with common currency markers,
+// relative dates such as "yesterday" or "last friday", payment methods such as
+// "paid by HDFC card", income such as "received salary" and transfers such as
+// "moved 5000 from HDFC to SBI", and uses a keyword map to pick a category,
+// unless the user corrected the same description before. Hashtags tag the
+// expense they're written in. It can be used as the primary
+// parser or as a fallback when the LLM is unreachable.
+type Offline struct {
+	now func() time.Time
+}
+
+func NewOffline() *Offline {
+	return &Offline{now: time.Now}
+}
+
+func (o *Offline) Parse(_ context.Context, msg string, hints Hints) (Result, error) {
+	today := o.now()
+
+	// A date mentioned anywhere in the message applies to all the expenses,
+	// unless an expense mentions its own date.
+	lineDate, _ := parseDate(msg, today)
+
+	var transactions models.Transactions
+	for _, chunk := range splitChunks(msg) {
+		item, ok := parseChunk(chunk, today, lineDate)
+		if !ok {
+			continue
+		}
+		// An expense which the user filed before goes under the same category.
+		for _, ex := range hints.Examples {
+			if strings.EqualFold(ex.Description, item.Description) {
+				item.Category = ex.Category
+				break
+			}
+		}
+		// The keyword categories may not be among the user's categories.
+		if len(hints.Categories) > 0 && !slices.ContainsFunc(hints.Categories, func(c string) bool { return strings.EqualFold(c, item.Category) }) {
+			item.Category = offlineCategory
+		}
+		transactions.Transactions = append(transactions.Transactions, item)
+	}
+
+	if len(transactions.Transactions) == 0 {
+		return Result{}, &NoValidTransactionError{Message: "No expenses found in the input"}
+	}
+
+	return Result{
+		Transactions: transactions,
+		Parser:       ProviderOffline,
+		Offline:      true,
+	}, nil
+}
+
+// splitChunks breaks a message into chunks of one expense each. Commas are
+// only treated as separators when they aren't part of a number like 1,200.
+func splitChunks(msg string) []string {
+	var chunks []string
+	for _, c := range reSeparator.Split(msg, -1) {
+		if c = strings.TrimSpace(c); c != "" {
+			chunks = append(chunks, splitAnd(c)...)
+		}
+	}
+	return chunks
+}
+
+// splitAnd splits a chunk on "and" between expenses, eg: "coffee 40 and lunch 200". A
+// part without an amount is a part of the description of the next expense, or the
+// previous one if it's the last, eg: "bread and butter 60" is one expense.
+func splitAnd(chunk string) []string {
+	var (
+		out     []string
+		pending string
+	)
+	for _, p := range reAnd.Split(chunk, -1) {
+		if pending != "" {
+			p = pending + " and " + p
+			pending = ""
+		}
+		if !reAmount.MatchString(p) {
+			pending = p
+			continue
+		}
+		out = append(out, p)
+	}
+	if pending != "" {
+		if len(out) == 0 {
+			return []string{pending}
+		}
+		out[len(out)-1] += " and " + pending
+	}
+	return out
+}
+
+func parseChunk(chunk string, today, lineDate time.Time) (models.Item, bool) {
+	// Strip the hashtags and the date first so that numbers in them aren't mistaken for the amount.
+	tags := Hashtags(chunk)
+	chunk = reHashtag.ReplaceAllString(chunk, "")
+	date, chunk := parseDate(chunk, today)
+	if date.IsZero() {
+		date = lineDate
+	}
+	if date.IsZero() {
+		date = today
+	}
+
+	typ := models.TypeExpense
+	switch {
+	case reTransfer.MatchString(chunk):
+		typ = models.TypeTransfer
+	case reIncome.MatchString(chunk):
+		typ = models.TypeIncome
+	}
+
+	// A transfer names both accounts, eg: "moved 5000 from HDFC to SBI".
+	var account, transferAccount string
+	if m := reTransferTo.FindStringSubmatchIndex(chunk); typ == models.TypeTransfer && m != nil {
+		account, transferAccount = chunk[m[2]:m[3]], chunk[m[4]:m[5]]
+		chunk = chunk[:m[0]] + chunk[m[1]:]
+	}
+
+	// The account is optional, eg: "paid by HDFC card", "via UPI".
+	if m := reAccount.FindStringSubmatchIndex(chunk); account == "" && m != nil {
+		account = chunk[m[2]:m[3]]
+		chunk = chunk[:m[0]] + chunk[m[1]:]
+	}
+
+	m := pickAmount(chunk)
+	if m == nil {
+		return models.Item{}, false
+	}
+
+	amount, err := models.ParseDecimal(strings.ReplaceAll(chunk[m[4]:m[5]], ",", ""))
+	if err != nil || amount.Sign() <= 0 {
+		return models.Item{}, false
+	}
+	if m[6] != -1 {
+		amount = amount.MulPow10(3)
+	}
+
+	var currency string
+	for _, g := range []int{2, 8} {
+		if m[g] != -1 {
+			currency = currencySymbols[strings.ToLower(chunk[m[g]:m[g+1]])]
+		}
+	}
+
+	desc := strings.Join(strings.Fields(chunk[:m[0]]+" "+chunk[m[1]:]), " ")
+	desc = reConnective.ReplaceAllString(desc, "")
+	if desc == "" {
+		desc = typ
+	}
+
+	category := categorize(desc)
+	switch typ {
+	case models.TypeIncome:
+		category = incomeCategory
+		if strings.Contains(strings.ToLower(desc), "salary") {
+			category = "salary"
+		}
+	case models.TypeTransfer:
+		category = transferCategory
+	}
+
+	return models.Item{
+		TransactionDate: date.Format("2006-01-02"),
+		Currency:        currency,
+		Amount:          amount,
+		Category:        category,
+		Description:     desc,
+		Type:            typ,
+		Account:         account,
+		TransferAccount: transferAccount,
+		Tags:            tags,
+	}, true
+}
+
+// pickAmount returns the submatch indexes of the amount of a chunk, nil if there's no
+// number in it. An amount with a currency marker or a "k" is preferred over any other
+// number. Otherwise, a number which is directly followed by a word is taken to be a
+// quantity, eg: "2 coffees 300", and the last number which isn't is the amount.
+func pickAmount(chunk string) []int {
+	matches := reAmount.FindAllStringSubmatchIndex(chunk, -1)
+	if matches == nil {
+		return nil
+	}
+	for _, m := range matches {
+		if m[2] != -1 || m[6] != -1 || m[8] != -1 {
+			return m
+		}
+	}
+	for i := len(matches) - 1; i >= 0; i-- {
+		if !reQuantity.MatchString(chunk[matches[i][1]:]) {
+			return matches[i]
+		}
+	}
+	return matches[len(matches)-1]
+}
+
+// parseDate finds a date expression in s relative to today. It returns the
+// date (zero if none was found) and s with the expression removed.
+func parseDate(s string, today time.Time) (time.Time, string) {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+
+	if m := reISODate.FindStringSubmatchIndex(s); m != nil {
+		if d, err := time.ParseInLocation("2006-01-02", s[m[2]:m[3]], today.Location()); err == nil {
+			return d, s[:m[0]] + s[m[1]:]
+		}
+	}
+
+	if m := reDaysAgo.FindStringSubmatchIndex(s); m != nil {
+		n, _ := strconv.Atoi(s[m[2]:m[3]])
+		return today.AddDate(0, 0, -n), s[:m[0]] + s[m[1]:]
+	}
+
+	if m := reLastDay.FindStringSubmatchIndex(s); m != nil {
+		wd := weekdays[strings.ToLower(s[m[2]:m[3]])]
+		diff := (int(today.Weekday()) - int(wd) + 7) % 7
+		if diff == 0 {
+			diff = 7
+		}
+		return today.AddDate(0, 0, -diff), s[:m[0]] + s[m[1]:]
+	}
+
+	if m := reRelative.FindStringSubmatchIndex(s); m != nil {
+		var d time.Time
+		switch strings.ToLower(s[m[2]:m[3]]) {
+		case "today":
+			d = today
+		case "yesterday":
+			d = today.AddDate(0, 0, -1)
+		case "day before yesterday":
+			d = today.AddDate(0, 0, -2)
+		}
+		return d, s[:m[0]] + s[m[1]:]
+	}
+
+	return time.Time{}, s
+}
+
+// categorize picks a category for the description using categoryKeywords.
+func categorize(desc string) string {
+	for _, w := range strings.FieldsFunc(strings.ToLower(desc), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z')
+	}) {
+		if c, ok := categoryKeywords[w]; ok {
+			return c
+		}
+	}
+	return offlineCategory
+}
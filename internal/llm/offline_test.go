@@ -0,0 +1,222 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// offlineNow is a Friday.
+var offlineNow = time.Date(2026, 10, 16, 18, 30, 0, 0, time.UTC)
+
+func TestOfflineParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []models.Item
+	}{
+		{
+			name: "plain amount",
+			in:   "coffee 40",
+			want: []models.Item{{Amount: models.NewDecimal(40, 0), Description: "coffee", Category: "food"}},
+		},
+		{
+			name: "quantity before the amount",
+			in:   "2 coffees 300",
+			want: []models.Item{{Amount: models.NewDecimal(300, 0), Description: "2 coffees"}},
+		},
+		{
+			name: "amount before the description",
+			in:   "300 coffee",
+			want: []models.Item{{Amount: models.NewDecimal(300, 0), Description: "coffee", Category: "food"}},
+		},
+		{
+			name: "marked amount over a quantity",
+			in:   "Rs 40 for 2 teas",
+			want: []models.Item{{Amount: models.NewDecimal(40, 0), Currency: "INR", Description: "2 teas"}},
+		},
+		{
+			name: "rupee symbol with grouping",
+			in:   "rent ₹1,200.50",
+			want: []models.Item{{Amount: models.NewDecimal(120050, 2), Currency: "INR", Description: "rent", Category: "rent"}},
+		},
+		{
+			name: "currency after the amount",
+			in:   "netflix 12.5 dollars",
+			want: []models.Item{{Amount: models.NewDecimal(125, 1), Currency: "USD", Description: "netflix", Category: "entertainment"}},
+		},
+		{
+			name: "dollar sign",
+			in:   "books $30",
+			want: []models.Item{{Amount: models.NewDecimal(30, 0), Currency: "USD", Description: "books", Category: "education"}},
+		},
+		{
+			name: "k suffix",
+			in:   "rent 5k for 2 months",
+			want: []models.Item{{Amount: models.NewDecimal(5000, 0), Description: "rent for 2 months", Category: "rent"}},
+		},
+		{
+			name: "k suffix with a decimal",
+			in:   "flight 1.5k",
+			want: []models.Item{{Amount: models.NewDecimal(1500, 0), Description: "flight", Category: "travel"}},
+		},
+		{
+			name: "separators",
+			in:   "coffee 40; lunch 200, uber 150\nmilk 30",
+			want: []models.Item{
+				{Amount: models.NewDecimal(40, 0), Description: "coffee", Category: "food"},
+				{Amount: models.NewDecimal(200, 0), Description: "lunch", Category: "food"},
+				{Amount: models.NewDecimal(150, 0), Description: "uber", Category: "travel"},
+				{Amount: models.NewDecimal(30, 0), Description: "milk", Category: "groceries"},
+			},
+		},
+		{
+			name: "and between expenses",
+			in:   "coffee 40 and lunch 200",
+			want: []models.Item{
+				{Amount: models.NewDecimal(40, 0), Description: "coffee", Category: "food"},
+				{Amount: models.NewDecimal(200, 0), Description: "lunch", Category: "food"},
+			},
+		},
+		{
+			name: "and in a description",
+			in:   "bread and butter 60",
+			want: []models.Item{{Amount: models.NewDecimal(60, 0), Description: "bread and butter"}},
+		},
+		{
+			name: "and in the last description",
+			in:   "milk 30 and 60 bread and butter",
+			want: []models.Item{
+				{Amount: models.NewDecimal(30, 0), Description: "milk", Category: "groceries"},
+				{Amount: models.NewDecimal(60, 0), Description: "bread and butter"},
+			},
+		},
+		{
+			name: "yesterday",
+			in:   "dinner 500 yesterday",
+			want: []models.Item{{Amount: models.NewDecimal(500, 0), Description: "dinner", Category: "food", TransactionDate: "2026-10-15"}},
+		},
+		{
+			name: "day before yesterday",
+			in:   "day before yesterday pizza 350",
+			want: []models.Item{{Amount: models.NewDecimal(350, 0), Description: "pizza", Category: "food", TransactionDate: "2026-10-14"}},
+		},
+		{
+			name: "days ago",
+			in:   "petrol 2000 3 days ago",
+			want: []models.Item{{Amount: models.NewDecimal(2000, 0), Description: "petrol", Category: "fuel", TransactionDate: "2026-10-13"}},
+		},
+		{
+			name: "last weekday",
+			in:   "movie 400 last monday",
+			want: []models.Item{{Amount: models.NewDecimal(400, 0), Description: "movie", Category: "entertainment", TransactionDate: "2026-10-12"}},
+		},
+		{
+			name: "last of the same weekday",
+			in:   "movie 400 last friday",
+			want: []models.Item{{Amount: models.NewDecimal(400, 0), Description: "movie", Category: "entertainment", TransactionDate: "2026-10-09"}},
+		},
+		{
+			name: "date of the line",
+			in:   "yesterday coffee 40 and lunch 200",
+			want: []models.Item{
+				{Amount: models.NewDecimal(40, 0), Description: "coffee", Category: "food", TransactionDate: "2026-10-15"},
+				{Amount: models.NewDecimal(200, 0), Description: "lunch", Category: "food", TransactionDate: "2026-10-15"},
+			},
+		},
+		{
+			name: "iso date",
+			in:   "2026-09-30 doctor 800",
+			want: []models.Item{{Amount: models.NewDecimal(800, 0), Description: "doctor", Category: "health", TransactionDate: "2026-09-30"}},
+		},
+		{
+			name: "account",
+			in:   "groceries 900 paid by hdfc card",
+			want: []models.Item{{Amount: models.NewDecimal(900, 0), Description: "groceries", Category: "groceries", Account: "hdfc card"}},
+		},
+		{
+			name: "income",
+			in:   "received salary 50000",
+			want: []models.Item{{Amount: models.NewDecimal(50000, 0), Description: "salary", Category: "salary", Type: models.TypeIncome}},
+		},
+		{
+			name: "transfer",
+			in:   "moved 5000 from hdfc to sbi",
+			want: []models.Item{{Amount: models.NewDecimal(5000, 0), Description: "transfer", Category: "transfer", Type: models.TypeTransfer, Account: "hdfc", TransferAccount: "sbi"}},
+		},
+		{
+			name: "transfer with a k suffix",
+			in:   "transferred 10k from savings to wallet",
+			want: []models.Item{{Amount: models.NewDecimal(10000, 0), Description: "transfer", Category: "transfer", Type: models.TypeTransfer, Account: "savings", TransferAccount: "wallet"}},
+		},
+	}
+
+	o := &Offline{now: func() time.Time { return offlineNow }}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := o.Parse(context.Background(), tt.in, Hints{})
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.in, err)
+			}
+			got := res.Transactions.Transactions
+			if len(got) != len(tt.want) {
+				t.Fatalf("Parse(%q) = %d transactions, want %d: %+v", tt.in, len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				if want.TransactionDate == "" {
+					want.TransactionDate = "2026-10-16"
+				}
+				if want.Category == "" {
+					want.Category = offlineCategory
+				}
+				if want.Type == "" {
+					want.Type = models.TypeExpense
+				}
+
+				g := got[i]
+				if c, err := g.Amount.Cmp(want.Amount); err != nil || c != 0 {
+					t.Errorf("Parse(%q)[%d].Amount = %s, want %s", tt.in, i, g.Amount, want.Amount)
+				}
+				for _, f := range []struct{ name, got, want string }{
+					{"Currency", g.Currency, want.Currency},
+					{"Description", g.Description, want.Description},
+					{"Category", g.Category, want.Category},
+					{"TransactionDate", g.TransactionDate, want.TransactionDate},
+					{"Type", g.Type, want.Type},
+					{"Account", g.Account, want.Account},
+					{"TransferAccount", g.TransferAccount, want.TransferAccount},
+				} {
+					if f.got != f.want {
+						t.Errorf("Parse(%q)[%d].%s = %q, want %q", tt.in, i, f.name, f.got, f.want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestOfflineParseNoAmount(t *testing.T) {
+	o := &Offline{now: func() time.Time { return offlineNow }}
+	for _, in := range []string{"", "salt and pepper", "coffee with friends"} {
+		if res, err := o.Parse(context.Background(), in, Hints{}); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", in, res.Transactions.Transactions)
+		}
+	}
+}
+
+func TestOfflineParseHints(t *testing.T) {
+	o := &Offline{now: func() time.Time { return offlineNow }}
+	res, err := o.Parse(context.Background(), "swiggy instamart 300; pizza 250", Hints{
+		Categories: []string{"groceries", "misc"},
+		Examples:   []Example{{Description: "Swiggy Instamart", Category: "groceries"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := res.Transactions.Transactions
+	if len(got) != 2 || got[0].Category != "groceries" || got[1].Category != offlineCategory {
+		t.Errorf("Parse with hints = %+v, want groceries and %s", got, offlineCategory)
+	}
+}
@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const defaultOllamaURL = "http://localhost:11434"
+
+// ollama talks to the native Ollama chat API (/api/chat).
+type ollama struct {
+	client  *http.Client
+	baseURL string
+	model   string
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaTool struct {
+	Type     string         `json:"type"`
+	Function ollamaFunction `json:"function"`
+}
+
+type ollamaFunction struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Parameters  jsonschema.Definition `json:"parameters"`
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []ollamaTool    `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+}
+
+type ollamaChatResponse struct {
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+func newOllama(cfg Config) *ollama {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOllamaURL
+	}
+
+	return &ollama{
+		client:  &http.Client{Timeout: cfg.Timeout},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   cfg.Model,
+	}
+}
+
+func (o *ollama) CallTool(ctx context.Context, req ToolRequest) (ToolResponse, error) {
+	body := ollamaChatRequest{
+		Model: o.model,
+		Messages: []ollamaMessage{
+			{Role: "system", Content: req.System},
+			{Role: "user", Content: req.Prompt},
+		},
+		Tools: []ollamaTool{{
+			Type: "function",
+			Function: ollamaFunction{
+				Name:        req.Tool.Name,
+				Description: req.Tool.Description,
+				Parameters:  req.Tool.Parameters,
+			},
+		}},
+	}
+
+	var resp ollamaChatResponse
+	if err := postJSON(ctx, o.client, o.baseURL+"/api/chat", nil, body, &resp); err != nil {
+		return ToolResponse{}, err
+	}
+
+	out := ToolResponse{
+		Model: resp.Model,
+		Usage: Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+		},
+	}
+
+	for _, toolCall := range resp.Message.ToolCalls {
+		if toolCall.Function.Name == req.Tool.Name {
+			out.Arguments = toolCall.Function.Arguments
+			return out, nil
+		}
+	}
+
+	out.Content = resp.Message.Content
+	return out, nil
+}
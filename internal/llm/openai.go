@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// openAI talks to any OpenAI compatible chat completions API.
+type openAI struct {
+	client *openai.Client
+	model  string
+}
+
+func newOpenAI(cfg Config) *openAI {
+	c := openai.DefaultConfig(cfg.Token)
+	if cfg.BaseURL != "" {
+		c.BaseURL = cfg.BaseURL
+	}
+	c.HTTPClient.Timeout = cfg.Timeout
+
+	return &openAI{
+		client: openai.NewClientWithConfig(c),
+		model:  cfg.Model,
+	}
+}
+
+func (o *openAI) CallTool(ctx context.Context, req ToolRequest) (ToolResponse, error) {
+	fn := openai.FunctionDefinition{
+		Name:        req.Tool.Name,
+		Description: req.Tool.Description,
+		Parameters:  req.Tool.Parameters,
+	}
+
+	// The images go along with the prompt as data URLs.
+	prompt := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: req.Prompt}
+	if len(req.Images) > 0 {
+		prompt.Content = ""
+		prompt.MultiContent = []openai.ChatMessagePart{{Type: openai.ChatMessagePartTypeText, Text: req.Prompt}}
+		for _, img := range req.Images {
+			prompt.MultiContent = append(prompt.MultiContent, openai.ChatMessagePart{
+				Type: openai.ChatMessagePartTypeImageURL,
+				ImageURL: &openai.ChatMessageImageURL{
+					URL:    "data:" + img.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(img.Data),
+					Detail: openai.ImageURLDetailHigh,
+				},
+			})
+		}
+	}
+
+	resp, err := o.client.CreateChatCompletion(ctx,
+		openai.ChatCompletionRequest{
+			Model: o.model,
+			Messages: []openai.ChatCompletionMessage{
+				{Role: openai.ChatMessageRoleSystem, Content: req.System},
+				prompt,
+			},
+			Tools: []openai.Tool{{Type: openai.ToolTypeFunction, Function: &fn}},
+		},
+	)
+	if err != nil {
+		return ToolResponse{}, err
+	}
+	if len(resp.Choices) != 1 {
+		return ToolResponse{}, fmt.Errorf("unexpected number of choices: %d", len(resp.Choices))
+	}
+
+	out := ToolResponse{
+		Model: resp.Model,
+		Usage: Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+		},
+	}
+
+	choice := resp.Choices[0]
+	for _, toolCall := range choice.Message.ToolCalls {
+		if toolCall.Function.Name == req.Tool.Name {
+			out.Arguments = []byte(toolCall.Function.Arguments)
+			return out, nil
+		}
+	}
+
+	if choice.FinishReason == openai.FinishReasonStop {
+		out.Content = choice.Message.Content
+	}
+
+	return out, nil
+}
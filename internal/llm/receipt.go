@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultVisionTimeout is longer than the default timeout, as reading an image takes a while.
+const defaultVisionTimeout = 60 * time.Second
+
+// ErrNoVision is returned when receipts are parsed without a vision model.
+var ErrNoVision = errors.New("no vision model is configured to read receipts, set llm.vision.model")
+
+// visionConfig fills the vision settings which aren't set from the provider's, when
+// it's openai. It reports whether a vision model can be used at all.
+func visionConfig(cfg Config) (Config, bool) {
+	v := cfg.Vision
+	if cfg.Provider == ProviderOpenAI {
+		if v.BaseURL == "" {
+			v.BaseURL = cfg.BaseURL
+		}
+		if v.Token == "" {
+			v.Token = cfg.Token
+		}
+		if v.Model == "" {
+			v.Model = cfg.Model
+		}
+	} else if v.Model == "" {
+		return Config{}, false
+	}
+	if v.Timeout <= 0 {
+		v.Timeout = defaultVisionTimeout
+	}
+
+	return Config{
+		Provider: ProviderOpenAI,
+		BaseURL:  v.BaseURL,
+		Token:    v.Token,
+		Model:    v.Model,
+		Timeout:  v.Timeout,
+	}, true
+}
+
+const receiptPrompt = "You will be provided with photos of a receipt, bill or invoice of the user, the photos are pages of the same receipt. Your task is to parse every item purchased on it as a transaction and categorise it in valid categories. Use the date and currency printed on the receipt, and the merchant's name in the descriptions. Spread taxes, service charges and discounts over the items in proportion to their amounts, so that the amounts add up to the total paid. If the photos aren't of a receipt then return an error. Today's date is %s"
+
+// receiptPromptVersion identifies the prompt and tool schema used for parsing receipts.
+var receiptPromptVersion = func() string {
+	b, _ := json.Marshal(fnCategorizeExpenses)
+	h := sha256.Sum256(append([]byte(receiptPrompt), b...))
+	return hex.EncodeToString(h[:4])
+}()
+
+// ParseReceipt reads the expenses from the photos of a receipt with the vision model, filed
+// the way the hints say. There's no offline fallback, as the offline parser can't read images.
+func (m *Manager) ParseReceipt(ctx context.Context, images []Image, hints Hints) (Result, error) {
+	if m.vision == nil {
+		return Result{}, ErrNoVision
+	}
+	if len(images) == 0 {
+		return Result{}, errors.New("no images")
+	}
+
+	m.log.Debug("Parsing receipt", "images", len(images), "provider", ProviderOpenAI)
+	start := time.Now()
+	res, err := m.vision.parse(ctx, ToolRequest{
+		System: fmt.Sprintf(receiptPrompt, time.Now().Format("2006-01-02")) + examplesPrompt(hints.Examples),
+		Prompt: "Parse the items on this receipt.",
+		Tool:   categorizeTool(hints.Categories),
+		Images: images,
+	}, receiptPromptVersion)
+	if err != nil {
+		return Result{}, err
+	}
+	res.Latency = time.Since(start)
+
+	return res, nil
+}
@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// reHashtag matches a hashtag like #trip-goa or #reimbursable.
+var reHashtag = regexp.MustCompile(`#([\p{L}\p{N}][\p{L}\p{N}_-]*)`)
+
+// Hashtags returns the normalised tags of the hashtags in s.
+func Hashtags(s string) []string {
+	var tags []string
+	for _, m := range reHashtag.FindAllStringSubmatch(s, -1) {
+		tags = append(tags, m[1])
+	}
+	return NormalizeTags(tags)
+}
+
+// NormalizeTags lowercases the tags, strips a leading # and drops empty and duplicate tags.
+func NormalizeTags(tags []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(t), "#")))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// tagTransactions keeps the tags of the transactions which are hashtags in the message,
+// as the model may come up with its own. When no transaction was given a tag, like when
+// the message is a single expense with a hashtag at the end, every hashtag applies to all.
+func tagTransactions(msg string, items []models.Item) {
+	hashtags := Hashtags(msg)
+	inMsg := map[string]bool{}
+	for _, t := range hashtags {
+		inMsg[t] = true
+	}
+
+	var tagged bool
+	for i := range items {
+		var tags []string
+		for _, t := range NormalizeTags(items[i].Tags) {
+			if inMsg[t] {
+				tags = append(tags, t)
+			}
+		}
+		items[i].Tags = tags
+		tagged = tagged || len(tags) > 0
+	}
+
+	if !tagged {
+		for i := range items {
+			items[i].Tags = hashtags
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// defaultTranscriptionTimeout is longer than the default timeout, as a recording takes a
+// while to upload and transcribe.
+const defaultTranscriptionTimeout = 60 * time.Second
+
+// ErrNoTranscription is returned when audio is transcribed without a transcription endpoint.
+var ErrNoTranscription = errors.New("no transcription endpoint is configured to transcribe audio, set llm.transcription.base_url")
+
+// TranscriptionConfig holds the settings for the OpenAI compatible /audio/transcriptions
+// endpoint which transcribes voice notes, eg: OpenAI's Whisper or a local whisper server.
+// The settings which aren't set are taken from Config when its provider is openai, else
+// voice notes are only transcribed when a base URL is set.
+type TranscriptionConfig struct {
+	BaseURL string
+	Token   string
+	Model   string
+	Timeout time.Duration
+
+	// Language is the ISO 639-1 code of the language spoken in the voice notes, eg: en.
+	// It's detected when it's empty.
+	Language string
+}
+
+// Audio is a recording to be transcribed. The endpoint tells its format from the
+// extension of its filename.
+type Audio struct {
+	Filename string
+	Data     []byte
+}
+
+// transcriber turns speech into text through an OpenAI compatible /audio/transcriptions endpoint.
+type transcriber struct {
+	client   *openai.Client
+	model    string
+	language string
+}
+
+// newTranscriber fills the transcription settings which aren't set from the provider's,
+// when it's openai. It returns nil when audio can't be transcribed at all.
+func newTranscriber(cfg Config) *transcriber {
+	t := cfg.Transcription
+	if cfg.Provider == ProviderOpenAI {
+		if t.BaseURL == "" {
+			t.BaseURL = cfg.BaseURL
+		}
+		if t.Token == "" {
+			t.Token = cfg.Token
+		}
+	} else if t.BaseURL == "" {
+		return nil
+	}
+	if t.Model == "" {
+		t.Model = openai.Whisper1
+	}
+	if t.Timeout <= 0 {
+		t.Timeout = defaultTranscriptionTimeout
+	}
+
+	c := openai.DefaultConfig(t.Token)
+	if t.BaseURL != "" {
+		c.BaseURL = t.BaseURL
+	}
+	c.HTTPClient.Timeout = t.Timeout
+
+	return &transcriber{
+		client:   openai.NewClientWithConfig(c),
+		model:    t.Model,
+		language: t.Language,
+	}
+}
+
+// Transcribe turns the speech in the recording into text, to be parsed like a line of input.
+func (m *Manager) Transcribe(ctx context.Context, audio Audio) (string, error) {
+	if m.transcriber == nil {
+		return "", ErrNoTranscription
+	}
+	if len(audio.Data) == 0 {
+		return "", errors.New("empty audio")
+	}
+
+	m.log.Debug("Transcribing audio", "filename", audio.Filename, "size", len(audio.Data), "model", m.transcriber.model)
+	resp, err := m.transcriber.client.CreateTranscription(ctx, openai.AudioRequest{
+		Model:    m.transcriber.model,
+		FilePath: audio.Filename,
+		Reader:   bytes.NewReader(audio.Data),
+		Language: m.transcriber.language,
+	})
+	if err != nil {
+		m.log.Error("Transcription error", "error", err)
+		return "", fmt.Errorf("error transcribing the audio")
+	}
+
+	return strings.TrimSpace(resp.Text), nil
+}
@@ -0,0 +1,176 @@
+// Package migrate applies versioned SQL migrations to a SQLite database.
+//
+// Migrations are read from files named `<version>_<name>.up.sql` and
+// `<version>_<name>.down.sql` (the golang-migrate layout, which sqlc also
+// understands). The version of the database is tracked in `PRAGMA user_version`
+// and every migration is applied inside a transaction along with the version bump.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var reFilename = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is the state of a migration in the database.
+type Status struct {
+	Migration
+	Applied bool
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New loads the migrations in dir from fsys. Versions must start at 1 and
+// have no gaps, and every migration needs both an up and a down file.
+func New(db *sql.DB, fsys fs.FS, dir string) (*Migrator, error) {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, f := range files {
+		m := reFilename.FindStringSubmatch(f.Name())
+		if m == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(m[1])
+		b, err := fs.ReadFile(fsys, path.Join(dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %w", f.Name(), err)
+		}
+
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mg
+		} else if mg.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names: %s, %s", version, mg.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			mg.Up = string(b)
+		} else {
+			mg.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		migrations = append(migrations, *mg)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, mg := range migrations {
+		if mg.Version != i+1 {
+			return nil, fmt.Errorf("missing migration %d", i+1)
+		}
+		if mg.Up == "" || mg.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both up and down files", mg.Version, mg.Name)
+		}
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Version returns the version the database is currently migrated to.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	var v int
+	if err := m.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&v); err != nil {
+		return 0, fmt.Errorf("error reading schema version: %w", err)
+	}
+	if v > len(m.migrations) {
+		return v, fmt.Errorf("database schema version %d is newer than the latest known migration %d", v, len(m.migrations))
+	}
+	return v, nil
+}
+
+// Status lists all the migrations and whether they've been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	v, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Status, len(m.migrations))
+	for i, mg := range m.migrations {
+		out[i] = Status{Migration: mg, Applied: mg.Version <= v}
+	}
+	return out, nil
+}
+
+// Up applies all the pending migrations in order and returns the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	v, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, mg := range m.migrations[v:] {
+		if err := m.apply(ctx, mg.Up, mg.Version); err != nil {
+			return applied, fmt.Errorf("error applying migration %d_%s: %w", mg.Version, mg.Name, err)
+		}
+		applied = append(applied, mg)
+	}
+	return applied, nil
+}
+
+// Down rolls back the latest applied migration and returns it.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	v, err := m.Version(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+	if v == 0 {
+		return Migration{}, errors.New("no migrations to roll back")
+	}
+
+	mg := m.migrations[v-1]
+	if err := m.apply(ctx, mg.Down, v-1); err != nil {
+		return Migration{}, fmt.Errorf("error rolling back migration %d_%s: %w", mg.Version, mg.Name, err)
+	}
+	return mg, nil
+}
+
+// apply runs the statements and sets the schema version in a single transaction.
+func (m *Migrator) apply(ctx context.Context, stmts string, version int) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, stmts); err != nil {
+		return err
+	}
+
+	// PRAGMA doesn't accept bind parameters.
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,143 @@
+// Package recur computes the occurrences of recurrence rules written in a
+// subset of the iCalendar RRULE syntax, eg: `FREQ=MONTHLY;INTERVAL=1;COUNT=12`.
+package recur
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies of a rule.
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// Rule repeats every Interval days, weeks, months or years from a start date.
+// It ends after Count occurrences or on Until, if they're set.
+type Rule struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    time.Time
+}
+
+// Parse reads a rule like `FREQ=WEEKLY;INTERVAL=2`. The parts supported are
+// FREQ, INTERVAL, COUNT and UNTIL (as YYYYMMDD). A frequency on its own, like
+// `monthly`, is also accepted.
+func Parse(s string) (Rule, error) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	if s != "" && !strings.Contains(s, "=") {
+		s = "FREQ=" + s
+	}
+
+	r := Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = val
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(val)
+			if err == nil && r.Interval < 1 {
+				err = fmt.Errorf("must be at least 1")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(val)
+			if err == nil && r.Count < 1 {
+				err = fmt.Errorf("must be at least 1")
+			}
+		case "UNTIL":
+			// The time part of a UNTIL date time is ignored, occurrences are days.
+			r.Until, err = time.Parse("20060102", val[:min(len(val), 8)])
+		default:
+			return Rule{}, fmt.Errorf("unsupported rule part %s", key)
+		}
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid %s %q: %w", key, val, err)
+		}
+	}
+
+	switch r.Freq {
+	case Daily, Weekly, Monthly, Yearly:
+	case "":
+		return Rule{}, fmt.Errorf("FREQ is required")
+	default:
+		return Rule{}, fmt.Errorf("unsupported FREQ %s, use one of DAILY, WEEKLY, MONTHLY, YEARLY", r.Freq)
+	}
+
+	return r, nil
+}
+
+// String returns the rule in RRULE syntax.
+func (r Rule) String() string {
+	s := "FREQ=" + r.Freq + ";INTERVAL=" + strconv.Itoa(r.Interval)
+	if r.Count > 0 {
+		s += ";COUNT=" + strconv.Itoa(r.Count)
+	}
+	if !r.Until.IsZero() {
+		s += ";UNTIL=" + r.Until.Format("20060102")
+	}
+	return s
+}
+
+// Occurrence returns the nth (starting at 0) occurrence of the rule from start. It's
+// false if the rule ends before it. Occurrences are counted from start rather than
+// from the previous one, so that a rule starting on the 31st falls on the last day of
+// shorter months and returns to the 31st after them.
+func (r Rule) Occurrence(start time.Time, n int) (time.Time, bool) {
+	if r.Count > 0 && n >= r.Count {
+		return time.Time{}, false
+	}
+
+	var d time.Time
+	step := n * r.Interval
+	switch r.Freq {
+	case Daily:
+		d = start.AddDate(0, 0, step)
+	case Weekly:
+		d = start.AddDate(0, 0, 7*step)
+	case Monthly:
+		d = addMonths(start, step)
+	case Yearly:
+		d = addMonths(start, 12*step)
+	}
+
+	if !r.Until.IsZero() && d.After(r.Until) {
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+// Next returns the first occurrence of the rule from start which is on or after
+// from, along with its index. It's false if the rule ends before from.
+func (r Rule) Next(start, from time.Time) (time.Time, int, bool) {
+	for n := 0; ; n++ {
+		d, ok := r.Occurrence(start, n)
+		if !ok {
+			return time.Time{}, 0, false
+		}
+		if !d.Before(from) {
+			return d, n, true
+		}
+	}
+}
+
+// addMonths adds n months to t, clamping the day to the last day of the month.
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, n, 0)
+	last := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(t.Day(), last), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
@@ -0,0 +1,132 @@
+package recur
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "FREQ=MONTHLY", want: "FREQ=MONTHLY;INTERVAL=1"},
+		{in: "monthly", want: "FREQ=MONTHLY;INTERVAL=1"},
+		{in: "RRULE:FREQ=WEEKLY;INTERVAL=2", want: "FREQ=WEEKLY;INTERVAL=2"},
+		{in: "freq=daily;count=10", want: "FREQ=DAILY;INTERVAL=1;COUNT=10"},
+		{in: "FREQ=YEARLY;UNTIL=20301231T000000Z", want: "FREQ=YEARLY;INTERVAL=1;UNTIL=20301231"},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.in, err)
+			continue
+		}
+		if r.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, r, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"FREQ=HOURLY",
+		"FREQ=MONTHLY;BYDAY=MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"INTERVAL=2",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=x",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;UNTIL=2030",
+		"FREQ=DAILY;INTERVAL",
+	} {
+		if r, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %s, want an error", in, r)
+		}
+	}
+}
+
+func TestOccurrence(t *testing.T) {
+	tests := []struct {
+		rule  string
+		start string
+		want  []string
+		// ends is whether the rule ends after the occurrences in want.
+		ends bool
+	}{
+		// The 31st falls on the last day of shorter months and returns to the 31st after them.
+		{rule: "FREQ=MONTHLY", start: "2024-01-31", want: []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30", "2024-05-31"}},
+		{rule: "FREQ=MONTHLY;INTERVAL=1", start: "2023-01-31", want: []string{"2023-01-31", "2023-02-28", "2023-03-31"}},
+		{rule: "FREQ=YEARLY", start: "2024-02-29", want: []string{"2024-02-29", "2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"}},
+		{rule: "FREQ=DAILY;INTERVAL=3", start: "2024-02-27", want: []string{"2024-02-27", "2024-03-01", "2024-03-04"}},
+		{rule: "FREQ=WEEKLY;INTERVAL=2", start: "2024-12-25", want: []string{"2024-12-25", "2025-01-08", "2025-01-22"}},
+		{rule: "FREQ=MONTHLY;INTERVAL=3", start: "2024-11-30", want: []string{"2024-11-30", "2025-02-28", "2025-05-30"}},
+		// COUNT and UNTIL end the rule, whichever comes first.
+		{rule: "FREQ=MONTHLY;COUNT=2", start: "2024-01-15", want: []string{"2024-01-15", "2024-02-15"}, ends: true},
+		{rule: "FREQ=WEEKLY;UNTIL=20240115", start: "2024-01-01", want: []string{"2024-01-01", "2024-01-08", "2024-01-15"}, ends: true},
+		{rule: "FREQ=DAILY;COUNT=5;UNTIL=20240102", start: "2024-01-01", want: []string{"2024-01-01", "2024-01-02"}, ends: true},
+		{rule: "FREQ=DAILY;UNTIL=20231231", start: "2024-01-01", want: nil, ends: true},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.rule, err)
+		}
+
+		for n, want := range tt.want {
+			d, ok := r.Occurrence(date(tt.start), n)
+			if !ok {
+				t.Errorf("%s from %s ended before occurrence %d, want %s", tt.rule, tt.start, n, want)
+				break
+			}
+			if got := d.Format("2006-01-02"); got != want {
+				t.Errorf("%s from %s, occurrence %d = %s, want %s", tt.rule, tt.start, n, got, want)
+			}
+		}
+		if d, ok := r.Occurrence(date(tt.start), len(tt.want)); tt.ends && ok {
+			t.Errorf("%s from %s, occurrence %d = %s, want the rule to have ended", tt.rule, tt.start, len(tt.want), d.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		rule     string
+		start    string
+		from     string
+		want     string
+		wantN    int
+		wantDone bool
+	}{
+		{rule: "FREQ=MONTHLY", start: "2024-01-31", from: "2024-03-01", want: "2024-03-31", wantN: 2},
+		{rule: "FREQ=MONTHLY", start: "2024-01-31", from: "2024-02-29", want: "2024-02-29", wantN: 1},
+		{rule: "FREQ=WEEKLY;INTERVAL=2", start: "2024-01-01", from: "2023-06-01", want: "2024-01-01", wantN: 0},
+		{rule: "FREQ=DAILY;COUNT=3", start: "2024-01-01", from: "2024-01-04", wantDone: true},
+		{rule: "FREQ=DAILY;UNTIL=20240110", start: "2024-01-01", from: "2024-01-11", wantDone: true},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.rule, err)
+		}
+		d, n, ok := r.Next(date(tt.start), date(tt.from))
+		if tt.wantDone {
+			if ok {
+				t.Errorf("%s from %s, next after %s = %s, want none", tt.rule, tt.start, tt.from, d.Format("2006-01-02"))
+			}
+			continue
+		}
+		if !ok || d.Format("2006-01-02") != tt.want || n != tt.wantN {
+			t.Errorf("%s from %s, next after %s = %s (%d, %v), want %s (%d)", tt.rule, tt.start, tt.from, d.Format("2006-01-02"), n, ok, tt.want, tt.wantN)
+		}
+	}
+}
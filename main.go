@@ -4,12 +4,14 @@ import (
 	"context"
 	"embed"
 	"flag"
+	"fmt"
 	"io/fs"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/mr-karan/gullak/internal/db"
 	"github.com/mr-karan/gullak/internal/llm"
 )
 
@@ -22,6 +24,10 @@ var content embed.FS
 
 func main() {
 	cfgPath := flag.String("config", "config.toml", "File path to the config file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [migrate status|up|down] [rates import <file>]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	// Initialize the configuration.
@@ -48,26 +54,58 @@ func main() {
 	}
 	logger := slog.New(slog.NewTextHandler(os.Stdout, lgrOpts))
 
-	// Initialize the OpenAI client.
-	llmMgr, err := llm.New(
-		ko.MustString("openai.token"),
-		ko.String("openai.base_url"),
-		ko.MustString("openai.model"),
-		ko.Duration("openai.timeout"),
-		logger)
+	// Initialize the database.
+	conn, err := initDB(ko.MustString("app.db_path"))
+	if err != nil {
+		logger.Error("Error initializing database", "error", err)
+		os.Exit(1)
+	}
+
+	// Run the `migrate` command and exit.
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrateCmd(context.Background(), conn, flag.Arg(1), logger); err != nil {
+			logger.Error("Error running migrations", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := migrateDB(context.Background(), conn, logger); err != nil {
+		logger.Error("Error migrating database", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Successfully connected to the database and applied migrations", "path", ko.MustString("app.db_path"))
+
+	// Run the `rates` command and exit.
+	if flag.Arg(0) == "rates" {
+		if err := runRatesCmd(context.Background(), conn, flag.Arg(1), flag.Arg(2), logger); err != nil {
+			logger.Error("Error importing exchange rates", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Initialize the LLM provider.
+	llmMgr, err := llm.New(llmConfig(ko), logger)
 	if err != nil {
 		logger.Error("Error initializing llm", "error", err)
 		os.Exit(1)
 	}
-	logger.Info("Successfully initialized OpenAI client", "model", ko.MustString("openai.model"))
+	logger.Info("Successfully initialized LLM provider", "provider", llmMgr.Provider(), "model", llmMgr.Model())
 
-	// Initialize the database.
-	db, err := initDB(ko.MustString("app.db_path"), ko.String("app.currency"))
+	// Initialize the policy for confirming parsed transactions.
+	confirm, err := confirmPolicyConfig(ko)
 	if err != nil {
-		logger.Error("Error initializing database", "error", err)
+		logger.Error("Error initializing auto_confirm", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize the store for the attachments of transactions.
+	blobs, err := newBlobStore(ko.String("app.attachments_dir"))
+	if err != nil {
+		logger.Error("Error initializing attachments", "error", err)
 		os.Exit(1)
 	}
-	logger.Info("Successfully connected to the database and tables created", "path", ko.MustString("app.db_path"))
 
 	// Create a context that is cancelled on SIGTERM or SIGINT
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
@@ -78,8 +116,12 @@ func main() {
 		ko.MustString("http.address"),
 		ko.MustDuration("http.timeout"),
 		subFS,
-		db,
+		conn,
+		db.New(conn),
 		llmMgr,
+		ko.String("app.currency"),
+		confirm,
+		blobs,
 		logger,
 	)
 	if err := app.Start(ctx); err != nil {
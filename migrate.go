@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/mr-karan/gullak/internal/migrate"
+)
+
+// The same migration files are used by sqlc as the schema, see sqlc.yaml.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrateDB applies any pending migrations. It's run on every startup.
+func migrateDB(ctx context.Context, conn *sql.DB, log *slog.Logger) error {
+	m, err := migrate.New(conn, migrations, "migrations")
+	if err != nil {
+		return err
+	}
+
+	applied, err := m.Up(ctx)
+	for _, mg := range applied {
+		log.Info("Applied migration", "version", mg.Version, "name", mg.Name)
+	}
+	return err
+}
+
+// runMigrateCmd handles the `migrate status|up|down` command.
+func runMigrateCmd(ctx context.Context, conn *sql.DB, cmd string, log *slog.Logger) error {
+	m, err := migrate.New(conn, migrations, "migrations")
+	if err != nil {
+		return err
+	}
+
+	switch cmd {
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, s := range status {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, state)
+		}
+		return w.Flush()
+
+	case "up":
+		return migrateDB(ctx, conn, log)
+
+	case "down":
+		mg, err := m.Down(ctx)
+		if err != nil {
+			return err
+		}
+		log.Info("Rolled back migration", "version", mg.Version, "name", mg.Name)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command %q, use one of status, up, down", cmd)
+	}
+}
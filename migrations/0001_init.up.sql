@@ -1,8 +1,9 @@
+-- Databases created before migrations were introduced already have this table.
 CREATE TABLE IF NOT EXISTS transactions (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     created_at DATETIME NOT NULL DEFAULT (datetime('now')),
     transaction_date DATE NOT NULL,
-    currency TEXT NOT NULL,
+    currency TEXT NOT NULL DEFAULT 'INR',
     amount FLOAT NOT NULL,
     category TEXT NOT NULL,
     description TEXT NOT NULL DEFAULT '',
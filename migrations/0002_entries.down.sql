@@ -0,0 +1,18 @@
+-- SQLite can't drop a column which references another table, so the table is rebuilt.
+CREATE TABLE transactions_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    transaction_date DATE NOT NULL,
+    currency TEXT NOT NULL DEFAULT 'INR',
+    amount FLOAT NOT NULL,
+    category TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    confirm BOOLEAN NOT NULL DEFAULT false
+);
+
+INSERT INTO transactions_old (id, created_at, transaction_date, currency, amount, category, description, confirm)
+SELECT id, created_at, transaction_date, currency, amount, category, description, confirm FROM transactions;
+
+DROP TABLE transactions;
+ALTER TABLE transactions_old RENAME TO transactions;
+DROP TABLE entries;
@@ -0,0 +1,14 @@
+CREATE TABLE entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    line TEXT NOT NULL,
+    parser TEXT NOT NULL,
+    model TEXT NOT NULL DEFAULT '',
+    prompt_version TEXT NOT NULL DEFAULT '',
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0
+);
+
+ALTER TABLE transactions ADD COLUMN needs_reparse BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE transactions ADD COLUMN entry_id INTEGER REFERENCES entries(id);
@@ -0,0 +1,32 @@
+-- Converts amounts back from minor units to floats.
+CREATE TABLE transactions_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    transaction_date DATE NOT NULL,
+    currency TEXT NOT NULL DEFAULT 'INR',
+    amount FLOAT NOT NULL,
+    category TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    confirm BOOLEAN NOT NULL DEFAULT false,
+    needs_reparse BOOLEAN NOT NULL DEFAULT false,
+    entry_id INTEGER REFERENCES entries(id)
+);
+
+INSERT INTO transactions_old (id, created_at, transaction_date, currency, amount, category, description, confirm, needs_reparse, entry_id)
+SELECT id, created_at, transaction_date, currency,
+    amount * 1.0 / (CASE UPPER(currency)
+        WHEN 'BIF' THEN 1 WHEN 'CLP' THEN 1 WHEN 'DJF' THEN 1 WHEN 'GNF' THEN 1
+        WHEN 'ISK' THEN 1 WHEN 'JPY' THEN 1 WHEN 'KMF' THEN 1 WHEN 'KRW' THEN 1
+        WHEN 'PYG' THEN 1 WHEN 'RWF' THEN 1 WHEN 'UGX' THEN 1 WHEN 'UYI' THEN 1
+        WHEN 'VND' THEN 1 WHEN 'VUV' THEN 1 WHEN 'XAF' THEN 1 WHEN 'XOF' THEN 1
+        WHEN 'XPF' THEN 1
+        WHEN 'BHD' THEN 1000 WHEN 'IQD' THEN 1000 WHEN 'JOD' THEN 1000 WHEN 'KWD' THEN 1000
+        WHEN 'LYD' THEN 1000 WHEN 'OMR' THEN 1000 WHEN 'TND' THEN 1000
+        WHEN 'CLF' THEN 10000 WHEN 'UYW' THEN 10000
+        ELSE 100
+    END),
+    category, description, confirm, needs_reparse, entry_id
+FROM transactions;
+
+DROP TABLE transactions;
+ALTER TABLE transactions_old RENAME TO transactions;
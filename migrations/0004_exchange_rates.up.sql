@@ -0,0 +1,8 @@
+-- One unit of base is worth rate units of quote on date.
+CREATE TABLE exchange_rates (
+    date DATE NOT NULL,
+    base TEXT NOT NULL,
+    quote TEXT NOT NULL,
+    rate REAL NOT NULL,
+    PRIMARY KEY (date, base, quote)
+);
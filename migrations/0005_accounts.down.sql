@@ -0,0 +1,20 @@
+-- SQLite can't drop a column which references another table, so the table is rebuilt.
+CREATE TABLE transactions_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    transaction_date DATE NOT NULL,
+    currency TEXT NOT NULL DEFAULT 'INR',
+    amount INTEGER NOT NULL,
+    category TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    confirm BOOLEAN NOT NULL DEFAULT false,
+    needs_reparse BOOLEAN NOT NULL DEFAULT false,
+    entry_id INTEGER REFERENCES entries(id)
+);
+
+INSERT INTO transactions_old (id, created_at, transaction_date, currency, amount, category, description, confirm, needs_reparse, entry_id)
+SELECT id, created_at, transaction_date, currency, amount, category, description, confirm, needs_reparse, entry_id FROM transactions;
+
+DROP TABLE transactions;
+ALTER TABLE transactions_old RENAME TO transactions;
+DROP TABLE accounts;
@@ -0,0 +1,14 @@
+-- Accounts are the cards, bank accounts, UPI handles, wallets and cash which money is paid from.
+CREATE TABLE accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    kind TEXT NOT NULL DEFAULT 'other',
+    currency TEXT NOT NULL DEFAULT 'INR',
+    -- In minor units of the account's currency.
+    opening_balance INTEGER NOT NULL DEFAULT 0
+);
+
+ALTER TABLE transactions ADD COLUMN account_id INTEGER REFERENCES accounts(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_transactions_account_id ON transactions(account_id);
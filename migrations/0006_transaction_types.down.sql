@@ -0,0 +1,34 @@
+-- Income and transfers can't be kept without their type, so rolling back fails while
+-- there are any, rather than losing them or turning them into expenses. RAISE only
+-- works in triggers, so the check runs as a trigger of a throwaway table.
+CREATE TEMP TABLE migration_guard (n INTEGER);
+CREATE TEMP TRIGGER migration_guard_types BEFORE INSERT ON migration_guard WHEN NEW.n > 0
+BEGIN
+    SELECT RAISE(ABORT, 'there are income or transfer transactions, delete them before rolling back 0006_transaction_types');
+END;
+INSERT INTO migration_guard SELECT count(*) FROM transactions WHERE type <> 'expense';
+DROP TABLE migration_guard;
+
+-- SQLite can't drop a column which references another table, so the table is rebuilt.
+
+CREATE TABLE transactions_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    transaction_date DATE NOT NULL,
+    currency TEXT NOT NULL DEFAULT 'INR',
+    amount INTEGER NOT NULL,
+    category TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    confirm BOOLEAN NOT NULL DEFAULT false,
+    needs_reparse BOOLEAN NOT NULL DEFAULT false,
+    entry_id INTEGER REFERENCES entries(id),
+    account_id INTEGER REFERENCES accounts(id) ON DELETE SET NULL
+);
+
+INSERT INTO transactions_old (id, created_at, transaction_date, currency, amount, category, description, confirm, needs_reparse, entry_id, account_id)
+SELECT id, created_at, transaction_date, currency, amount, category, description, confirm, needs_reparse, entry_id, account_id FROM transactions;
+
+DROP TABLE transactions;
+ALTER TABLE transactions_old RENAME TO transactions;
+
+CREATE INDEX idx_transactions_account_id ON transactions(account_id);
@@ -0,0 +1,6 @@
+-- Transactions were all expenses. Income adds to an account, and a transfer moves
+-- money from account_id to transfer_account_id.
+ALTER TABLE transactions ADD COLUMN type TEXT NOT NULL DEFAULT 'expense' CHECK (type IN ('expense', 'income', 'transfer'));
+ALTER TABLE transactions ADD COLUMN transfer_account_id INTEGER REFERENCES accounts(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_transactions_transfer_account_id ON transactions(transfer_account_id);
@@ -0,0 +1,13 @@
+-- A budget caps the expenses of a category in every period. With rollover, the
+-- unspent amount of the previous period (or the overspend) carries over.
+CREATE TABLE budgets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    category TEXT NOT NULL COLLATE NOCASE,
+    period TEXT NOT NULL DEFAULT 'monthly' CHECK (period IN ('weekly', 'monthly', 'yearly')),
+    -- In minor units of the budget's currency.
+    amount INTEGER NOT NULL,
+    currency TEXT NOT NULL DEFAULT 'INR',
+    rollover BOOLEAN NOT NULL DEFAULT false,
+    UNIQUE (category, period)
+);
@@ -0,0 +1,27 @@
+-- Recurring rules create an unconfirmed transaction on every due date. The rule
+-- is in RRULE syntax, eg: FREQ=MONTHLY;INTERVAL=1, and repeats from start_date.
+-- next_due is the date of the next transaction, or NULL once the rule has ended.
+CREATE TABLE recurring_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    description TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT 'expense' CHECK (type IN ('expense', 'income')),
+    -- In minor units of the currency.
+    amount INTEGER NOT NULL,
+    currency TEXT NOT NULL DEFAULT 'INR',
+    account_id INTEGER REFERENCES accounts(id) ON DELETE SET NULL,
+    rrule TEXT NOT NULL,
+    start_date DATE NOT NULL,
+    next_due DATE,
+    -- The number of occurrences which are due before next_due.
+    occurrences INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_recurring_rules_next_due ON recurring_rules(next_due);
+
+ALTER TABLE transactions ADD COLUMN recurring_rule_id INTEGER REFERENCES recurring_rules(id) ON DELETE SET NULL;
+
+-- A rule creates a single transaction per date, even if the scheduler runs again
+-- after a crash. NULLs are distinct, so other transactions aren't affected.
+CREATE UNIQUE INDEX idx_transactions_recurring_rule_id ON transactions(recurring_rule_id, transaction_date);
@@ -0,0 +1,3 @@
+-- The names of the categories of transactions are left as they are.
+DROP TABLE category_aliases;
+DROP TABLE categories;
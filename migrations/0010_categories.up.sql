@@ -0,0 +1,77 @@
+-- Categories are the managed list of categories which transactions are filed under.
+-- A category can have a parent, eg: groceries is under food, so that reports can roll
+-- up to the parent. Transactions keep the name of their category.
+CREATE TABLE categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    parent_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+    -- A hex color like #e67e22 to show the category with.
+    color TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_categories_parent_id ON categories(parent_id);
+
+-- Aliases are other names of a category, eg: dining for restaurants. Categories
+-- which are an alias are saved as the category instead.
+CREATE TABLE category_aliases (
+    alias TEXT PRIMARY KEY COLLATE NOCASE,
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_category_aliases_category_id ON category_aliases(category_id);
+
+INSERT INTO categories (name, color) VALUES
+    ('food', '#e67e22'),
+    ('travel', '#3498db'),
+    ('housing', '#8e44ad'),
+    ('shopping', '#e84393'),
+    ('entertainment', '#9b59b6'),
+    ('health', '#27ae60'),
+    ('education', '#16a085'),
+    ('income', '#2ecc71'),
+    ('transfer', '#95a5a6'),
+    ('misc', '#7f8c8d');
+
+INSERT INTO categories (name, parent_id, color) VALUES
+    ('groceries', (SELECT id FROM categories WHERE name = 'food'), '#d35400'),
+    ('restaurants', (SELECT id FROM categories WHERE name = 'food'), '#f39c12'),
+    ('fuel', (SELECT id FROM categories WHERE name = 'travel'), '#2980b9'),
+    ('rent', (SELECT id FROM categories WHERE name = 'housing'), '#6c3483'),
+    ('utilities', (SELECT id FROM categories WHERE name = 'housing'), '#a569bd'),
+    ('subscriptions', (SELECT id FROM categories WHERE name = 'entertainment'), '#bb8fce'),
+    ('salary', (SELECT id FROM categories WHERE name = 'income'), '#229954');
+
+INSERT INTO category_aliases (alias, category_id) VALUES
+    ('dining', (SELECT id FROM categories WHERE name = 'restaurants')),
+    ('eating out', (SELECT id FROM categories WHERE name = 'restaurants')),
+    ('grocery', (SELECT id FROM categories WHERE name = 'groceries')),
+    ('transport', (SELECT id FROM categories WHERE name = 'travel')),
+    ('commute', (SELECT id FROM categories WHERE name = 'travel')),
+    ('petrol', (SELECT id FROM categories WHERE name = 'fuel')),
+    ('bills', (SELECT id FROM categories WHERE name = 'utilities')),
+    ('clothing', (SELECT id FROM categories WHERE name = 'shopping')),
+    ('medical', (SELECT id FROM categories WHERE name = 'health')),
+    ('other', (SELECT id FROM categories WHERE name = 'misc')),
+    ('miscellaneous', (SELECT id FROM categories WHERE name = 'misc'));
+
+-- File the existing transactions, budgets and recurring rules under the name of their
+-- category, so that "Food" and "dining" aren't reported apart from food and restaurants.
+UPDATE transactions SET category = COALESCE(
+    (SELECT c.name FROM categories c WHERE c.name = transactions.category),
+    (SELECT c.name FROM category_aliases a JOIN categories c ON c.id = a.category_id WHERE a.alias = transactions.category),
+    category
+);
+
+-- Budgets are only renamed to the case of their category, a budget for an alias
+-- could clash with a budget for the category.
+UPDATE budgets SET category = COALESCE(
+    (SELECT c.name FROM categories c WHERE c.name = budgets.category),
+    category
+);
+
+UPDATE recurring_rules SET category = COALESCE(
+    (SELECT c.name FROM categories c WHERE c.name = recurring_rules.category),
+    (SELECT c.name FROM category_aliases a JOIN categories c ON c.id = a.category_id WHERE a.alias = recurring_rules.category),
+    category
+);
@@ -0,0 +1,5 @@
+DROP TRIGGER category_corrections_au;
+DROP TRIGGER category_corrections_ad;
+DROP TRIGGER category_corrections_ai;
+DROP TABLE category_corrections_fts;
+DROP TABLE category_corrections;
@@ -0,0 +1,30 @@
+-- Category corrections are the categories which the user changed transactions to,
+-- by description. The corrections of similar descriptions are offered to the LLM as
+-- examples when parsing, so that it files expenses the way the user would.
+CREATE TABLE category_corrections (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    description TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    category TEXT NOT NULL
+);
+
+-- The trigram tokenizer matches parts of words, eg: "instamart" matches "swiggy instamart".
+CREATE VIRTUAL TABLE category_corrections_fts USING fts5(
+    description,
+    content = 'category_corrections',
+    content_rowid = 'id',
+    tokenize = 'trigram'
+);
+
+CREATE TRIGGER category_corrections_ai AFTER INSERT ON category_corrections BEGIN
+    INSERT INTO category_corrections_fts (rowid, description) VALUES (new.id, new.description);
+END;
+
+CREATE TRIGGER category_corrections_ad AFTER DELETE ON category_corrections BEGIN
+    INSERT INTO category_corrections_fts (category_corrections_fts, rowid, description) VALUES ('delete', old.id, old.description);
+END;
+
+CREATE TRIGGER category_corrections_au AFTER UPDATE ON category_corrections BEGIN
+    INSERT INTO category_corrections_fts (category_corrections_fts, rowid, description) VALUES ('delete', old.id, old.description);
+    INSERT INTO category_corrections_fts (rowid, description) VALUES (new.id, new.description);
+END;
@@ -0,0 +1,25 @@
+-- Rules file the transactions which match their conditions the way the user wants,
+-- eg: descriptions matching swiggy|zomato go under food-delivery. They're applied in
+-- order of priority when transactions are saved, overriding the LLM.
+CREATE TABLE rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    name TEXT NOT NULL DEFAULT '',
+    priority INTEGER NOT NULL DEFAULT 0,
+
+    -- Conditions, all the ones which are set have to match.
+    -- A regular expression in Go's syntax, eg: (?i)swiggy|zomato.
+    description_pattern TEXT NOT NULL DEFAULT '',
+    -- In minor units of the rule's currency, or of the default currency if it isn't set.
+    min_amount INTEGER,
+    max_amount INTEGER,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+    currency TEXT NOT NULL DEFAULT '',
+
+    -- Actions.
+    set_category TEXT NOT NULL DEFAULT '',
+    -- Comma separated tags to add.
+    add_tags TEXT NOT NULL DEFAULT '',
+    set_account_id INTEGER REFERENCES accounts(id) ON DELETE SET NULL,
+    confirm BOOLEAN NOT NULL DEFAULT false
+);
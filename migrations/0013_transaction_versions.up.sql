@@ -0,0 +1,3 @@
+-- The version of a transaction goes up on every update. It's the ETag of the
+-- transaction, so that an update based on an older version can be rejected.
+ALTER TABLE transactions ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
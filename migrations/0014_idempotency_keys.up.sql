@@ -0,0 +1,12 @@
+-- Idempotency keys sent with new transactions, so that a retried request returns the
+-- response of the original one instead of saving the transactions again.
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    -- The hash of the request, as a key can't be reused for another request.
+    request_hash TEXT NOT NULL,
+    -- The entry which the request saved. It's set along with the transactions.
+    entry_id INTEGER REFERENCES entries(id) ON DELETE CASCADE,
+    -- The response which is returned again, set once the request is done.
+    response TEXT NOT NULL DEFAULT ''
+);
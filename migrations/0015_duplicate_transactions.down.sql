@@ -0,0 +1,3 @@
+DROP TRIGGER transactions_duplicate_of_ad;
+DROP INDEX idx_transactions_duplicate_of;
+ALTER TABLE transactions DROP COLUMN duplicate_of;
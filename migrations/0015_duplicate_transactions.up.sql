@@ -0,0 +1,11 @@
+-- A transaction which looks like a duplicate of an existing one, like the same coffee
+-- logged from two devices, points to it until it's merged or dismissed. It's not a
+-- foreign key, as SQLite can't drop such a column without rebuilding the table, the
+-- trigger clears it instead when the original is deleted.
+ALTER TABLE transactions ADD COLUMN duplicate_of INTEGER;
+
+CREATE INDEX idx_transactions_duplicate_of ON transactions(duplicate_of) WHERE duplicate_of IS NOT NULL;
+
+CREATE TRIGGER transactions_duplicate_of_ad AFTER DELETE ON transactions BEGIN
+    UPDATE transactions SET duplicate_of = NULL WHERE duplicate_of = old.id;
+END;
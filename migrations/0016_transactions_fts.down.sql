@@ -0,0 +1,6 @@
+DROP TRIGGER transaction_tags_fts_ad;
+DROP TRIGGER transaction_tags_fts_ai;
+DROP TRIGGER transactions_fts_ad;
+DROP TRIGGER transactions_fts_au;
+DROP TRIGGER transactions_fts_ai;
+DROP TABLE transactions_fts;
@@ -0,0 +1,48 @@
+-- The full text index of transactions, over their description, category, tags and the
+-- line they were parsed from. The tags and the line are in other tables, so the index
+-- keeps its own copy of the text, which the triggers keep in sync.
+CREATE VIRTUAL TABLE transactions_fts USING fts5(
+    description,
+    category,
+    tags,
+    line,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO transactions_fts (rowid, description, category, tags, line)
+SELECT
+    t.id,
+    t.description,
+    t.category,
+    COALESCE((SELECT group_concat(tg.name, ' ') FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tt.transaction_id = t.id), ''),
+    COALESCE((SELECT e.line FROM entries e WHERE e.id = t.entry_id), '')
+FROM transactions t;
+
+CREATE TRIGGER transactions_fts_ai AFTER INSERT ON transactions BEGIN
+    INSERT INTO transactions_fts (rowid, description, category, tags, line)
+    VALUES (new.id, new.description, new.category, '', COALESCE((SELECT line FROM entries WHERE id = new.entry_id), ''));
+END;
+
+CREATE TRIGGER transactions_fts_au AFTER UPDATE OF description, category, entry_id ON transactions BEGIN
+    UPDATE transactions_fts
+    SET description = new.description,
+        category = new.category,
+        line = COALESCE((SELECT line FROM entries WHERE id = new.entry_id), '')
+    WHERE rowid = new.id;
+END;
+
+CREATE TRIGGER transactions_fts_ad AFTER DELETE ON transactions BEGIN
+    DELETE FROM transactions_fts WHERE rowid = old.id;
+END;
+
+CREATE TRIGGER transaction_tags_fts_ai AFTER INSERT ON transaction_tags BEGIN
+    UPDATE transactions_fts
+    SET tags = COALESCE((SELECT group_concat(tg.name, ' ') FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tt.transaction_id = new.transaction_id), '')
+    WHERE rowid = new.transaction_id;
+END;
+
+CREATE TRIGGER transaction_tags_fts_ad AFTER DELETE ON transaction_tags BEGIN
+    UPDATE transactions_fts
+    SET tags = COALESCE((SELECT group_concat(tg.name, ' ') FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tt.transaction_id = old.transaction_id), '')
+    WHERE rowid = old.transaction_id;
+END;
@@ -0,0 +1,16 @@
+-- Attachments are files kept next to a transaction, like the photo of its receipt. The
+-- content is stored on disk by its SHA-256 hash, so that a receipt attached to each of
+-- the items on it is stored once.
+CREATE TABLE attachments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    mime_type TEXT NOT NULL,
+    -- In bytes.
+    size INTEGER NOT NULL,
+    sha256 TEXT NOT NULL
+);
+
+CREATE INDEX idx_attachments_transaction_id ON attachments(transaction_id);
+CREATE INDEX idx_attachments_sha256 ON attachments(sha256);
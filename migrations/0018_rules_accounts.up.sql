@@ -0,0 +1,32 @@
+-- Deleting an account used to delete the rules which match it and turn off the ones
+-- which set it, silently changing how transactions are filed. An account can't be
+-- deleted while rules use it instead. SQLite can't change a foreign key, so the table
+-- is rebuilt.
+CREATE TABLE rules_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    name TEXT NOT NULL DEFAULT '',
+    priority INTEGER NOT NULL DEFAULT 0,
+
+    -- Conditions, all the ones which are set have to match.
+    -- A regular expression in Go's syntax, eg: (?i)swiggy|zomato.
+    description_pattern TEXT NOT NULL DEFAULT '',
+    -- In minor units of the rule's currency, or of the default currency if it isn't set.
+    min_amount INTEGER,
+    max_amount INTEGER,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE RESTRICT,
+    currency TEXT NOT NULL DEFAULT '',
+
+    -- Actions.
+    set_category TEXT NOT NULL DEFAULT '',
+    -- Comma separated tags to add.
+    add_tags TEXT NOT NULL DEFAULT '',
+    set_account_id INTEGER REFERENCES accounts(id) ON DELETE RESTRICT,
+    confirm BOOLEAN NOT NULL DEFAULT false
+);
+
+INSERT INTO rules_new (id, created_at, name, priority, description_pattern, min_amount, max_amount, account_id, currency, set_category, add_tags, set_account_id, confirm)
+SELECT id, created_at, name, priority, description_pattern, min_amount, max_amount, account_id, currency, set_category, add_tags, set_account_id, confirm FROM rules;
+
+DROP TABLE rules;
+ALTER TABLE rules_new RENAME TO rules;
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// etag returns the ETag of a version of a transaction.
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatch returns the version of the transaction in the If-Match header. It's nil
+// when there's no header or it's *, which matches any version.
+func ifMatch(c echo.Context) (*int64, error) {
+	h := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if h == "" || h == "*" {
+		return nil, nil
+	}
+
+	// Weak ETags are compared like strong ones, there's only one representation.
+	v, err := strconv.Unquote(strings.TrimPrefix(h, "W/"))
+	if err != nil {
+		return nil, errors.New("invalid If-Match header, use the ETag of the transaction")
+	}
+	version, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid If-Match header, use the ETag of the transaction")
+	}
+	return &version, nil
+}
+
+// patchItem applies a JSON merge patch to the transaction. The tags are only replaced
+// when the patch has them, and null removes all of them. The fields which can't be
+// updated, like the ID, are ignored.
+func patchItem(item models.Item, patch []byte) (models.Item, error) {
+	dec := json.NewDecoder(bytes.NewReader(patch))
+	// Numbers are kept as they are, so that amounts aren't rounded through a float.
+	dec.UseNumber()
+
+	var p map[string]any
+	if err := dec.Decode(&p); err != nil || p == nil {
+		return models.Item{}, errors.New("invalid input, the patch should be a JSON object")
+	}
+	for _, f := range []string{"amount", "transaction_date"} {
+		if v, ok := p[f]; ok && v == nil {
+			return models.Item{}, fmt.Errorf("%s can't be removed", f)
+		}
+	}
+
+	b, err := json.Marshal(item)
+	if err != nil {
+		return models.Item{}, err
+	}
+	var doc map[string]any
+	dec = json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return models.Item{}, err
+	}
+
+	if b, err = json.Marshal(mergePatch(doc, p)); err != nil {
+		return models.Item{}, err
+	}
+	var out models.Item
+	if err := json.Unmarshal(b, &out); err != nil {
+		return models.Item{}, fmt.Errorf("invalid input: %w", err)
+	}
+
+	if tags, ok := p["tags"]; !ok {
+		out.Tags = nil
+	} else if tags == nil {
+		out.Tags = []string{}
+	}
+	return out, nil
+}
+
+// mergePatch applies a JSON merge patch to target as described in RFC 7386.
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
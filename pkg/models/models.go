@@ -1,16 +1,275 @@
 package models
 
+// Types of a transaction.
+const (
+	TypeExpense  = "expense"
+	TypeIncome   = "income"
+	TypeTransfer = "transfer"
+)
+
 type Item struct {
 	ID              int64   `json:"id"`
 	CreatedAt       string  `json:"created_at"`
 	TransactionDate string  `json:"transaction_date"`
 	Currency        string  `json:"currency"`
-	Amount          float64 `json:"amount"`
+	Amount          Decimal `json:"amount"`
 	Category        string  `json:"category"`
 	Description     string  `json:"description"`
 	Confirm         bool    `json:"confirm"`
+	NeedsReparse    bool    `json:"needs_reparse"`
+	EntryID         *int64  `json:"entry_id"`
+	AccountID       *int64  `json:"account_id"`
+
+	// Type is one of expense, income or transfer. A transfer moves the amount
+	// from AccountID to TransferAccountID.
+	Type              string `json:"type"`
+	TransferAccountID *int64 `json:"transfer_account_id"`
+
+	// RecurringRuleID is the recurring rule which created the transaction.
+	RecurringRuleID *int64 `json:"recurring_rule_id"`
+
+	// Version goes up on every update of the transaction. It's sent as the ETag.
+	Version int64 `json:"version"`
+
+	// DuplicateOf is the transaction which this one looks like a duplicate of, until
+	// it's merged into it or dismissed.
+	DuplicateOf *int64 `json:"duplicate_of"`
+
+	// Tags are labels like trip-goa or reimbursable, taken from the hashtags in the input.
+	Tags []string `json:"tags"`
+
+	// Account is the account or payment method mentioned in the input (eg: HDFC card).
+	// It's resolved to AccountID when the transaction is saved, as is TransferAccount
+	// to TransferAccountID.
+	Account         string `json:"account,omitempty"`
+	TransferAccount string `json:"transfer_account,omitempty"`
+
+	// Confidence is how sure the model is of the parsed transaction, from 0 to 1. It
+	// decides whether the transaction is confirmed automatically when it's saved.
+	Confidence float64 `json:"confidence,omitempty"`
+
+	// Attachments are the files attached to the transaction when it's saved, like the
+	// photo of its receipt.
+	Attachments []Attachment `json:"attachments,omitempty"`
 }
 
 type Transactions struct {
 	Transactions []Item `json:"transactions"`
 }
+
+type Account struct {
+	ID             int64   `json:"id"`
+	CreatedAt      string  `json:"created_at"`
+	Name           string  `json:"name"`
+	Kind           string  `json:"kind"`
+	Currency       string  `json:"currency"`
+	OpeningBalance Decimal `json:"opening_balance"`
+}
+
+type Budget struct {
+	ID        int64   `json:"id"`
+	CreatedAt string  `json:"created_at"`
+	Category  string  `json:"category"`
+	Period    string  `json:"period"`
+	Amount    Decimal `json:"amount"`
+	Currency  string  `json:"currency"`
+	Rollover  bool    `json:"rollover"`
+}
+
+type RecurringRule struct {
+	ID          int64   `json:"id"`
+	CreatedAt   string  `json:"created_at"`
+	Description string  `json:"description"`
+	Category    string  `json:"category"`
+	Type        string  `json:"type"`
+	Amount      Decimal `json:"amount"`
+	Currency    string  `json:"currency"`
+	AccountID   *int64  `json:"account_id"`
+	RRule       string  `json:"rrule"`
+	StartDate   string  `json:"start_date"`
+	// NextDue is the date of the next transaction, empty once the rule has ended.
+	NextDue string `json:"next_due"`
+}
+
+type Category struct {
+	ID        int64  `json:"id"`
+	CreatedAt string `json:"created_at"`
+	Name      string `json:"name"`
+	ParentID  *int64 `json:"parent_id"`
+	Color     string `json:"color"`
+	// Aliases are other names of the category, eg: dining for restaurants.
+	Aliases []string `json:"aliases"`
+}
+
+// Rule sets the category, tags, account or confirmation of the transactions which
+// match all of its conditions. Conditions which aren't set match any transaction.
+type Rule struct {
+	ID        int64  `json:"id"`
+	CreatedAt string `json:"created_at"`
+	Name      string `json:"name"`
+	// Rules are applied in order of priority, so that later rules win.
+	Priority int64 `json:"priority"`
+
+	// DescriptionPattern is a regular expression, eg: /swiggy|zomato/i.
+	DescriptionPattern string `json:"description_pattern"`
+	// MinAmount and MaxAmount are inclusive.
+	MinAmount *Decimal `json:"min_amount"`
+	MaxAmount *Decimal `json:"max_amount"`
+	AccountID *int64   `json:"account_id"`
+	Currency  string   `json:"currency"`
+
+	SetCategory  string   `json:"set_category"`
+	AddTags      []string `json:"add_tags"`
+	SetAccountID *int64   `json:"set_account_id"`
+	Confirm      bool     `json:"confirm"`
+}
+
+// RuleChange is a transaction as it is and as it would be after a rule is applied.
+type RuleChange struct {
+	Before Item `json:"before"`
+	After  Item `json:"after"`
+}
+
+// Bulk actions on transactions.
+const (
+	BulkConfirm     = "confirm"
+	BulkDelete      = "delete"
+	BulkSetCategory = "set_category"
+	BulkAddTag      = "add_tag"
+	BulkRemoveTag   = "remove_tag"
+	BulkSetAccount  = "set_account"
+	BulkSetDate     = "set_date"
+)
+
+// TransactionFilter selects transactions like the filters of the transactions list.
+// Conditions which aren't set match any transaction.
+type TransactionFilter struct {
+	Confirm      *bool  `json:"confirm"`
+	NeedsReparse *bool  `json:"needs_reparse"`
+	StartDate    string `json:"start_date"`
+	EndDate      string `json:"end_date"`
+	AccountID    *int64 `json:"account_id"`
+	Type         string `json:"type"`
+	Tag          string `json:"tag"`
+	// Category matches the category along with its subcategories.
+	Category string `json:"category"`
+	Currency string `json:"currency"`
+	// MinAmount and MaxAmount are compared with the amount in the currency of the transaction.
+	MinAmount *Decimal `json:"min_amount"`
+	MaxAmount *Decimal `json:"max_amount"`
+	// Description matches the transactions whose description contains it, ignoring case.
+	Description string `json:"description"`
+}
+
+// BulkRequest applies an action to the transactions with the IDs, or the ones which
+// match the filter.
+type BulkRequest struct {
+	IDs    []int64            `json:"ids"`
+	Filter *TransactionFilter `json:"filter"`
+	Action string             `json:"action"`
+
+	// The arguments of the actions: the category of set_category, the tag of add_tag
+	// and remove_tag, the account of set_account and the date of set_date.
+	Category        string `json:"category"`
+	Tag             string `json:"tag"`
+	AccountID       *int64 `json:"account_id"`
+	TransactionDate string `json:"transaction_date"`
+}
+
+// BulkResult is the outcome of a bulk action on a transaction.
+type BulkResult struct {
+	ID    int64  `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Duplicate is a transaction which looks like a duplicate, along with its original.
+type Duplicate struct {
+	Transaction Item `json:"transaction"`
+	Original    Item `json:"original"`
+}
+
+// SearchResult is a transaction which matches a search, along with the text it matched.
+type SearchResult struct {
+	Item
+	// Snippet is the matching text, with the matches in <mark> tags. The rest of the
+	// text is HTML escaped.
+	Snippet string `json:"snippet"`
+	// Score is the relevance of the transaction, higher for a better match.
+	Score float64 `json:"score"`
+}
+
+// Metrics and groupings of a question about the transactions.
+const (
+	MetricTotal   = "total"
+	MetricCount   = "count"
+	MetricAverage = "average"
+
+	GroupByCategory = "category"
+	GroupByTag      = "tag"
+	GroupByAccount  = "account"
+	GroupByType     = "type"
+	GroupByDay      = "day"
+	GroupByWeek     = "week"
+	GroupByMonth    = "month"
+)
+
+// QueryPlan is a question about the transactions, like "how much on food last month?",
+// as a structured query. Filters which aren't set match any transaction.
+type QueryPlan struct {
+	// Metric is one of total, count or average.
+	Metric string `json:"metric"`
+	// GroupBy breaks the metric down by category, tag, account, type, day, week or
+	// month. It's empty for a single number.
+	GroupBy string `json:"group_by"`
+	// Type of the transactions, expense when it's empty.
+	Type        string   `json:"type"`
+	Categories  []string `json:"categories"`
+	Tags        []string `json:"tags"`
+	Accounts    []string `json:"accounts"`
+	Description string   `json:"description"`
+	StartDate   string   `json:"start_date"`
+	EndDate     string   `json:"end_date"`
+	// Limit is the number of groups in the answer, the largest ones.
+	Limit int `json:"limit"`
+}
+
+// Answer is the answer to a question about the transactions, along with the numbers
+// it's based on. The amounts are in Currency.
+type Answer struct {
+	Question string        `json:"question"`
+	Answer   string        `json:"answer"`
+	Plan     QueryPlan     `json:"plan"`
+	Currency string        `json:"currency"`
+	Total    Decimal       `json:"total"`
+	Count    int64         `json:"count"`
+	Average  Decimal       `json:"average"`
+	Groups   []AnswerGroup `json:"groups"`
+}
+
+// AnswerGroup is the part of an answer for a group, like a category or a month.
+type AnswerGroup struct {
+	Key     string  `json:"key"`
+	Total   Decimal `json:"total"`
+	Count   int64   `json:"count"`
+	Average Decimal `json:"average"`
+}
+
+// Attachment is a file kept next to a transaction, like the photo of its receipt.
+type Attachment struct {
+	ID            int64  `json:"id"`
+	CreatedAt     string `json:"created_at"`
+	TransactionID int64  `json:"transaction_id"`
+	Filename      string `json:"filename"`
+	MIMEType      string `json:"mime_type"`
+	// Size is in bytes.
+	Size int64 `json:"size"`
+	// SHA256 is the hash of the content, which it's stored by.
+	SHA256 string `json:"sha256"`
+}
+
+// VoiceNote is the transcript of a voice note along with the transactions parsed from it.
+type VoiceNote struct {
+	Transcript   string `json:"transcript"`
+	Transactions []Item `json:"transactions"`
+}
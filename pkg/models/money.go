@@ -0,0 +1,245 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit isn't
+// 1/100th of the major unit. All other currencies have two decimal places.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyExponent returns the number of decimal places in the minor unit of
+// the ISO 4217 currency code.
+func CurrencyExponent(currency string) int {
+	if e, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return e
+	}
+	return 2
+}
+
+// CurrencyExponents returns the ISO 4217 currencies whose minor unit isn't
+// 1/100th of the major unit, along with their number of decimal places.
+func CurrencyExponents() map[string]int {
+	return maps.Clone(currencyExponents)
+}
+
+// MaxDecimalPlaces is the number of digits a Decimal can have after the decimal point.
+// Amounts are rescaled by powers of ten, and 10^18 is the largest which fits an int64.
+const MaxDecimalPlaces = 18
+
+// errDecimalOverflow is returned when a Decimal doesn't fit an int64 coefficient.
+var errDecimalOverflow = errors.New("decimal overflow")
+
+// Decimal is an exact base 10 number. Amounts are exchanged as Decimal in the
+// API so that they never pass through a float64, and are stored in the database
+// as integer minor units of their currency.
+type Decimal struct {
+	// The value is coef * 10^-exp.
+	coef int64
+	exp  int
+}
+
+// NewDecimal returns coef * 10^-exp.
+func NewDecimal(coef int64, exp int) Decimal {
+	return Decimal{coef: coef, exp: exp}
+}
+
+// FromMinor converts an amount in minor units of the currency to a Decimal.
+func FromMinor(minor int64, currency string) Decimal {
+	return Decimal{coef: minor, exp: CurrencyExponent(currency)}
+}
+
+// ParseDecimal parses a number like "1200", "-12.50" or ".005".
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Decimal{}, errors.New("empty decimal")
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if strings.ContainsAny(frac, "+-") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	if len(frac) > MaxDecimalPlaces {
+		return Decimal{}, fmt.Errorf("invalid decimal %q, it can have at most %d decimal places", s, MaxDecimalPlaces)
+	}
+	coef, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	return Decimal{coef: coef, exp: len(frac)}, nil
+}
+
+// Minor converts the amount to minor units of the currency, rounding half away from zero.
+func (d Decimal) Minor(currency string) (int64, error) {
+	return d.rescale(CurrencyExponent(currency))
+}
+
+// MulPow10 returns d * 10^n.
+func (d Decimal) MulPow10(n int) Decimal {
+	d.exp -= n
+	return d.normalize()
+}
+
+// Add returns d + o. It fails if the sum doesn't fit a Decimal.
+func (d Decimal) Add(o Decimal) (Decimal, error) {
+	exp := max(d.exp, o.exp)
+	a, err := d.rescale(exp)
+	if err != nil {
+		return Decimal{}, err
+	}
+	b, err := o.rescale(exp)
+	if err != nil {
+		return Decimal{}, err
+	}
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return Decimal{}, errDecimalOverflow
+	}
+	return Decimal{coef: a + b, exp: exp}, nil
+}
+
+// Cmp returns -1, 0 or 1 if d is less than, equal to or greater than o. It fails if
+// they can't be brought to the same number of decimal places.
+func (d Decimal) Cmp(o Decimal) (int, error) {
+	exp := max(d.exp, o.exp)
+	a, err := d.rescale(exp)
+	if err != nil {
+		return 0, err
+	}
+	b, err := o.rescale(exp)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case a < b:
+		return -1, nil
+	case a > b:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// IsZero reports whether the value is zero.
+func (d Decimal) IsZero() bool {
+	return d.coef == 0
+}
+
+// Sign returns -1, 0 or 1 depending on the sign of d.
+func (d Decimal) Sign() int {
+	switch {
+	case d.coef < 0:
+		return -1
+	case d.coef > 0:
+		return 1
+	}
+	return 0
+}
+
+// Float64 returns the nearest float64. Only use this for display or ratios,
+// never for storing amounts.
+func (d Decimal) Float64() float64 {
+	return float64(d.coef) / math.Pow10(d.exp)
+}
+
+func (d Decimal) String() string {
+	d = d.normalize()
+	if d.exp <= 0 {
+		return strconv.FormatInt(d.coef, 10)
+	}
+
+	sign := ""
+	coef := d.coef
+	if coef < 0 {
+		sign = "-"
+		coef = -coef
+	}
+
+	s := strconv.FormatInt(coef, 10)
+	if len(s) <= d.exp {
+		s = strings.Repeat("0", d.exp-len(s)+1) + s
+	}
+	return sign + s[:len(s)-d.exp] + "." + s[len(s)-d.exp:]
+}
+
+// MarshalJSON encodes the value as a JSON number.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a string containing a number.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+
+	// Some models answer with exponents, eg: 1.2e3.
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid decimal %q", s)
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// rescale returns the coefficient of d with exp decimal places, rounding half away from zero.
+func (d Decimal) rescale(exp int) (int64, error) {
+	if d.exp <= exp {
+		c := d.coef
+		for i := d.exp; i < exp; i++ {
+			if c > math.MaxInt64/10 || c < math.MinInt64/10 {
+				return 0, errDecimalOverflow
+			}
+			c *= 10
+		}
+		return c, nil
+	}
+
+	p := int64(1)
+	for i := exp; i < d.exp; i++ {
+		if p > math.MaxInt64/10 {
+			return 0, errDecimalOverflow
+		}
+		p *= 10
+	}
+	q, r := d.coef/p, d.coef%p
+	if r*2 >= p {
+		q++
+	} else if r*2 <= -p {
+		q--
+	}
+	return q, nil
+}
+
+// normalize strips trailing zeros after the decimal point and makes exp non-negative.
+func (d Decimal) normalize() Decimal {
+	for d.exp < 0 {
+		d.coef *= 10
+		d.exp++
+	}
+	for d.exp > 0 && d.coef%10 == 0 {
+		d.coef /= 10
+		d.exp--
+	}
+	return d
+}
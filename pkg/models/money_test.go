@@ -0,0 +1,140 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "1200", want: "1200"},
+		{in: "-12.50", want: "-12.5"},
+		{in: ".005", want: "0.005"},
+		{in: "0." + strings.Repeat("0", 17) + "1", want: "0." + strings.Repeat("0", 17) + "1"},
+		{in: "0." + strings.Repeat("0", 18) + "1", wantErr: true},
+		{in: "0." + strings.Repeat("0", 70) + "1", wantErr: true},
+		{in: "9223372036854775807", want: "9223372036854775807"},
+		{in: "9223372036854775808", wantErr: true},
+		{in: "1.-5", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDecimal(%q) = %s, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDecimal(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalJSONTooManyPlaces(t *testing.T) {
+	for _, in := range []string{`0.` + strings.Repeat("0", 70) + `1`, `"1e-70"`} {
+		var d Decimal
+		if err := d.UnmarshalJSON([]byte(in)); err == nil {
+			t.Errorf("UnmarshalJSON(%s) = %s, want an error", in, d)
+		}
+	}
+}
+
+func TestMinor(t *testing.T) {
+	tests := []struct {
+		d        Decimal
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{d: NewDecimal(1250, 2), currency: "INR", want: 1250},
+		{d: NewDecimal(12345, 3), currency: "INR", want: 1235},
+		{d: NewDecimal(-12345, 3), currency: "INR", want: -1235},
+		{d: NewDecimal(12344, 3), currency: "INR", want: 1234},
+		{d: NewDecimal(5, 1), currency: "JPY", want: 1},
+		{d: NewDecimal(12, 0), currency: "KWD", want: 12000},
+		{d: NewDecimal(1, MaxDecimalPlaces), currency: "INR", want: 0},
+		{d: NewDecimal(math.MaxInt64, 0), currency: "INR", wantErr: true},
+		{d: NewDecimal(math.MaxInt64/100, 0), currency: "INR", want: math.MaxInt64 / 100 * 100},
+	}
+	for _, tt := range tests {
+		got, err := tt.d.Minor(tt.currency)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s.Minor(%s) = %d, want an error", tt.d, tt.currency, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s.Minor(%s) error: %v", tt.d, tt.currency, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s.Minor(%s) = %d, want %d", tt.d, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestRescale(t *testing.T) {
+	tests := []struct {
+		d       Decimal
+		exp     int
+		want    int64
+		wantErr bool
+	}{
+		{d: NewDecimal(5, 0), exp: 18, want: 5_000_000_000_000_000_000},
+		{d: NewDecimal(10, 0), exp: 18, wantErr: true},
+		{d: NewDecimal(math.MaxInt64, 18), exp: 0, want: 9},
+		{d: NewDecimal(math.MinInt64, 18), exp: 0, want: -9},
+		{d: NewDecimal(1, 19), exp: 0, wantErr: true},
+		{d: NewDecimal(1, 64), exp: 0, wantErr: true},
+		{d: NewDecimal(1, 70), exp: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.d.rescale(tt.exp)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("rescale(%d, %d, %d) = %d, want an error", tt.d.coef, tt.d.exp, tt.exp, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("rescale(%d, %d, %d) error: %v", tt.d.coef, tt.d.exp, tt.exp, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("rescale(%d, %d, %d) = %d, want %d", tt.d.coef, tt.d.exp, tt.exp, got, tt.want)
+		}
+	}
+}
+
+func TestAddCmpOverflow(t *testing.T) {
+	if _, err := NewDecimal(math.MaxInt64, 0).Add(NewDecimal(1, 0)); err == nil {
+		t.Error("MaxInt64 + 1 didn't fail")
+	}
+	if _, err := NewDecimal(1, 0).Add(NewDecimal(1, 70)); err == nil {
+		t.Error("adding a decimal with 70 places didn't fail")
+	}
+	if _, err := NewDecimal(math.MaxInt64, 0).Cmp(NewDecimal(1, 2)); err == nil {
+		t.Error("comparing MaxInt64 at 2 places didn't fail")
+	}
+
+	sum, err := NewDecimal(1250, 2).Add(NewDecimal(5, 3))
+	if err != nil || sum.String() != "12.505" {
+		t.Errorf("12.50 + 0.005 = %s, %v, want 12.505", sum, err)
+	}
+	if c, err := NewDecimal(1250, 2).Cmp(NewDecimal(125, 1)); err != nil || c != 0 {
+		t.Errorf("12.50 cmp 12.5 = %d, %v, want 0", c, err)
+	}
+}
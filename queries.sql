@@ -1,53 +1,127 @@
 -- name: CreateTransaction :many
 -- Inserts a new transaction into the database.
-INSERT INTO transactions (created_at, transaction_date, amount, currency, category, description, confirm)
-VALUES (?, ?, ?, ?, ?, ?, ?)
+INSERT INTO transactions (created_at, transaction_date, amount, currency, category, description, confirm, needs_reparse, entry_id, account_id, type, transfer_account_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
+-- name: ListTransactionsByEntry :many
+-- Retrieves the transactions parsed from an entry.
+SELECT * FROM transactions WHERE entry_id = ? ORDER BY id;
+
 -- name: ListTransactions :many
--- Retrieves transactions optionally filtered by confirmation status and date range.
+-- Retrieves transactions optionally filtered by confirmation status, date range, re-parse flag, account, type, tag,
+-- categories, currency, amount range and description, sorted by date (the default, newest first), amount or category.
+-- The categories are a JSON array. The amount bounds are JSON objects of the bound in minor units of each currency,
+-- with "*" for the currencies which aren't listed. A page starts after the cursor, which is the sort value and ID of
+-- the last transaction of the previous page.
 SELECT *
 FROM transactions
 WHERE (:confirm IS NULL OR confirm = :confirm)
   AND (:start_date IS NULL OR transaction_date >= :start_date)
   AND (:end_date IS NULL OR transaction_date <= :end_date)
-ORDER BY transaction_date DESC, created_at DESC;
+  AND (:needs_reparse IS NULL OR needs_reparse = :needs_reparse)
+  AND (:account_id IS NULL OR account_id = :account_id OR transfer_account_id = :account_id)
+  AND (:type IS NULL OR type = :type)
+  AND (:tag IS NULL OR id IN (SELECT tt.transaction_id FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tg.name = :tag))
+  AND (:categories IS NULL OR lower(category) IN (SELECT lower(value) FROM json_each(:categories)))
+  AND (:currency IS NULL OR currency = :currency)
+  AND (:min_amount IS NULL OR amount >= COALESCE(json_extract(:min_amount, '$."' || currency || '"'), json_extract(:min_amount, '$."*"')))
+  AND (:max_amount IS NULL OR amount <= COALESCE(json_extract(:max_amount, '$."' || currency || '"'), json_extract(:max_amount, '$."*"')))
+  AND (:description IS NULL OR description LIKE '%' || :description || '%' ESCAPE '\')
+  AND (:cursor_id IS NULL OR CASE WHEN COALESCE(:sort, '-date') LIKE '-%'
+    THEN (CASE WHEN :sort IN ('amount', '-amount') THEN amount WHEN :sort IN ('category', '-category') THEN lower(category) ELSE transaction_date END, id) < (:cursor_value, :cursor_id)
+    ELSE (CASE WHEN :sort IN ('amount', '-amount') THEN amount WHEN :sort IN ('category', '-category') THEN lower(category) ELSE transaction_date END, id) > (:cursor_value, :cursor_id)
+  END)
+ORDER BY
+  CASE WHEN :sort = 'date' THEN transaction_date END,
+  CASE WHEN COALESCE(:sort, '-date') = '-date' THEN transaction_date END DESC,
+  CASE WHEN :sort = 'amount' THEN amount END,
+  CASE WHEN :sort = '-amount' THEN amount END DESC,
+  CASE WHEN :sort = 'category' THEN lower(category) END,
+  CASE WHEN :sort = '-category' THEN lower(category) END DESC,
+  CASE WHEN COALESCE(:sort, '-date') LIKE '-%' THEN id END DESC,
+  id
+LIMIT COALESCE(:limit, -1);
+
+-- name: CountTransactions :one
+-- Counts the transactions which match the filters of ListTransactions.
+SELECT COUNT(*)
+FROM transactions
+WHERE (:confirm IS NULL OR confirm = :confirm)
+  AND (:start_date IS NULL OR transaction_date >= :start_date)
+  AND (:end_date IS NULL OR transaction_date <= :end_date)
+  AND (:needs_reparse IS NULL OR needs_reparse = :needs_reparse)
+  AND (:account_id IS NULL OR account_id = :account_id OR transfer_account_id = :account_id)
+  AND (:type IS NULL OR type = :type)
+  AND (:tag IS NULL OR id IN (SELECT tt.transaction_id FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tg.name = :tag))
+  AND (:categories IS NULL OR lower(category) IN (SELECT lower(value) FROM json_each(:categories)))
+  AND (:currency IS NULL OR currency = :currency)
+  AND (:min_amount IS NULL OR amount >= COALESCE(json_extract(:min_amount, '$."' || currency || '"'), json_extract(:min_amount, '$."*"')))
+  AND (:max_amount IS NULL OR amount <= COALESCE(json_extract(:max_amount, '$."' || currency || '"'), json_extract(:max_amount, '$."*"')))
+  AND (:description IS NULL OR description LIKE '%' || :description || '%' ESCAPE '\');
+
+-- name: SearchTransactions :many
+-- Retrieves the transactions which match the full text query, optionally filtered by confirmation status and
+-- date range, the most relevant first. The snippet is the matching text, with the matches between the
+-- characters 0x02 and 0x03. A description match counts the most and a match in the line the least.
+SELECT t.*,
+    CAST(snippet(transactions_fts, -1, char(2), char(3), '…', 12) AS TEXT) AS snippet,
+    CAST(-bm25(transactions_fts, 4.0, 2.0, 2.0, 1.0) AS REAL) AS score
+FROM transactions_fts f
+JOIN transactions t ON t.id = f.rowid
+WHERE transactions_fts MATCH :query
+  AND (:confirm IS NULL OR t.confirm = :confirm)
+  AND (:start_date IS NULL OR t.transaction_date >= :start_date)
+  AND (:end_date IS NULL OR t.transaction_date <= :end_date)
+ORDER BY score DESC, t.transaction_date DESC
+LIMIT :limit;
 
 -- name: GetTransaction :one
 -- Retrieves a single transaction by ID.
 SELECT * FROM transactions WHERE id = ?;
 
--- name: UpdateTransaction :exec
--- Updates a transaction by ID.
+-- name: UpdateTransaction :execrows
+-- Updates a transaction by ID if it's still at the given version, moving it to the next version.
 UPDATE transactions
-SET amount = ?, currency = ?, category = ?, description = ?, confirm = ?, transaction_date = ?
-WHERE id = ?;
+SET amount = ?, currency = ?, category = ?, description = ?, confirm = ?, transaction_date = ?, account_id = ?, type = ?, transfer_account_id = ?, version = version + 1
+WHERE id = ? AND version = ?;
+
+-- name: TouchTransaction :exec
+-- Moves a transaction to the next version, when its tags change.
+UPDATE transactions SET version = version + 1 WHERE id = ?;
 
 -- name: DeleteTransaction :exec
 -- Deletes a transaction by ID.
 DELETE FROM transactions WHERE id = ?;
 
 -- name: TopExpenseCategories :many
--- Retrieves the top expense categories over a specified period.
--- Uses parameters: confirm, startDate, endDate to filter by transaction date range.
+-- Retrieves the total spent on expenses per category, currency and day over a specified period.
+-- Uses parameters: startDate, endDate to filter by transaction date range and
+-- an optional account_id.
+-- Amounts are in minor units of the currency. Totals are per day so that they
+-- can be converted using the exchange rate of that day.
 SELECT
     category,
-    SUM(amount) AS total_spent
+    currency,
+    transaction_date,
+    CAST(COALESCE(SUM(amount), 0) AS INTEGER) AS total_spent
 FROM transactions
-WHERE transaction_date BETWEEN :startDate AND :endDate AND confirm = 1
-GROUP BY category
-ORDER BY total_spent DESC
-LIMIT 5;-- Can be adjusted to show more or fewer categories
+WHERE transaction_date BETWEEN :startDate AND :endDate AND confirm = 1 AND type = 'expense'
+  AND (:account_id IS NULL OR account_id = :account_id)
+GROUP BY category, currency, transaction_date;
 
 
 -- name: DailySpending :many
--- Retrieves the sum total of all transactions for each day within a specified date range.
+-- Retrieves the sum total of all expenses for each day and currency within a specified date range,
+-- optionally for a single account. Amounts are in minor units of the currency.
 SELECT
     transaction_date,
-    SUM(amount) AS total_spent
+    currency,
+    CAST(COALESCE(SUM(amount), 0) AS INTEGER) AS total_spent
 FROM transactions
-WHERE transaction_date BETWEEN :startDate AND :endDate AND confirm = 1
-GROUP BY transaction_date
+WHERE transaction_date BETWEEN :startDate AND :endDate AND confirm = 1 AND type = 'expense'
+  AND (:account_id IS NULL OR account_id = :account_id)
+GROUP BY transaction_date, currency
 ORDER BY transaction_date ASC;
 
 -- TODO: This is not live yet.
@@ -57,7 +131,394 @@ SELECT
     strftime('%Y', transaction_date) AS year,
     strftime('%m', transaction_date) AS month,
     category,
-    SUM(amount) AS total_spent
+    currency,
+    CAST(COALESCE(SUM(amount), 0) AS INTEGER) AS total_spent
+FROM transactions
+WHERE type = 'expense'
+GROUP BY year, month, category, currency
+ORDER BY year DESC, month DESC, total_spent DESC;
+
+-- name: CreateEntry :one
+-- Saves an input line along with the details of how it was parsed.
+INSERT INTO entries (created_at, line, parser, model, prompt_version, latency_ms, prompt_tokens, completion_tokens)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetEntry :one
+-- Retrieves a single entry by ID.
+SELECT * FROM entries WHERE id = ?;
+
+-- name: UpsertExchangeRate :exec
+-- Saves an exchange rate, replacing the existing rate for the same day and pair.
+INSERT INTO exchange_rates (date, base, quote, rate)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (date, base, quote) DO UPDATE SET rate = excluded.rate;
+
+-- name: GetExchangeRate :one
+-- Retrieves the latest rate of a currency pair on or before a date.
+SELECT rate FROM exchange_rates
+WHERE base = :base AND quote = :quote AND date <= :date
+ORDER BY date DESC
+LIMIT 1;
+
+-- name: ListExchangeRateBases :many
+-- Lists the base currencies which have rates. These are used to convert
+-- between two currencies which don't have a direct rate.
+SELECT DISTINCT base FROM exchange_rates ORDER BY base;
+
+-- name: CreateAccount :one
+-- Inserts a new account.
+INSERT INTO accounts (created_at, name, kind, currency, opening_balance)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListAccounts :many
+-- Retrieves all the accounts.
+SELECT * FROM accounts ORDER BY name;
+
+-- name: GetAccount :one
+-- Retrieves a single account by ID.
+SELECT * FROM accounts WHERE id = ?;
+
+-- name: UpdateAccount :one
+-- Updates an account by ID.
+UPDATE accounts
+SET name = ?, kind = ?, currency = ?, opening_balance = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteAccount :exec
+-- Deletes an account by ID. Its transactions are kept without an account.
+DELETE FROM accounts WHERE id = ?;
+
+-- name: CountRulesByAccount :one
+-- Counts the rules which match transactions of the account or move them to it.
+SELECT COUNT(*) FROM rules WHERE account_id = :account_id OR set_account_id = :account_id;
+
+-- name: AccountDailyTotals :many
+-- Retrieves the money which came in to and went out of an account for each day and currency
+-- up to a date. Income and transfers to the account are inflows, expenses and transfers
+-- from it are outflows. Used to compute running balances, so there's no start date.
+SELECT
+    transaction_date,
+    currency,
+    CAST(COALESCE(SUM(CASE WHEN type = 'income' OR (type = 'transfer' AND transfer_account_id = :account_id) THEN amount ELSE 0 END), 0) AS INTEGER) AS inflow,
+    CAST(COALESCE(SUM(CASE WHEN type = 'expense' OR (type = 'transfer' AND account_id = :account_id) THEN amount ELSE 0 END), 0) AS INTEGER) AS outflow
+FROM transactions
+WHERE (account_id = :account_id OR transfer_account_id = :account_id) AND transaction_date <= :end_date AND confirm = 1
+GROUP BY transaction_date, currency
+ORDER BY transaction_date ASC;
+
+-- name: CashFlow :many
+-- Retrieves the total income and expenses for each day and currency within a specified
+-- date range, optionally for a single account. Transfers between accounts are neither.
+SELECT
+    transaction_date,
+    currency,
+    type,
+    CAST(COALESCE(SUM(amount), 0) AS INTEGER) AS total
 FROM transactions
-GROUP BY year, month, category
-ORDER BY year DESC, month DESC, total_spent DESC;
\ No newline at end of file
+WHERE transaction_date BETWEEN :startDate AND :endDate AND confirm = 1 AND type IN ('expense', 'income')
+  AND (:account_id IS NULL OR account_id = :account_id)
+GROUP BY transaction_date, currency, type
+ORDER BY transaction_date ASC;
+
+-- name: CreateBudget :one
+-- Inserts a new budget.
+INSERT INTO budgets (created_at, category, period, amount, currency, rollover)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListBudgets :many
+-- Retrieves all the budgets.
+SELECT * FROM budgets ORDER BY category, period;
+
+-- name: GetBudget :one
+-- Retrieves a single budget by ID.
+SELECT * FROM budgets WHERE id = ?;
+
+-- name: UpdateBudget :one
+-- Updates a budget by ID.
+UPDATE budgets
+SET category = ?, period = ?, amount = ?, currency = ?, rollover = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteBudget :exec
+-- Deletes a budget by ID.
+DELETE FROM budgets WHERE id = ?;
+
+-- name: CreateRecurringRule :one
+-- Inserts a new recurring rule.
+INSERT INTO recurring_rules (created_at, description, category, type, amount, currency, account_id, rrule, start_date, next_due, occurrences)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListRecurringRules :many
+-- Retrieves all the recurring rules.
+SELECT * FROM recurring_rules ORDER BY id;
+
+-- name: GetRecurringRule :one
+-- Retrieves a single recurring rule by ID.
+SELECT * FROM recurring_rules WHERE id = ?;
+
+-- name: UpdateRecurringRule :one
+-- Updates a recurring rule by ID.
+UPDATE recurring_rules
+SET description = ?, category = ?, type = ?, amount = ?, currency = ?, account_id = ?, rrule = ?, start_date = ?, next_due = ?, occurrences = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteRecurringRule :exec
+-- Deletes a recurring rule by ID. Its transactions are kept.
+DELETE FROM recurring_rules WHERE id = ?;
+
+-- name: ListDueRecurringRules :many
+-- Retrieves the recurring rules which are due on or before a date.
+SELECT * FROM recurring_rules WHERE next_due IS NOT NULL AND next_due <= ? ORDER BY next_due, id;
+
+-- name: AdvanceRecurringRule :exec
+-- Moves a recurring rule to its next due date.
+UPDATE recurring_rules SET next_due = ?, occurrences = ? WHERE id = ?;
+
+-- name: CreateRecurringTransaction :execrows
+-- Inserts the unconfirmed transaction of a recurring rule for a date. It does nothing
+-- if the rule already has a transaction on that date.
+INSERT INTO transactions (created_at, transaction_date, amount, currency, category, description, type, account_id, recurring_rule_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: UpsertTag :one
+-- Saves a tag, returning the existing tag of the same name.
+INSERT INTO tags (name) VALUES (?)
+ON CONFLICT (name) DO UPDATE SET name = tags.name
+RETURNING *;
+
+-- name: ListTags :many
+-- Retrieves all the tags along with the number of transactions which have them.
+SELECT tg.id, tg.name, CAST(COUNT(tt.transaction_id) AS INTEGER) AS transactions
+FROM tags tg
+LEFT JOIN transaction_tags tt ON tt.tag_id = tg.id
+GROUP BY tg.id
+ORDER BY tg.name;
+
+-- name: AddTransactionTag :exec
+-- Tags a transaction.
+INSERT INTO transaction_tags (transaction_id, tag_id) VALUES (?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteTransactionTags :exec
+-- Removes all the tags of a transaction.
+DELETE FROM transaction_tags WHERE transaction_id = ?;
+
+-- name: RemoveTransactionTag :exec
+-- Removes a tag from a transaction.
+DELETE FROM transaction_tags
+WHERE transaction_id = :transaction_id AND tag_id IN (SELECT id FROM tags WHERE name = :tag);
+
+-- name: ListTagsByTransaction :many
+-- Retrieves the names of the tags of a transaction.
+SELECT tg.name
+FROM tags tg
+JOIN transaction_tags tt ON tt.tag_id = tg.id
+WHERE tt.transaction_id = ?
+ORDER BY tg.name;
+
+-- name: ListTransactionTags :many
+-- Retrieves the tags of the transactions, optionally only of the ones whose IDs are in
+-- the JSON array ids.
+SELECT tt.transaction_id, tg.name
+FROM transaction_tags tt
+JOIN tags tg ON tg.id = tt.tag_id
+WHERE (:ids IS NULL OR tt.transaction_id IN (SELECT value FROM json_each(:ids)))
+ORDER BY tg.name;
+
+-- name: TagTotals :many
+-- Retrieves the total spent on expenses and the number of expenses per tag, currency
+-- and day over a specified period, optionally for a single account. Amounts are in
+-- minor units of the currency.
+SELECT
+    tg.name AS tag,
+    t.currency,
+    t.transaction_date,
+    CAST(COUNT(*) AS INTEGER) AS transactions,
+    CAST(COALESCE(SUM(t.amount), 0) AS INTEGER) AS total_spent
+FROM transactions t
+JOIN transaction_tags tt ON tt.transaction_id = t.id
+JOIN tags tg ON tg.id = tt.tag_id
+WHERE t.transaction_date BETWEEN :startDate AND :endDate AND t.confirm = 1 AND t.type = 'expense'
+  AND (:account_id IS NULL OR t.account_id = :account_id)
+GROUP BY tg.name, t.currency, t.transaction_date;
+
+-- name: CreateCategory :one
+-- Inserts a new category.
+INSERT INTO categories (created_at, name, parent_id, color)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListCategories :many
+-- Retrieves all the categories.
+SELECT * FROM categories ORDER BY name;
+
+-- name: GetCategory :one
+-- Retrieves a single category by ID.
+SELECT * FROM categories WHERE id = ?;
+
+-- name: UpdateCategory :one
+-- Updates a category by ID.
+UPDATE categories
+SET name = ?, parent_id = ?, color = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteCategory :exec
+-- Deletes a category by ID. Its subcategories are moved to the top level and
+-- its transactions keep the name of the category.
+DELETE FROM categories WHERE id = ?;
+
+-- name: ListCategoryAliases :many
+-- Retrieves the aliases of all the categories.
+SELECT * FROM category_aliases ORDER BY alias;
+
+-- name: AddCategoryAlias :exec
+-- Adds an alias to a category.
+INSERT INTO category_aliases (alias, category_id) VALUES (?, ?);
+
+-- name: DeleteCategoryAliases :exec
+-- Removes all the aliases of a category.
+DELETE FROM category_aliases WHERE category_id = ?;
+
+-- name: RenameTransactionsCategory :exec
+-- Moves the transactions of a category to its new name.
+UPDATE transactions SET category = :new_name WHERE category = :old_name COLLATE NOCASE;
+
+-- name: RenameBudgetsCategory :exec
+-- Moves the budgets of a category to its new name.
+UPDATE budgets SET category = :new_name WHERE category = :old_name;
+
+-- name: RenameRecurringRulesCategory :exec
+-- Moves the recurring rules of a category to its new name.
+UPDATE recurring_rules SET category = :new_name WHERE category = :old_name COLLATE NOCASE;
+
+-- name: RenameCorrectionsCategory :exec
+-- Moves the category corrections of a category to its new name.
+UPDATE category_corrections SET category = :new_name WHERE category = :old_name COLLATE NOCASE;
+
+-- name: UpsertCategoryCorrection :exec
+-- Saves the category which the user corrected a description to, replacing an older correction.
+INSERT INTO category_corrections (created_at, description, category)
+VALUES (?, ?, ?)
+ON CONFLICT (description) DO UPDATE SET category = excluded.category, created_at = excluded.created_at;
+
+-- name: SimilarCategoryCorrections :many
+-- Retrieves the category corrections whose description is the most similar to the
+-- full text query, the most similar first.
+SELECT c.description, c.category
+FROM category_corrections_fts f
+JOIN category_corrections c ON c.id = f.rowid
+WHERE category_corrections_fts MATCH :query
+ORDER BY bm25(category_corrections_fts), c.created_at DESC
+LIMIT :limit;
+
+-- name: CreateRule :one
+-- Inserts a new rule.
+INSERT INTO rules (created_at, name, priority, description_pattern, min_amount, max_amount, account_id, currency, set_category, add_tags, set_account_id, confirm)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListRules :many
+-- Retrieves all the rules in the order they're applied.
+SELECT * FROM rules ORDER BY priority, id;
+
+-- name: GetRule :one
+-- Retrieves a single rule by ID.
+SELECT * FROM rules WHERE id = ?;
+
+-- name: UpdateRule :one
+-- Updates a rule by ID.
+UPDATE rules
+SET name = ?, priority = ?, description_pattern = ?, min_amount = ?, max_amount = ?, account_id = ?, currency = ?, set_category = ?, add_tags = ?, set_account_id = ?, confirm = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteRule :exec
+-- Deletes a rule by ID.
+DELETE FROM rules WHERE id = ?;
+
+-- name: RenameRulesCategory :exec
+-- Moves the rules which set a category to its new name.
+UPDATE rules SET set_category = :new_name WHERE set_category = :old_name COLLATE NOCASE;
+
+-- name: ClaimIdempotencyKey :execrows
+-- Saves an idempotency key, unless it exists already.
+INSERT INTO idempotency_keys (key, created_at, request_hash) VALUES (?, ?, ?)
+ON CONFLICT (key) DO NOTHING;
+
+-- name: GetIdempotencyKey :one
+-- Retrieves an idempotency key.
+SELECT * FROM idempotency_keys WHERE key = ?;
+
+-- name: SetIdempotencyKeyEntry :exec
+-- Sets the entry which the request of an idempotency key saved.
+UPDATE idempotency_keys SET entry_id = ? WHERE key = ?;
+
+-- name: SetIdempotencyKeyResponse :exec
+-- Sets the response of the request of an idempotency key.
+UPDATE idempotency_keys SET response = ? WHERE key = ?;
+
+-- name: ReleaseIdempotencyKey :exec
+-- Deletes an idempotency key whose request hasn't saved anything, and was claimed before the given time.
+DELETE FROM idempotency_keys WHERE key = :key AND entry_id IS NULL AND created_at < :before;
+
+-- name: DeleteExpiredIdempotencyKeys :exec
+-- Deletes the idempotency keys created before the given time.
+DELETE FROM idempotency_keys WHERE created_at < ?;
+
+-- name: ListDuplicateCandidates :many
+-- Retrieves the transactions of other entries which a new transaction could be a duplicate of:
+-- the ones on the same day with the same amount, which were created after the given time.
+SELECT * FROM transactions
+WHERE amount = :amount AND currency = :currency AND type = :type
+  AND transaction_date >= :day_start AND transaction_date < :day_end
+  AND (entry_id IS NULL OR entry_id <> :entry_id)
+  AND duplicate_of IS NULL
+  AND created_at >= :created_after
+ORDER BY id;
+
+-- name: SetTransactionDuplicate :exec
+-- Flags a transaction as a duplicate of another, or clears the flag.
+UPDATE transactions SET duplicate_of = ? WHERE id = ?;
+
+-- name: ListDuplicateTransactions :many
+-- Retrieves the transactions which are flagged as duplicates.
+SELECT * FROM transactions WHERE duplicate_of IS NOT NULL ORDER BY transaction_date DESC, id DESC;
+
+-- name: CreateAttachment :one
+-- Attaches a file in the blob store to a transaction.
+INSERT INTO attachments (created_at, transaction_id, filename, mime_type, size, sha256)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListAttachmentsByTransaction :many
+-- Retrieves the attachments of a transaction.
+SELECT * FROM attachments WHERE transaction_id = ? ORDER BY id;
+
+-- name: GetAttachment :one
+-- Retrieves an attachment of a transaction.
+SELECT * FROM attachments WHERE id = :id AND transaction_id = :transaction_id;
+
+-- name: DeleteAttachment :one
+-- Deletes an attachment of a transaction, returning the hash of its content.
+DELETE FROM attachments WHERE id = :id AND transaction_id = :transaction_id
+RETURNING sha256;
+
+-- name: CountAttachmentsByHash :one
+-- Counts the attachments whose content has the given hash.
+SELECT COUNT(*) FROM attachments WHERE sha256 = ?;
+
+-- name: MoveAttachments :exec
+-- Moves the attachments of a duplicate transaction to its original, except the ones
+-- whose content the original already has.
+UPDATE attachments SET transaction_id = :original_id
+WHERE transaction_id = :duplicate_id
+  AND sha256 NOT IN (SELECT sha256 FROM attachments WHERE transaction_id = :original_id);
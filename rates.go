@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/internal/fx"
+)
+
+// runRatesCmd handles the `rates import <file>` command. Files ending in
+// .xml are read as ECB reference rates, everything else as CSV.
+func runRatesCmd(ctx context.Context, conn *sql.DB, cmd, path string, log *slog.Logger) error {
+	if cmd != "import" {
+		return fmt.Errorf("unknown rates command %q, use import", cmd)
+	}
+	if path == "" {
+		return errors.New("missing file to import")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening rates file: %w", err)
+	}
+	defer f.Close()
+
+	var rates []fx.Rate
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		rates, err = fx.ParseECB(f)
+	} else {
+		rates, err = fx.ParseCSV(f)
+	}
+	if err != nil {
+		return err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := db.New(tx)
+	for _, r := range rates {
+		if err := q.UpsertExchangeRate(ctx, db.UpsertExchangeRateParams{
+			Date:  r.Date,
+			Base:  r.Base,
+			Quote: r.Quote,
+			Rate:  r.Rate,
+		}); err != nil {
+			return fmt.Errorf("error saving rate: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Info("Imported exchange rates", "count", len(rates), "file", path)
+	return nil
+}
+
+// converter returns a converter which looks up rates in the database. Every
+// base currency which has rates is used as a pivot for cross rates.
+func (a *App) converter(ctx context.Context) (*fx.Converter, error) {
+	pivots, err := a.queries.ListExchangeRateBases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing exchange rate bases: %w", err)
+	}
+
+	lookup := func(ctx context.Context, base, quote string, date time.Time) (float64, bool, error) {
+		rate, err := a.queries.GetExchangeRate(ctx, db.GetExchangeRateParams{
+			Base:  base,
+			Quote: quote,
+			Date:  date,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		return rate, true, nil
+	}
+
+	return fx.NewConverter(lookup, pivots), nil
+}
+
+// currencyOf returns the currency code of an amount, defaulting to the
+// configured `app.currency` when it's empty.
+func (a *App) currencyOf(currency string) string {
+	if currency = strings.ToUpper(strings.TrimSpace(currency)); currency != "" {
+		return currency
+	}
+	return a.currency
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+
+	"github.com/mr-karan/gullak/internal/llm"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+const (
+	// maxReceiptImages is the number of photos of a receipt, for one too long to fit in one.
+	maxReceiptImages = 5
+	// maxReceiptImageSize is the size limit of a photo of a receipt, in bytes.
+	maxReceiptImageSize = 10 << 20
+)
+
+// receiptTypes are the image formats which vision models read.
+var receiptTypes = []string{"image/jpeg", "image/png", "image/webp", "image/gif"}
+
+// receiptImage is an uploaded photo of a receipt.
+type receiptImage struct {
+	filename string
+	llm.Image
+}
+
+// readReceipt reads the uploaded photos of a receipt, checking their number, size and format.
+func readReceipt(files []*multipart.FileHeader) ([]receiptImage, error) {
+	if len(files) == 0 {
+		return nil, &uploadError{"image is required"}
+	}
+	if len(files) > maxReceiptImages {
+		return nil, &uploadError{fmt.Sprintf("a receipt can have at most %d images", maxReceiptImages)}
+	}
+
+	images := make([]receiptImage, 0, len(files))
+	for _, fh := range files {
+		data, typ, err := readUpload(fh, maxReceiptImageSize, receiptTypes)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, receiptImage{
+			filename: filepath.Base(fh.Filename),
+			Image:    llm.Image{MIMEType: typ, Data: data},
+		})
+	}
+	return images, nil
+}
+
+// saveReceipt saves the transactions read from the photos of a receipt, and attaches
+// the photos to every one of them.
+func (a *App) saveReceipt(ctx context.Context, images []receiptImage, res llm.Result) ([]models.Item, error) {
+	a.blobs.mu.Lock()
+	defer a.blobs.mu.Unlock()
+
+	attachments := make([]attachment, len(images))
+	hashes := make([]string, len(images))
+	for i, img := range images {
+		hash, err := a.blobs.put(img.Data)
+		if err != nil {
+			return nil, err
+		}
+		attachments[i] = attachment{
+			Filename: img.filename,
+			MIMEType: img.MIMEType,
+			Size:     int64(len(img.Data)),
+			Hash:     hash,
+		}
+		hashes[i] = hash
+	}
+
+	saved, err := a.Save(ctx, receiptLine(images), res, "", attachments...)
+	if err != nil {
+		a.removeOrphanBlobs(ctx, hashes)
+		return nil, err
+	}
+	return saved, nil
+}
+
+// receiptLine is the input line saved for the entry of a receipt, as there's no text.
+func receiptLine(images []receiptImage) string {
+	names := make([]string, len(images))
+	for i, img := range images {
+		names[i] = img.filename
+	}
+	return "Receipt: " + strings.Join(names, ", ")
+}
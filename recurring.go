@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/internal/fx"
+	"github.com/mr-karan/gullak/internal/recur"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// schedulerInterval is how often recurring rules are checked for due transactions.
+const schedulerInterval = time.Hour
+
+// backfillWindow is how far back the missed transactions of a rule are created. Older
+// occurrences are skipped, so that a daily rule which started years ago doesn't add
+// thousands of transactions at once.
+const backfillWindow = 90 * 24 * time.Hour
+
+// runScheduler creates the transactions of recurring rules on startup and then
+// every schedulerInterval, until ctx is cancelled.
+func (a *App) runScheduler(ctx context.Context) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := a.createRecurringTransactions(ctx, time.Now()); err != nil {
+			a.log.Error("Error creating recurring transactions", "error", err)
+		} else if n > 0 {
+			a.log.Info("Created recurring transactions", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// createRecurringTransactions creates an unconfirmed transaction for every occurrence of
+// every rule which is due on or before now, including the ones missed while Gullak wasn't
+// running, up to backfillWindow ago. Each rule is advanced in the same database transaction as its transactions are
+// created, and a rule can't have two transactions on a date, so it's safe to run again.
+func (a *App) createRecurringTransactions(ctx context.Context, now time.Time) (int, error) {
+	a.recurMu.Lock()
+	defer a.recurMu.Unlock()
+
+	today := dateOf(now)
+	rules, err := a.queries.ListDueRecurringRules(ctx, &today)
+	if err != nil {
+		return 0, fmt.Errorf("error listing due recurring rules: %w", err)
+	}
+
+	var created int
+	for _, rule := range rules {
+		n, err := a.advanceRecurringRule(ctx, rule, today)
+		created += n
+		if err != nil {
+			return created, fmt.Errorf("error advancing recurring rule %d: %w", rule.ID, err)
+		}
+	}
+	return created, nil
+}
+
+// advanceRecurringRule creates the transactions of the rule which are due on or before
+// today and moves it to its next due date.
+func (a *App) advanceRecurringRule(ctx context.Context, rule db.RecurringRule, today time.Time) (int, error) {
+	r, err := recur.Parse(rule.Rrule)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	q := a.queries.WithTx(tx)
+
+	var created int
+	next, occurrences := rule.NextDue, rule.Occurrences
+	if oldest := today.Add(-backfillWindow); next != nil && next.Before(oldest) {
+		a.log.Warn("Skipping recurring transactions due before the backfill window", "id", rule.ID, "from", next.Format("2006-01-02"), "to", oldest.Format("2006-01-02"))
+		next = nil
+		if d, n, ok := r.Next(rule.StartDate, oldest); ok {
+			next, occurrences = &d, int64(n)
+		}
+	}
+	for next != nil && !next.After(today) {
+		n, err := q.CreateRecurringTransaction(ctx, db.CreateRecurringTransactionParams{
+			CreatedAt:       time.Now(),
+			TransactionDate: *next,
+			Amount:          rule.Amount,
+			Currency:        rule.Currency,
+			Category:        rule.Category,
+			Description:     rule.Description,
+			Type:            rule.Type,
+			AccountID:       rule.AccountID,
+			RecurringRuleID: &rule.ID,
+		})
+		if err != nil {
+			return 0, err
+		}
+		created += int(n)
+
+		occurrences++
+		next = nil
+		if d, ok := r.Occurrence(rule.StartDate, int(occurrences)); ok {
+			next = &d
+		}
+	}
+
+	if err := q.AdvanceRecurringRule(ctx, db.AdvanceRecurringRuleParams{
+		NextDue:     next,
+		Occurrences: occurrences,
+		ID:          rule.ID,
+	}); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+// validateRecurringRule normalises the recurring rule input and checks that it's
+// valid. It returns the parsed rule and start date.
+func (a *App) validateRecurringRule(input *models.RecurringRule) (recur.Rule, time.Time, error) {
+	input.Description = strings.TrimSpace(input.Description)
+	t, err := a.loadTaxonomy(context.Background())
+	if err != nil {
+		return recur.Rule{}, time.Time{}, err
+	}
+	input.Category = t.resolve(input.Category)
+	if input.Category == "" {
+		return recur.Rule{}, time.Time{}, errors.New("category is required")
+	}
+
+	typ, err := transactionType(input.Type)
+	if err != nil {
+		return recur.Rule{}, time.Time{}, err
+	}
+	if typ == models.TypeTransfer {
+		return recur.Rule{}, time.Time{}, errors.New("invalid type, use one of expense, income")
+	}
+	input.Type = typ
+
+	if input.Amount.Sign() <= 0 {
+		return recur.Rule{}, time.Time{}, errors.New("amount must be positive")
+	}
+	input.Currency = a.currencyOf(input.Currency)
+	if !fx.IsCurrency(input.Currency) {
+		return recur.Rule{}, time.Time{}, errors.New("invalid currency, use an ISO 4217 code like USD")
+	}
+
+	if input.AccountID != nil {
+		if _, err := a.queries.GetAccount(context.Background(), *input.AccountID); err != nil {
+			return recur.Rule{}, time.Time{}, errors.New("invalid account_id")
+		}
+	}
+
+	r, err := recur.Parse(input.RRule)
+	if err != nil {
+		return recur.Rule{}, time.Time{}, fmt.Errorf("invalid rrule: %w", err)
+	}
+	input.RRule = r.String()
+
+	// Rules start today unless a start date is given.
+	start := dateOf(time.Now())
+	if input.StartDate != "" {
+		if start, err = time.Parse("2006-01-02", input.StartDate); err != nil {
+			return recur.Rule{}, time.Time{}, errors.New("invalid start_date format, use YYYY-MM-DD")
+		}
+	}
+	input.StartDate = start.Format("2006-01-02")
+
+	return r, start, nil
+}
+
+// toRecurringRule converts a recurring rule row to its API representation.
+func toRecurringRule(rule db.RecurringRule) models.RecurringRule {
+	out := models.RecurringRule{
+		ID:          rule.ID,
+		CreatedAt:   rule.CreatedAt.Format(time.RFC3339),
+		Description: rule.Description,
+		Category:    rule.Category,
+		Type:        rule.Type,
+		Amount:      models.FromMinor(rule.Amount, rule.Currency),
+		Currency:    rule.Currency,
+		AccountID:   rule.AccountID,
+		RRule:       rule.Rrule,
+		StartDate:   rule.StartDate.Format("2006-01-02"),
+	}
+	if rule.NextDue != nil {
+		out.NextDue = rule.NextDue.Format("2006-01-02")
+	}
+	return out
+}
+
+// dateOf returns the date of t, at midnight UTC like the dates parsed from the API.
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
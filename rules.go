@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/internal/fx"
+	"github.com/mr-karan/gullak/internal/llm"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// rePatternLiteral matches a pattern written like /swiggy|zomato/i.
+var rePatternLiteral = regexp.MustCompile(`^/(.*)/([imsU]*)$`)
+
+// compiledRule is a rule which is ready to be matched against transactions.
+type compiledRule struct {
+	models.Rule
+	re *regexp.Regexp
+}
+
+// compilePattern compiles the description pattern of a rule. Besides Go's syntax, it
+// accepts the /pattern/flags form, where the flags are the ones Go supports, eg: i.
+func compilePattern(p string) (*regexp.Regexp, error) {
+	if m := rePatternLiteral.FindStringSubmatch(p); m != nil {
+		p = m[1]
+		if m[2] != "" {
+			p = "(?" + m[2] + ")" + p
+		}
+	}
+	return regexp.Compile(p)
+}
+
+// compileRule compiles the pattern of a rule.
+func compileRule(r models.Rule) (compiledRule, error) {
+	c := compiledRule{Rule: r}
+	if r.DescriptionPattern != "" {
+		re, err := compilePattern(r.DescriptionPattern)
+		if err != nil {
+			return compiledRule{}, err
+		}
+		c.re = re
+	}
+	return c, nil
+}
+
+// loadRules returns the rules in the order they're applied.
+func (a *App) loadRules(ctx context.Context) ([]compiledRule, error) {
+	rows, err := a.queries.ListRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing rules: %w", err)
+	}
+
+	rules := make([]compiledRule, len(rows))
+	for i, r := range rows {
+		if rules[i], err = compileRule(a.toRule(r)); err != nil {
+			return nil, fmt.Errorf("error compiling rule %d: %w", r.ID, err)
+		}
+	}
+	return rules, nil
+}
+
+// matches reports whether the transaction matches all the conditions of the rule.
+// Without a currency, the amount range applies to the amount in any currency.
+func (r compiledRule) matches(item models.Item) bool {
+	if r.re != nil && !r.re.MatchString(item.Description) {
+		return false
+	}
+	if r.Currency != "" && !strings.EqualFold(r.Currency, item.Currency) {
+		return false
+	}
+	if r.AccountID != nil && (item.AccountID == nil || *item.AccountID != *r.AccountID) {
+		return false
+	}
+	// An amount which can't be compared with the bounds doesn't match them.
+	if r.MinAmount != nil {
+		if c, err := item.Amount.Cmp(*r.MinAmount); err != nil || c < 0 {
+			return false
+		}
+	}
+	if r.MaxAmount != nil {
+		if c, err := item.Amount.Cmp(*r.MaxAmount); err != nil || c > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// apply applies the actions of the rule to the transaction, if it matches. It reports
+// whether the transaction was changed.
+func (r compiledRule) apply(item *models.Item) bool {
+	if !r.matches(*item) {
+		return false
+	}
+
+	var changed bool
+	if r.SetCategory != "" && item.Category != r.SetCategory {
+		item.Category = r.SetCategory
+		changed = true
+	}
+	if r.SetAccountID != nil && (item.AccountID == nil || *item.AccountID != *r.SetAccountID) {
+		id := *r.SetAccountID
+		item.AccountID = &id
+		changed = true
+	}
+	for _, t := range r.AddTags {
+		if !slices.Contains(item.Tags, t) {
+			item.Tags = append(item.Tags, t)
+			changed = true
+		}
+	}
+	if r.Confirm && !item.Confirm {
+		item.Confirm = true
+		changed = true
+	}
+	return changed
+}
+
+// applyRules applies the rules to the transaction in order, so that the category and
+// account of a later rule win while tags add up.
+func applyRules(rules []compiledRule, item *models.Item) {
+	for _, r := range rules {
+		r.apply(item)
+	}
+}
+
+// validateRule normalises the rule input and checks that it's valid.
+func (a *App) validateRule(ctx context.Context, r *models.Rule) error {
+	r.Name = strings.TrimSpace(r.Name)
+
+	r.DescriptionPattern = strings.TrimSpace(r.DescriptionPattern)
+	if _, err := compilePattern(r.DescriptionPattern); err != nil {
+		return fmt.Errorf("invalid description_pattern: %w", err)
+	}
+
+	r.Currency = strings.ToUpper(strings.TrimSpace(r.Currency))
+	if r.Currency != "" && !fx.IsCurrency(r.Currency) {
+		return errors.New("invalid currency, use an ISO 4217 code like USD")
+	}
+
+	// The amounts are saved in minor units of the rule's currency, or the default one.
+	currency := a.currencyOf(r.Currency)
+	for _, amt := range []*models.Decimal{r.MinAmount, r.MaxAmount} {
+		if amt == nil {
+			continue
+		}
+		if amt.Sign() < 0 {
+			return errors.New("min_amount and max_amount can't be negative")
+		}
+		if _, err := amt.Minor(currency); err != nil {
+			return fmt.Errorf("invalid amount %s: %w", amt, err)
+		}
+	}
+	if r.MinAmount != nil && r.MaxAmount != nil {
+		c, err := r.MinAmount.Cmp(*r.MaxAmount)
+		if err != nil {
+			return fmt.Errorf("invalid min_amount or max_amount: %w", err)
+		}
+		if c > 0 {
+			return errors.New("min_amount can't be more than max_amount")
+		}
+	}
+
+	for _, id := range []*int64{r.AccountID, r.SetAccountID} {
+		if id == nil {
+			continue
+		}
+		if _, err := a.queries.GetAccount(ctx, *id); err != nil {
+			return fmt.Errorf("invalid account ID %d", *id)
+		}
+	}
+
+	if r.SetCategory = strings.TrimSpace(r.SetCategory); r.SetCategory != "" {
+		t, err := a.loadTaxonomy(ctx)
+		if err != nil {
+			return err
+		}
+		r.SetCategory = t.resolve(r.SetCategory)
+	}
+
+	// Tags are saved comma separated.
+	r.AddTags = llm.NormalizeTags(r.AddTags)
+	for _, t := range r.AddTags {
+		if strings.Contains(t, ",") {
+			return fmt.Errorf("invalid tag %q, tags can't have commas", t)
+		}
+	}
+	if r.AddTags == nil {
+		r.AddTags = []string{}
+	}
+
+	if r.SetCategory == "" && len(r.AddTags) == 0 && r.SetAccountID == nil && !r.Confirm {
+		return errors.New("rule has no actions, set one of set_category, add_tags, set_account_id, confirm")
+	}
+	return nil
+}
+
+// ruleParams converts the amounts and tags of a validated rule to the way they're saved.
+func (a *App) ruleParams(r models.Rule) (minAmount, maxAmount *int64, tags string, err error) {
+	currency := a.currencyOf(r.Currency)
+	if r.MinAmount != nil {
+		v, err := r.MinAmount.Minor(currency)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		minAmount = &v
+	}
+	if r.MaxAmount != nil {
+		v, err := r.MaxAmount.Minor(currency)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		maxAmount = &v
+	}
+	return minAmount, maxAmount, strings.Join(r.AddTags, ","), nil
+}
+
+// toRule converts a rule row to its API representation.
+func (a *App) toRule(r db.Rule) models.Rule {
+	out := models.Rule{
+		ID:                 r.ID,
+		CreatedAt:          r.CreatedAt.Format(time.RFC3339),
+		Name:               r.Name,
+		Priority:           r.Priority,
+		DescriptionPattern: r.DescriptionPattern,
+		AccountID:          r.AccountID,
+		Currency:           r.Currency,
+		SetCategory:        r.SetCategory,
+		AddTags:            []string{},
+		SetAccountID:       r.SetAccountID,
+		Confirm:            r.Confirm,
+	}
+
+	currency := a.currencyOf(r.Currency)
+	if r.MinAmount != nil {
+		v := models.FromMinor(*r.MinAmount, currency)
+		out.MinAmount = &v
+	}
+	if r.MaxAmount != nil {
+		v := models.FromMinor(*r.MaxAmount, currency)
+		out.MaxAmount = &v
+	}
+	if r.AddTags != "" {
+		out.AddTags = strings.Split(r.AddTags, ",")
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// defaultSearchLimit is the number of transactions a search returns when no limit is passed.
+const defaultSearchLimit = 50
+
+// searchQuery builds a full text query which matches transactions with all the words
+// of the search, or with any of them when anyWord is set. The last word also matches
+// words which start with it, eg: "dinner toi" matches "dinner at Toit", as it may not be
+// typed out yet. With anyWord, every word matches the words which start with it, and the
+// transactions with more of the words rank higher.
+func searchQuery(q string, anyWord bool) string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	var unique []string
+	seen := map[string]bool{}
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			unique = append(unique, w)
+		}
+	}
+
+	terms := make([]string, len(unique))
+	for i, w := range unique {
+		terms[i] = `"` + w + `"`
+		if anyWord || i == len(unique)-1 {
+			terms[i] += "*"
+		}
+	}
+
+	if anyWord {
+		return strings.Join(terms, " OR ")
+	}
+	// Terms next to each other all have to match.
+	return strings.Join(terms, " ")
+}
+
+// highlight escapes the snippet of a search result for HTML and wraps its matches,
+// which SearchTransactions marks with control characters, in <mark> tags.
+func highlight(snippet string) string {
+	return strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>").Replace(html.EscapeString(snippet))
+}
+
+// searchTransactions returns the transactions which match the search, the most relevant
+// first. They match all the words of the search, or any of them with anyWord.
+func (a *App) searchTransactions(ctx context.Context, q string, anyWord bool, params db.SearchTransactionsParams) ([]models.SearchResult, error) {
+	results := []models.SearchResult{}
+	if params.Query = searchQuery(q, anyWord); params.Query == "" {
+		return results, nil
+	}
+
+	rows, err := a.queries.SearchTransactions(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("error searching transactions: %w", err)
+	}
+
+	items := make([]models.Item, len(rows))
+	for i, r := range rows {
+		items[i] = toItem(db.Transaction{
+			ID:                r.ID,
+			CreatedAt:         r.CreatedAt,
+			TransactionDate:   r.TransactionDate,
+			Currency:          r.Currency,
+			Amount:            r.Amount,
+			Category:          r.Category,
+			Description:       r.Description,
+			Confirm:           r.Confirm,
+			NeedsReparse:      r.NeedsReparse,
+			EntryID:           r.EntryID,
+			AccountID:         r.AccountID,
+			Type:              r.Type,
+			TransferAccountID: r.TransferAccountID,
+			RecurringRuleID:   r.RecurringRuleID,
+			Version:           r.Version,
+			DuplicateOf:       r.DuplicateOf,
+		})
+	}
+	if err := a.withTags(ctx, items); err != nil {
+		return nil, err
+	}
+
+	for i, r := range rows {
+		results = append(results, models.SearchResult{
+			Item:    items[i],
+			Snippet: highlight(r.Snippet),
+			Score:   r.Score,
+		})
+	}
+	return results, nil
+}
@@ -4,66 +4,107 @@ import (
 	"context"
 	"database/sql"
 	_ "embed"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/internal/fx"
+	"github.com/mr-karan/gullak/internal/llm"
 	"github.com/mr-karan/gullak/pkg/models"
 	_ "modernc.org/sqlite"
 )
 
-const (
-	DEFAULT_CURRENCY = "INR"
-)
-
 //go:embed pragmas.sql
 var pragmas string
 
-func createTableSQL(currency string) string {
-	return fmt.Sprintf(`
-        CREATE TABLE IF NOT EXISTS transactions (
-            id INTEGER PRIMARY KEY AUTOINCREMENT,
-            created_at DATETIME NOT NULL DEFAULT (datetime('now')),
-            transaction_date DATE NOT NULL,
-            currency TEXT NOT NULL DEFAULT '%s',
-            amount FLOAT NOT NULL,
-            category TEXT NOT NULL,
-            description TEXT NOT NULL DEFAULT '',
-            confirm BOOLEAN NOT NULL DEFAULT false
-        );
-    `, currency)
+// connPragmas are the PRAGMA statements which only apply to the connection they're run
+// on. They're set in the DSN, so that every connection of the pool runs them when it's
+// opened, not just the one which happens to run pragmas.sql.
+var connPragmas = []string{
+	"busy_timeout(5000)",          // Wait for 5s before returning an error if the DB is locked/busy.
+	"foreign_keys(1)",             // Enable foreign key constraints, which delete the tags and attachments of transactions.
+	"synchronous(NORMAL)",         // Dont wait for the data to be flushed to disk.
+	"temp_store(MEMORY)",          // Use memory instead of disk for temp storage.
+	"cache_size(-16000)",          // Set the cache size to 16MB. Useful for reducing disk IO.
+	"journal_size_limit(5000000)", // Limit is in bytes, set to 5 MB
 }
 
-func initDB(path string, currency string) (*db.Queries, error) {
-	conn, err := sql.Open("sqlite", path)
+// initDB opens the database. The schema is managed by migrations, see migrate.go.
+func initDB(path string) (*sql.DB, error) {
+	// The path may have a query string of its own, eg: file:x.db?mode=rwc.
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	dsn := path + sep + "_pragma=" + strings.Join(connPragmas, "&_pragma=")
+	conn, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("error opening database: %w", err)
 	}
 
-	if currency == "" {
-		currency = DEFAULT_CURRENCY
-	}
-
-	// Create the table if it doesn't exist.
-	if _, err = conn.Exec(createTableSQL(currency)); err != nil {
-		return nil, fmt.Errorf("error creating tables: %w", err)
-	}
-
 	// PRAGMA statements aren't recognised by sqlc:https://github.com/sqlc-dev/sqlc/issues/3237.
 	if _, err = conn.Exec(pragmas); err != nil {
 		return nil, fmt.Errorf("error running PRAGMA statements: %w", err)
 	}
 
-	queries := db.New(conn)
-
-	return queries, nil
+	return conn, nil
 }
 
 // SaveTransactions saves the transactions to the database using the generated CreateTransaction method.
-func (a *App) Save(transactions models.Transactions) ([]db.Transaction, error) {
-	var savedTransactions []db.Transaction
+// The transactions of the line are saved in a single database transaction, along with
+// an entry of the line and how it was parsed.
+func (a *App) Save(ctx context.Context, line string, res llm.Result, idempotencyKey string, attachments ...attachment) ([]models.Item, error) {
+	accounts, err := a.queries.ListAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing accounts: %w", err)
+	}
+
+	t, err := a.loadTaxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := a.loadRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The amounts are converted to check them against the maximum of the policy.
+	var conv *fx.Converter
+	if a.confirm.enabled() && !res.Offline {
+		if conv, err = a.converter(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Either all or none of the transactions of the line are saved.
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	q := a.queries.WithTx(tx)
+
+	// Every transaction is linked to the entry of the line it was parsed from.
+	entry, err := q.CreateEntry(ctx, db.CreateEntryParams{
+		CreatedAt:        time.Now(),
+		Line:             line,
+		Parser:           res.Parser,
+		Model:            res.Model,
+		PromptVersion:    res.PromptVersion,
+		LatencyMs:        res.Latency.Milliseconds(),
+		PromptTokens:     int64(res.Usage.PromptTokens),
+		CompletionTokens: int64(res.Usage.CompletionTokens),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error saving entry in db: %w", err)
+	}
 
-	for _, item := range transactions.Transactions {
+	var savedTransactions []models.Item
+
+	for _, item := range res.Transactions.Transactions {
 		var transactDate time.Time
 		var err error
 
@@ -77,20 +118,110 @@ func (a *App) Save(transactions models.Transactions) ([]db.Transaction, error) {
 			}
 		}
 
+		// The currency is optional in the input, fallback to the configured one.
+		currency := a.currencyOf(item.Currency)
+		amount, err := item.Amount.Minor(currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount %s: %w", item.Amount, err)
+		}
+
+		typ, err := transactionType(item.Type)
+		if err != nil {
+			a.log.Warn("Invalid transaction type, saving as an expense", "type", item.Type, "description", item.Description)
+			typ = models.TypeExpense
+		}
+
+		// Only transfers have a destination account.
+		var transferAccountID *int64
+		if typ == models.TypeTransfer {
+			transferAccountID = a.resolveAccount(accounts, item.TransferAccount)
+		}
+
+		// Trusted transactions are confirmed, as per the policy. The rules have the final
+		// say over the category, account, tags and confirmation.
+		ruled := models.Item{
+			Currency:    currency,
+			Amount:      item.Amount,
+			Category:    t.resolve(item.Category),
+			Description: item.Description,
+			AccountID:   a.resolveAccount(accounts, item.Account),
+			Tags:        item.Tags,
+			Confidence:  item.Confidence,
+		}
+		// The transactions of the offline parser are always reviewed.
+		ruled.Confirm = conv != nil && a.trusts(ctx, conv, t, ruled, transactDate)
+		applyRules(rules, &ruled)
+
+		// The transactions of the offline parser are flagged to be parsed again by the model.
 		arg := db.CreateTransactionParams{
-			CreatedAt:       time.Now(),
-			TransactionDate: transactDate,
-			Amount:          item.Amount,
-			Currency:        item.Currency,
-			Category:        item.Category,
-			Description:     item.Description,
+			CreatedAt:         time.Now(),
+			TransactionDate:   transactDate,
+			Amount:            amount,
+			Currency:          currency,
+			Category:          ruled.Category,
+			Description:       item.Description,
+			Confirm:           ruled.Confirm,
+			NeedsReparse:      res.Offline,
+			EntryID:           &entry.ID,
+			AccountID:         ruled.AccountID,
+			Type:              typ,
+			TransferAccountID: transferAccountID,
 		}
 
-		savedTx, err := a.queries.CreateTransaction(context.TODO(), arg)
+		savedTx, err := q.CreateTransaction(ctx, arg)
 		if err != nil {
 			return nil, fmt.Errorf("error saving in db: %w", err)
 		}
-		savedTransactions = append(savedTransactions, savedTx...)
+		for _, t := range savedTx {
+			saved := toItem(t)
+			if saved.Tags, err = setTags(ctx, q, t.ID, ruled.Tags); err != nil {
+				return nil, err
+			}
+
+			// Transactions which look like duplicates of existing ones are flagged for review.
+			if saved.DuplicateOf, err = findDuplicate(ctx, q, t); err != nil {
+				return nil, err
+			}
+			if saved.DuplicateOf != nil {
+				if err := q.SetTransactionDuplicate(ctx, db.SetTransactionDuplicateParams{
+					DuplicateOf: saved.DuplicateOf,
+					ID:          t.ID,
+				}); err != nil {
+					return nil, fmt.Errorf("error flagging duplicate: %w", err)
+				}
+			}
+
+			// The attachments, like the photos of a receipt, are attached to every transaction.
+			for _, att := range attachments {
+				row, err := q.CreateAttachment(ctx, db.CreateAttachmentParams{
+					CreatedAt:     time.Now(),
+					TransactionID: t.ID,
+					Filename:      att.Filename,
+					MimeType:      att.MIMEType,
+					Size:          att.Size,
+					Sha256:        att.Hash,
+				})
+				if err != nil {
+					return nil, fmt.Errorf("error saving attachment: %w", err)
+				}
+				saved.Attachments = append(saved.Attachments, toAttachment(row))
+			}
+			savedTransactions = append(savedTransactions, saved)
+		}
+	}
+
+	// The idempotency key of the request is marked as done along with the transactions.
+	if idempotencyKey != "" {
+		if err := q.SetIdempotencyKeyEntry(ctx, db.SetIdempotencyKeyEntryParams{
+			EntryID: &entry.ID,
+			Key:     idempotencyKey,
+		}); err != nil {
+			return nil, fmt.Errorf("error saving idempotency key: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error saving in db: %w", err)
 	}
 	return savedTransactions, nil
 }
@@ -102,31 +233,56 @@ func (a *App) Get(id int64) (models.Item, error) {
 		return models.Item{}, fmt.Errorf("error getting transaction: %w", err)
 	}
 
-	return models.Item{
-		CreatedAt:       transaction.CreatedAt.Format(time.RFC3339),
-		TransactionDate: transaction.TransactionDate.Format("2006-01-02"),
-		Currency:        transaction.Currency,
-		Amount:          transaction.Amount,
-		Category:        transaction.Category,
-		Description:     transaction.Description,
-		Confirm:         transaction.Confirm,
-	}, nil
+	return toItem(transaction), nil
 }
 
-// Update updates a transaction in the database.
-func (a *App) Update(id int64, transaction models.Item) error {
-	arg := db.UpdateTransactionParams{
-		Amount:      transaction.Amount,
-		Currency:    transaction.Currency,
-		Category:    transaction.Category,
-		Description: transaction.Description,
-		Confirm:     transaction.Confirm,
-		ID:          id,
+// errStale is returned when a transaction is updated based on an older version of it.
+var errStale = errors.New("transaction has changed since it was retrieved, retrieve it again")
+
+// toItem converts a transaction row to its API representation, with the
+// amount converted from minor units to a decimal.
+func toItem(t db.Transaction) models.Item {
+	return models.Item{
+		ID:                t.ID,
+		CreatedAt:         t.CreatedAt.Format(time.RFC3339),
+		TransactionDate:   t.TransactionDate.Format("2006-01-02"),
+		Currency:          t.Currency,
+		Amount:            models.FromMinor(t.Amount, t.Currency),
+		Category:          t.Category,
+		Description:       t.Description,
+		Confirm:           t.Confirm,
+		NeedsReparse:      t.NeedsReparse,
+		EntryID:           t.EntryID,
+		AccountID:         t.AccountID,
+		Type:              t.Type,
+		TransferAccountID: t.TransferAccountID,
+		RecurringRuleID:   t.RecurringRuleID,
+		Version:           t.Version,
+		DuplicateOf:       t.DuplicateOf,
 	}
+}
 
-	if err := a.queries.UpdateTransaction(context.TODO(), arg); err != nil {
-		return fmt.Errorf("error updating transaction: %w", err)
+// isUniqueErr reports whether err is a violation of a UNIQUE constraint.
+func isUniqueErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// transactionType validates the type of a transaction, which defaults to an expense.
+func transactionType(t string) (string, error) {
+	switch t = strings.ToLower(strings.TrimSpace(t)); t {
+	case "":
+		return models.TypeExpense, nil
+	case models.TypeExpense, models.TypeIncome, models.TypeTransfer:
+		return t, nil
 	}
+	return "", fmt.Errorf("invalid type %q, use one of expense, income, transfer", t)
+}
 
-	return nil
+// toItems converts transaction rows to their API representation.
+func toItems(transactions []db.Transaction) []models.Item {
+	items := make([]models.Item, len(transactions))
+	for i, t := range transactions {
+		items[i] = toItem(t)
+	}
+	return items
 }
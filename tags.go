@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mr-karan/gullak/internal/db"
+	"github.com/mr-karan/gullak/internal/llm"
+	"github.com/mr-karan/gullak/pkg/models"
+)
+
+// setTags replaces the tags of a transaction, creating the tags which don't exist yet.
+// It returns the normalised tags.
+func setTags(ctx context.Context, q *db.Queries, id int64, tags []string) ([]string, error) {
+	// A transaction without tags has an empty list of them, as when it's retrieved.
+	tags = llm.NormalizeTags(tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	if err := q.DeleteTransactionTags(ctx, id); err != nil {
+		return nil, fmt.Errorf("error removing tags: %w", err)
+	}
+
+	for _, name := range tags {
+		tag, err := q.UpsertTag(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("error saving tag %s: %w", name, err)
+		}
+		if err := q.AddTransactionTag(ctx, db.AddTransactionTagParams{
+			TransactionID: id,
+			TagID:         tag.ID,
+		}); err != nil {
+			return nil, fmt.Errorf("error tagging transaction: %w", err)
+		}
+	}
+	return tags, nil
+}
+
+// withTags loads the tags of each transaction. It's meant for a handful of
+// transactions, lists use ListTransactionTags instead.
+func (a *App) withTags(ctx context.Context, items []models.Item) error {
+	for i := range items {
+		tags, err := a.queries.ListTagsByTransaction(ctx, items[i].ID)
+		if err != nil {
+			return fmt.Errorf("error retrieving tags: %w", err)
+		}
+		items[i].Tags = tags
+	}
+	return nil
+}
+
+// attachTags sets the tags of the transactions from the rows of ListTransactionTags.
+func attachTags(items []models.Item, rows []db.ListTransactionTagsRow) {
+	tags := map[int64][]string{}
+	for _, r := range rows {
+		tags[r.TransactionID] = append(tags[r.TransactionID], r.Name)
+	}
+	for i := range items {
+		items[i].Tags = tags[items[i].ID]
+		if items[i].Tags == nil {
+			items[i].Tags = []string{}
+		}
+	}
+}
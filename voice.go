@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mr-karan/gullak/internal/llm"
+)
+
+// maxAudioSize is the size limit of a voice note, in bytes. It's the limit of OpenAI's
+// transcriptions API.
+const maxAudioSize = 25 << 20
+
+// audioFormats are the extensions of the formats which transcription endpoints accept.
+// Unlike images, the formats can't be told apart reliably from the content, eg: an m4a
+// recording from an iPhone.
+var audioFormats = []string{".flac", ".m4a", ".mp3", ".mp4", ".mpeg", ".mpga", ".oga", ".ogg", ".wav", ".webm"}
+
+// readAudio reads an uploaded voice note, checking its size and format.
+func readAudio(fh *multipart.FileHeader) (llm.Audio, error) {
+	name := filepath.Base(fh.Filename)
+	if !slices.Contains(audioFormats, strings.ToLower(filepath.Ext(name))) {
+		return llm.Audio{}, fmt.Errorf("%s isn't a supported audio file, use one of %s", name, strings.Join(audioFormats, ", "))
+	}
+	if fh.Size > maxAudioSize {
+		return llm.Audio{}, fmt.Errorf("%s is larger than %d MB", name, maxAudioSize>>20)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return llm.Audio{}, fmt.Errorf("error reading %s: %w", name, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxAudioSize+1))
+	if err != nil {
+		return llm.Audio{}, fmt.Errorf("error reading %s: %w", name, err)
+	}
+	if len(data) > maxAudioSize {
+		return llm.Audio{}, fmt.Errorf("%s is larger than %d MB", name, maxAudioSize>>20)
+	}
+
+	return llm.Audio{Filename: name, Data: data}, nil
+}